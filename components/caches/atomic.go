@@ -0,0 +1,28 @@
+package caches
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotInteger Incr / Decr 的 key 已存在，但不是整数
+var ErrNotInteger = errors.New("caches: value is not an integer")
+
+// Atomic 可选接口，后端支持原子操作时实现，用于限流、风控、幂等等需要多实例一致的场景
+//
+//	if a, ok := cache.(caches.Atomic); ok {
+//		n, err := a.Incr("login:42", 1, time.Minute)
+//	}
+type Atomic interface {
+	// Incr 原子加 delta 并返回新值；key 不存在时从 0 开始并使用 ttl，已存在时保持原有的过期时间
+	Incr(key string, delta int64, ttl time.Duration) (int64, error)
+
+	// Decr 同 Incr，减 delta
+	Decr(key string, delta int64, ttl time.Duration) (int64, error)
+
+	// SetNX key 不存在时写入，返回是否写入
+	SetNX(key string, value string, ttl time.Duration) (bool, error)
+
+	// CompareAndSwap 当前值等于 old 时写入 new 并使用新的 ttl，返回是否写入；key 不存在时不写入
+	CompareAndSwap(key string, old string, new string, ttl time.Duration) (bool, error)
+}
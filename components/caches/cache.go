@@ -4,36 +4,39 @@ import "time"
 
 type (
 	// Cache is the top-level cache interface
+	// 所有实现需通过 cachetest.Run 的一致性测试
 	Cache interface {
 
-		// GenerateCacheKey 生产缓存 key ； bucket 用于内部业务隔离
+		// GenerateCacheKey 生产缓存 key ； bucket 用于内部业务隔离，格式为 "bucket:key"
 		GenerateCacheKey(bucket string, key string) string
 
-		// Get retrieve the cached key value
+		// Get retrieve the cached key value，不存在或已过期返回 ErrNotFound
 		Get(key string) (string, error)
 
-		// GetMulti retrieve multiple cached keys value
+		// GetMulti retrieve multiple cached keys value，只包含命中的 key
 		GetMulti(keys []string) map[string]string
 
-		// Set cache a value by key
+		// Set cache a value by key，ttl 为 0 表示不过期
 		Set(key string, value string, ttl time.Duration) error
 
 		// SetWithBucket 在一个 bucket 保存数据，方便 scan 查找数据，并清楚一个 bucket 的数据
+		// key 原样保存（一般由 GenerateCacheKey 生成），可直接 Get / Delete
 		SetWithBucket(bucket string, key string, value string, ttl time.Duration) error
 
 		// Contains check if a cached key exists
 		Contains(key string) bool
 
-		// Delete remove the cached key
+		// Delete remove the cached key，key 不存在不报错
 		Delete(key string) error
 
 		// Flush remove all cached keys
 		Flush() error
 
-		// Scan 查询 bucket 里面所有数据
+		// Scan 查询 bucket 里面所有数据，只包含 SetWithBucket 写入且未删除、未过期的 key
+		// f 返回错误时停止遍历并返回该错误，f 中可以 Delete
 		Scan(bucket string, f func(key string) error) (err error)
 
-		// Fold all key
+		// Fold all key，不包含后端内部的索引数据
 		Fold(f func(key string) error) (err error)
 	}
 )
@@ -0,0 +1,160 @@
+package cachetest
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type atomicCase struct {
+	name string
+	run  func(t *testing.T, c caches.Cache, a caches.Atomic, o *options)
+}
+
+var atomicCases = []atomicCase{
+	{"Incr", testIncr},
+	{"IncrKeepsTTL", testIncrKeepsTTL},
+	{"IncrNotInteger", testIncrNotInteger},
+	{"SetNX", testSetNX},
+	{"CompareAndSwap", testCompareAndSwap},
+	{"ConcurrentIncr", testConcurrentIncr},
+	{"ConcurrentSetNX", testConcurrentSetNX},
+}
+
+// RunAtomic 执行 caches.Atomic 的用例，factory 返回的 cache 需实现 caches.Atomic
+func RunAtomic(t *testing.T, factory Factory, opts ...Option) {
+	o := newOptions(opts)
+
+	for _, tc := range atomicCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if o.skip[tc.name] {
+				t.Skip("skipped by option")
+			}
+			c := factory(t)
+			a, ok := c.(caches.Atomic)
+			require.True(t, ok, "%T does not implement caches.Atomic", c)
+			tc.run(t, c, a, o)
+		})
+	}
+}
+
+func testIncr(t *testing.T, c caches.Cache, a caches.Atomic, o *options) {
+	n, err := a.Incr("n", 2, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, _ = a.Incr("n", 3, time.Minute)
+	assert.Equal(t, int64(5), n)
+
+	n, _ = a.Decr("n", 6, time.Minute)
+	assert.Equal(t, int64(-1), n)
+
+	// 计数器可以用 Get 读取
+	v, err := c.Get("n")
+	assert.Nil(t, err)
+	assert.Equal(t, "-1", v)
+
+	// 从 Set 写入的整数开始
+	c.Set("m", "10", time.Minute)
+	n, _ = a.Incr("m", 1, time.Minute)
+	assert.Equal(t, int64(11), n)
+}
+
+func testIncrKeepsTTL(t *testing.T, c caches.Cache, a caches.Atomic, o *options) {
+	a.Incr("n", 1, o.ttl)
+	// 已存在时不改变过期时间
+	a.Incr("n", 1, time.Hour)
+	a.Incr("forever", 1, 0)
+
+	o.sleep(o.ttl * 2)
+	assert.False(t, c.Contains("n"))
+	assert.True(t, c.Contains("forever"))
+
+	// 过期后重新计数
+	n, _ := a.Incr("n", 1, o.ttl)
+	assert.Equal(t, int64(1), n)
+}
+
+func testIncrNotInteger(t *testing.T, c caches.Cache, a caches.Atomic, o *options) {
+	c.Set("s", "abc", time.Minute)
+	_, err := a.Incr("s", 1, time.Minute)
+	assert.True(t, errors.Is(err, caches.ErrNotInteger), "got %v", err)
+
+	v, _ := c.Get("s")
+	assert.Equal(t, "abc", v)
+}
+
+func testSetNX(t *testing.T, c caches.Cache, a caches.Atomic, o *options) {
+	ok, err := a.SetNX("k", "v1", o.ttl)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, _ = a.SetNX("k", "v2", o.ttl)
+	assert.False(t, ok)
+	v, _ := c.Get("k")
+	assert.Equal(t, "v1", v)
+
+	// 过期后可以再次写入
+	o.sleep(o.ttl * 2)
+	ok, _ = a.SetNX("k", "v3", time.Minute)
+	assert.True(t, ok)
+	v, _ = c.Get("k")
+	assert.Equal(t, "v3", v)
+}
+
+func testCompareAndSwap(t *testing.T, c caches.Cache, a caches.Atomic, o *options) {
+	ok, err := a.CompareAndSwap("k", "", "v1", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok, "missing key should not be swapped")
+	assert.False(t, c.Contains("k"))
+
+	c.Set("k", "v1", time.Minute)
+	ok, _ = a.CompareAndSwap("k", "other", "v2", time.Minute)
+	assert.False(t, ok)
+
+	ok, _ = a.CompareAndSwap("k", "v1", "v2", time.Minute)
+	assert.True(t, ok)
+	v, _ := c.Get("k")
+	assert.Equal(t, "v2", v)
+}
+
+func testConcurrentIncr(t *testing.T, c caches.Cache, a caches.Atomic, o *options) {
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				_, err := a.Incr("n", 1, time.Minute)
+				assert.Nil(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	v, _ := c.Get("n")
+	assert.Equal(t, "400", v)
+}
+
+func testConcurrentSetNX(t *testing.T, c caches.Cache, a caches.Atomic, o *options) {
+	var wg sync.WaitGroup
+	var won int32
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := a.SetNX("lock", "owner", time.Minute); ok {
+				atomic.AddInt32(&won, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), won)
+}
@@ -0,0 +1,284 @@
+// Package cachetest 是 caches.Cache 的行为一致性测试，每个后端都应通过
+//
+//	func TestConformance(t *testing.T) {
+//		cachetest.Run(t, func(t *testing.T) caches.Cache {
+//			return mem.NewLRU(1000, 0)
+//		})
+//	}
+package cachetest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory 每个用例调用一次，返回一个空的 cache
+type Factory func(t *testing.T) caches.Cache
+
+type Option func(o *options)
+
+type options struct {
+	ttl   time.Duration
+	sleep func(d time.Duration)
+	skip  map[string]bool
+}
+
+// WithTTL 过期用例使用的 ttl，精度为秒的后端需要设置为 2s 以上
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) { o.ttl = ttl }
+}
+
+// WithSleep 替换等待过期的方式，如 miniredis 的 FastForward
+func WithSleep(sleep func(d time.Duration)) Option {
+	return func(o *options) { o.sleep = sleep }
+}
+
+// WithSkip 跳过指定用例
+func WithSkip(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.skip[name] = true
+		}
+	}
+}
+
+type testCase struct {
+	name string
+	run  func(t *testing.T, c caches.Cache, o *options)
+}
+
+var cases = []testCase{
+	{"GenerateCacheKey", testGenerateCacheKey},
+	{"GetSet", testGetSet},
+	{"GetMiss", testGetMiss},
+	{"Overwrite", testOverwrite},
+	{"Delete", testDelete},
+	{"TTLExpiry", testTTLExpiry},
+	{"TTLZero", testTTLZero},
+	{"GetMultiPartial", testGetMultiPartial},
+	{"BucketIsolation", testBucketIsolation},
+	{"ScanStopsOnError", testScanStopsOnError},
+	{"Fold", testFold},
+	{"Flush", testFlush},
+	{"Concurrency", testConcurrency},
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		ttl:   time.Second,
+		sleep: time.Sleep,
+		skip:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Run 执行所有用例
+func Run(t *testing.T, factory Factory, opts ...Option) {
+	o := newOptions(opts)
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if o.skip[tc.name] {
+				t.Skip("skipped by option")
+			}
+			tc.run(t, factory(t), o)
+		})
+	}
+}
+
+func testGenerateCacheKey(t *testing.T, c caches.Cache, o *options) {
+	assert.Equal(t, "foo:bar", c.GenerateCacheKey("foo", "bar"))
+}
+
+func testGetSet(t *testing.T, c caches.Cache, o *options) {
+	require.Nil(t, c.Set("k1", "v1", time.Minute))
+
+	v, err := c.Get("k1")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", v)
+	assert.True(t, c.Contains("k1"))
+}
+
+func testGetMiss(t *testing.T, c caches.Cache, o *options) {
+	v, err := c.Get("missing")
+	assert.True(t, errors.Is(err, caches.ErrNotFound), "Get on miss should return caches.ErrNotFound, got %v", err)
+	assert.Equal(t, "", v)
+	assert.False(t, c.Contains("missing"))
+}
+
+func testOverwrite(t *testing.T, c caches.Cache, o *options) {
+	c.Set("k1", "v1", time.Minute)
+	c.Set("k1", "v2", time.Minute)
+
+	v, err := c.Get("k1")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", v)
+}
+
+func testDelete(t *testing.T, c caches.Cache, o *options) {
+	c.Set("k1", "v1", time.Minute)
+	assert.Nil(t, c.Delete("k1"))
+	assert.False(t, c.Contains("k1"))
+
+	_, err := c.Get("k1")
+	assert.True(t, errors.Is(err, caches.ErrNotFound))
+
+	// 删除不存在的 key 不报错
+	assert.Nil(t, c.Delete("missing"))
+}
+
+func testTTLExpiry(t *testing.T, c caches.Cache, o *options) {
+	c.Set("short", "v", o.ttl)
+	c.Set("long", "v", time.Hour)
+	assert.True(t, c.Contains("short"))
+
+	o.sleep(o.ttl * 2)
+
+	_, err := c.Get("short")
+	assert.True(t, errors.Is(err, caches.ErrNotFound), "expired key should be a miss, got %v", err)
+	assert.False(t, c.Contains("short"))
+	assert.True(t, c.Contains("long"))
+	assert.Equal(t, map[string]string{"long": "v"}, c.GetMulti([]string{"short", "long"}))
+}
+
+// testTTLZero ttl 为 0 表示不过期
+func testTTLZero(t *testing.T, c caches.Cache, o *options) {
+	c.Set("forever", "v", 0)
+	o.sleep(o.ttl * 2)
+
+	v, err := c.Get("forever")
+	assert.Nil(t, err)
+	assert.Equal(t, "v", v)
+}
+
+func testGetMultiPartial(t *testing.T, c caches.Cache, o *options) {
+	c.Set("a", "1", time.Minute)
+	c.Set("b", "2", time.Minute)
+
+	// 只返回命中的 key
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, c.GetMulti([]string{"a", "b", "c"}))
+	assert.Empty(t, c.GetMulti([]string{"x", "y"}))
+	assert.Empty(t, c.GetMulti(nil))
+}
+
+func testBucketIsolation(t *testing.T, c caches.Cache, o *options) {
+	k1 := c.GenerateCacheKey("foo", "1")
+	k2 := c.GenerateCacheKey("foo", "2")
+	k3 := c.GenerateCacheKey("bar", "1")
+	require.Nil(t, c.SetWithBucket("foo", k1, "v1", time.Minute))
+	require.Nil(t, c.SetWithBucket("foo", k2, "v2", time.Minute))
+	require.Nil(t, c.SetWithBucket("bar", k3, "v3", time.Minute))
+	c.Set("foo:plain", "not in bucket", time.Minute)
+
+	// SetWithBucket 写入的 key 可以直接 Get
+	v, err := c.Get(k1)
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", v)
+
+	assert.Equal(t, []string{k1, k2}, scan(t, c, "foo"))
+	assert.Equal(t, []string{k3}, scan(t, c, "bar"))
+	assert.Empty(t, scan(t, c, "none"))
+
+	// 删除后不再出现在 Scan 中
+	c.Delete(k1)
+	assert.Equal(t, []string{k2}, scan(t, c, "foo"))
+
+	// Scan 回调中删除
+	assert.Nil(t, c.Scan("foo", c.Delete))
+	assert.Empty(t, scan(t, c, "foo"))
+	assert.Equal(t, []string{k3}, scan(t, c, "bar"))
+}
+
+func testScanStopsOnError(t *testing.T, c caches.Cache, o *options) {
+	for i := 0; i < 3; i++ {
+		key := c.GenerateCacheKey("foo", fmt.Sprint(i))
+		c.SetWithBucket("foo", key, "v", time.Minute)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err := c.Scan("foo", func(key string) error {
+		n++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, n)
+}
+
+func testFold(t *testing.T, c caches.Cache, o *options) {
+	c.Set("a", "1", time.Minute)
+	c.SetWithBucket("foo", "foo:b", "2", time.Minute)
+	c.Set("deleted", "3", time.Minute)
+	c.Delete("deleted")
+
+	var keys []string
+	assert.Nil(t, c.Fold(func(key string) error {
+		keys = append(keys, key)
+		return nil
+	}))
+	sort.Strings(keys)
+	// 不包含后端内部的索引数据
+	assert.Equal(t, []string{"a", "foo:b"}, keys)
+}
+
+func testFlush(t *testing.T, c caches.Cache, o *options) {
+	c.Set("a", "1", time.Minute)
+	c.SetWithBucket("foo", "foo:b", "2", time.Minute)
+
+	assert.Nil(t, c.Flush())
+	assert.False(t, c.Contains("a"))
+	assert.False(t, c.Contains("foo:b"))
+	assert.Empty(t, scan(t, c, "foo"))
+
+	// Flush 后可以继续使用
+	c.Set("a", "1", time.Minute)
+	assert.True(t, c.Contains("a"))
+}
+
+func testConcurrency(t *testing.T, c caches.Cache, o *options) {
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				key := fmt.Sprintf("g%d:%d", g, i)
+				value := fmt.Sprint(i)
+				assert.Nil(t, c.Set(key, value, time.Minute))
+				v, err := c.Get(key)
+				assert.Nil(t, err)
+				assert.Equal(t, value, v)
+
+				// 所有协程同时写同一个 key
+				c.Set("shared", value, time.Minute)
+				c.Get("shared")
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.True(t, c.Contains("shared"))
+	assert.Len(t, c.GetMulti([]string{"g0:0", "g7:49"}), 2)
+}
+
+func scan(t *testing.T, c caches.Cache, bucket string) []string {
+	var keys []string
+	assert.Nil(t, c.Scan(bucket, func(key string) error {
+		keys = append(keys, key)
+		return nil
+	}))
+	sort.Strings(keys)
+	return keys
+}
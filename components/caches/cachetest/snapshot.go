@@ -0,0 +1,124 @@
+package cachetest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type snapshotCase struct {
+	name string
+	run  func(t *testing.T, factory Factory, o *options)
+}
+
+var snapshotCases = []snapshotCase{
+	{"RoundTrip", testSnapshotRoundTrip},
+	{"Portable", testSnapshotPortable},
+	{"SkipsExpired", testSnapshotSkipsExpired},
+}
+
+// RunSnapshot 执行 caches.Snapshotter 的用例，factory 返回的 cache 需实现 caches.Snapshotter
+func RunSnapshot(t *testing.T, factory Factory, opts ...Option) {
+	o := newOptions(opts)
+	for _, tc := range snapshotCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if o.skip[tc.name] {
+				t.Skip("skipped by option")
+			}
+			tc.run(t, factory, o)
+		})
+	}
+}
+
+func snapshotter(t *testing.T, c caches.Cache) caches.Snapshotter {
+	s, ok := c.(caches.Snapshotter)
+	require.True(t, ok, "%T does not implement caches.Snapshotter", c)
+	return s
+}
+
+func fill(c caches.Cache, o *options) {
+	c.Set("plain", "1", 0)
+	c.Set("binary", "\x00\xff", time.Hour)
+	c.SetWithBucket("foo", "foo:1", "2", time.Hour)
+	c.SetWithBucket("foo", "foo:2", "3", 0)
+	c.Set("short", "4", o.ttl)
+}
+
+func testSnapshotRoundTrip(t *testing.T, factory Factory, o *options) {
+	src := factory(t)
+	fill(src, o)
+
+	var buf bytes.Buffer
+	require.Nil(t, snapshotter(t, src).Snapshot(&buf))
+
+	dst := factory(t)
+	require.Nil(t, snapshotter(t, dst).Restore(&buf))
+
+	assert.Equal(t, map[string]string{
+		"plain":  "1",
+		"binary": "\x00\xff",
+		"foo:1":  "2",
+		"foo:2":  "3",
+		"short":  "4",
+	}, dst.GetMulti([]string{"plain", "binary", "foo:1", "foo:2", "short"}))
+	assert.Equal(t, []string{"foo:1", "foo:2"}, scan(t, dst, "foo"))
+
+	// 剩余的过期时间保留
+	o.sleep(o.ttl * 2)
+	assert.False(t, dst.Contains("short"))
+	assert.True(t, dst.Contains("binary"))
+	assert.True(t, dst.Contains("plain"))
+}
+
+// testSnapshotPortable 快照中只有用户数据，bucket 和过期时间以通用字段表示
+func testSnapshotPortable(t *testing.T, factory Factory, o *options) {
+	src := factory(t)
+	fill(src, o)
+
+	var buf bytes.Buffer
+	require.Nil(t, snapshotter(t, src).Snapshot(&buf))
+
+	sr, err := caches.NewSnapshotReader(&buf)
+	require.Nil(t, err)
+	entries := make(map[string]caches.SnapshotEntry)
+	var keys []string
+	for {
+		e, err := sr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.Nil(t, err)
+		entries[e.Key] = e
+		keys = append(keys, e.Key)
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{"binary", "foo:1", "foo:2", "plain", "short"}, keys)
+
+	assert.Equal(t, "foo", entries["foo:1"].Bucket)
+	assert.Equal(t, "", entries["plain"].Bucket)
+	assert.True(t, entries["plain"].ExpireAt.IsZero())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), entries["binary"].ExpireAt, time.Minute)
+}
+
+func testSnapshotSkipsExpired(t *testing.T, factory Factory, o *options) {
+	src := factory(t)
+	src.Set("short", "1", o.ttl)
+	src.Set("long", "2", time.Hour)
+	o.sleep(o.ttl * 2)
+
+	var buf bytes.Buffer
+	require.Nil(t, snapshotter(t, src).Snapshot(&buf))
+
+	dst := factory(t)
+	require.Nil(t, snapshotter(t, dst).Restore(&buf))
+	assert.False(t, dst.Contains("short"))
+	assert.True(t, dst.Contains("long"))
+}
@@ -0,0 +1,121 @@
+package caches
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"io"
+
+	"github.com/bytedance/sonic"
+	"github.com/cute-angelia/go-xutils/syntax/ijson"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec 序列化 Typed 的值
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSONCodec 使用 ijson (jsoniter)
+	JSONCodec Codec = jsonCodec{}
+	// SonicCodec 使用 bytedance/sonic
+	SonicCodec Codec = sonicCodec{}
+	// GobCodec 使用 encoding/gob，类型需要提前 gob.Register
+	GobCodec Codec = gobCodec{}
+	// MsgpackCodec 使用 vmihailenco/msgpack
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return ijson.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return ijson.Unmarshal(data, v) }
+
+type sonicCodec struct{}
+
+func (sonicCodec) Marshal(v any) ([]byte, error)      { return sonic.ConfigDefault.Marshal(v) }
+func (sonicCodec) Unmarshal(data []byte, v any) error { return sonic.ConfigDefault.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+
+// 压缩标记，写在数据第一个字节
+const (
+	flagRaw  byte = 0
+	flagGzip byte = 1
+)
+
+var errCompressedData = errors.New("caches: invalid compressed data")
+
+type compressCodec struct {
+	codec     Codec
+	threshold int
+}
+
+// Compress 数据超过 threshold 字节时使用 gzip 压缩，小数据保持原样
+func Compress(codec Codec, threshold int) Codec {
+	return compressCodec{codec: codec, threshold: threshold}
+}
+
+func (c compressCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) <= c.threshold {
+		return append([]byte{flagRaw}, data...), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(flagGzip)
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c compressCodec) Unmarshal(data []byte, v any) error {
+	if len(data) == 0 {
+		return errCompressedData
+	}
+	switch data[0] {
+	case flagRaw:
+		return c.codec.Unmarshal(data[1:], v)
+	case flagGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data[1:]))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		raw, err := io.ReadAll(zr)
+		if err != nil {
+			return err
+		}
+		return c.codec.Unmarshal(raw, v)
+	default:
+		return errCompressedData
+	}
+}
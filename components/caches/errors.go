@@ -0,0 +1,23 @@
+package caches
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound 缓存不存在
+var ErrNotFound = errors.New("caches: key not found")
+
+// DecodeError 缓存存在，但无法解码为目标类型
+type DecodeError struct {
+	Key string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("caches: decode %s: %v", e.Key, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,120 @@
+package ibitcask
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"git.mills.io/prologic/bitcask"
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+var _ caches.Atomic = (*Component)(nil)
+
+// Incr 已存在时保留原有的过期时间
+func (d *Component) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+	if d.closed {
+		return 0, ErrClosed
+	}
+	d.writeLk.Lock()
+	defer d.writeLk.Unlock()
+
+	v, err := d.db.Get([]byte(key))
+	if errors.Is(err, bitcask.ErrKeyNotFound) || errors.Is(err, bitcask.ErrKeyExpired) {
+		return delta, d.put(key, strconv.FormatInt(delta, 10), ttl)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseInt(string(v), 10, 64)
+	if err != nil {
+		return 0, caches.ErrNotInteger
+	}
+	n += delta
+
+	var remaining time.Duration
+	if expiry, ok := d.expiry(key); ok {
+		if remaining = time.Until(expiry); remaining <= 0 {
+			// 刚好过期，重新计数
+			return delta, d.put(key, strconv.FormatInt(delta, 10), ttl)
+		}
+	}
+	return n, d.put(key, strconv.FormatInt(n, 10), remaining)
+}
+
+func (d *Component) Decr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return d.Incr(key, -delta, ttl)
+}
+
+func (d *Component) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	if d.closed {
+		return false, ErrClosed
+	}
+	d.writeLk.Lock()
+	defer d.writeLk.Unlock()
+
+	if d.db.Has([]byte(key)) {
+		return false, nil
+	}
+	if err := d.put(key, value, ttl); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *Component) CompareAndSwap(key string, old string, new string, ttl time.Duration) (bool, error) {
+	if d.closed {
+		return false, ErrClosed
+	}
+	d.writeLk.Lock()
+	defer d.writeLk.Unlock()
+
+	v, err := d.db.Get([]byte(key))
+	if errors.Is(err, bitcask.ErrKeyNotFound) || errors.Is(err, bitcask.ErrKeyExpired) {
+		return false, nil
+	}
+	if err != nil || string(v) != old {
+		return false, err
+	}
+	if err := d.put(key, new, ttl); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// put 写入数据和过期时间，调用方需持有 writeLk
+func (d *Component) put(key string, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		if err := d.db.Put([]byte(key), []byte(value)); err != nil {
+			return err
+		}
+		return d.deleteExpiry(key)
+	}
+
+	if err := d.db.PutWithTTL([]byte(key), []byte(value), ttl); err != nil {
+		return err
+	}
+	expiry := strconv.FormatInt(time.Now().Add(ttl).UnixMilli(), 10)
+	return d.db.PutWithTTL([]byte(expiryKey(key)), []byte(expiry), ttl)
+}
+
+// expiry 读取 put 记录的过期时间，不过期的 key 返回 false
+func (d *Component) expiry(key string) (time.Time, bool) {
+	v, err := d.db.Get([]byte(expiryKey(key)))
+	if err != nil {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(string(v), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+func (d *Component) deleteExpiry(key string) error {
+	if !d.db.Has([]byte(expiryKey(key))) {
+		return nil
+	}
+	return d.db.Delete([]byte(expiryKey(key)))
+}
@@ -4,14 +4,23 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"git.mills.io/prologic/bitcask"
+	"github.com/cute-angelia/go-xutils/components/caches"
 )
 
 var ErrClosed = errors.New("datastore closed")
 
+const (
+	// bucketNamespace bucket 索引的 key 前缀
+	bucketNamespace = "__bucket__:"
+	// expiryNamespace 带 ttl 的 key 的过期时间（unix 毫秒），bitcask 无法读取剩余 ttl，Incr 时用它保留过期时间
+	expiryNamespace = "__expiry__:"
+)
+
 var iComponent *Component
 
 const PackageName = "component.ibitcask"
@@ -25,6 +34,11 @@ type Component struct {
 	closeLk   sync.RWMutex
 	closeOnce sync.Once
 	closing   chan struct{}
+
+	stats caches.StatsCounter
+
+	// writeLk 串行化写操作，保证 Incr / SetNX / CompareAndSwap 的读改写是原子的
+	writeLk sync.Mutex
 }
 
 func GetComponent() *Component {
@@ -112,25 +126,33 @@ func (c *Component) GenerateCacheKey(bucket string, key string) string {
 	return fmt.Sprintf("%s:%s", bucket, key)
 }
 
-// Set 保存数据
+// Set 保存数据，ttl 为 0 时不过期
 func (d *Component) Set(key string, value string, ttl time.Duration) error {
 	if d.closed {
 		return ErrClosed
 	}
-	return d.db.PutWithTTL([]byte(key), []byte(value), ttl)
+	d.writeLk.Lock()
+	defer d.writeLk.Unlock()
+	return d.put(key, value, ttl)
 }
 
-// SetWithBucket 保存数据
+// SetWithBucket 保存数据，同时写入一条 bucket 索引，过期时间相同
 func (d *Component) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
 	if d.closed {
 		return ErrClosed
 	}
-	// 修复：使用生成的组合键
-	realKey := d.GenerateCacheKey(bucket, key)
-	return d.db.PutWithTTL([]byte(realKey), []byte(value), ttl)
+	d.writeLk.Lock()
+	defer d.writeLk.Unlock()
+	if err := d.put(key, value, ttl); err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return d.db.Put([]byte(bucketIndexKey(bucket, key)), nil)
+	}
+	return d.db.PutWithTTL([]byte(bucketIndexKey(bucket, key)), nil, ttl)
 }
 
-// Get 获取数据
+// Get 获取数据，不存在或过期返回 caches.ErrNotFound
 func (d *Component) Get(key string) (string, error) {
 	if d.closed {
 		return "", ErrClosed
@@ -141,14 +163,44 @@ func (d *Component) Get(key string) (string, error) {
 	}
 
 	v, err := d.db.Get([]byte(key))
+	d.count(err)
+	if errors.Is(err, bitcask.ErrKeyNotFound) || errors.Is(err, bitcask.ErrKeyExpired) {
+		return "", caches.ErrNotFound
+	}
 	return string(v), err
 }
 
+// count 统计命中
+func (d *Component) count(err error) {
+	switch {
+	case err == nil:
+		d.stats.Hit()
+	case errors.Is(err, bitcask.ErrKeyExpired):
+		d.stats.Expire()
+		d.stats.Miss()
+	case errors.Is(err, bitcask.ErrKeyNotFound):
+		d.stats.Miss()
+	}
+}
+
+// Stats 命中统计，Size 为当前 key 数量（包含 bucket 索引等内部数据）
+func (d *Component) Stats() caches.Stats {
+	if d.closed {
+		return d.stats.Snapshot(-1)
+	}
+	return d.stats.Snapshot(d.db.Len())
+}
+
 func (d *Component) Delete(key string) error {
 	if d.closed {
 		return ErrClosed
 	}
-	return d.db.Delete([]byte(key))
+	d.writeLk.Lock()
+	defer d.writeLk.Unlock()
+	if err := d.db.Delete([]byte(key)); err != nil {
+		return err
+	}
+	return d.deleteExpiry(key)
 }
 
 func (d *Component) GetMulti(keys []string) map[string]string {
@@ -161,7 +213,9 @@ func (d *Component) GetMulti(keys []string) map[string]string {
 	}
 
 	for _, key := range keys {
-		if value, err := d.db.Get([]byte(key)); err == nil {
+		value, err := d.db.Get([]byte(key))
+		d.count(err)
+		if err == nil {
 			result[key] = string(value)
 		}
 	}
@@ -173,17 +227,84 @@ func (d *Component) Contains(key string) bool {
 }
 
 func (d *Component) Flush() error {
+	d.writeLk.Lock()
+	defer d.writeLk.Unlock()
 	return d.db.DeleteAll()
 }
 
-func (d *Component) Scan(prefix string, f func(key string) error) (err error) {
-	return d.db.Scan([]byte(prefix), func(key []byte) error {
-		return f(string(key))
-	})
+// Scan 遍历 bucket 内的 key，顺便清理失效的索引
+func (d *Component) Scan(bucket string, f func(key string) error) (err error) {
+	prefix := bucketIndexKey(bucket, "")
+	indexKeys, err := d.keys([]byte(prefix))
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	for _, indexKey := range indexKeys {
+		key := strings.TrimPrefix(indexKey, prefix)
+		if d.db.Has([]byte(key)) {
+			keys = append(keys, key)
+		} else {
+			d.db.Delete([]byte(indexKey))
+		}
+	}
+	return each(keys, f)
+}
+
+// ScanPrefix 遍历前缀匹配且未过期的 key，不包含 bucket 索引等内部数据
+func (d *Component) ScanPrefix(prefix string, f func(key string) error) (err error) {
+	all, err := d.keys([]byte(prefix))
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	for _, key := range all {
+		if !internal(key) && d.db.Has([]byte(key)) {
+			keys = append(keys, key)
+		}
+	}
+	return each(keys, f)
 }
 
+// Fold 遍历所有未过期的 key，不包含 bucket 索引等内部数据
 func (d *Component) Fold(f func(key string) error) (err error) {
-	return d.db.Fold(func(key []byte) error {
-		return f(string(key))
-	})
+	return d.ScanPrefix("", f)
+}
+
+// keys 收集前缀匹配的 key（包含已过期的）
+// bitcask 遍历时持有读锁，回调里不能再访问 db，所以先收集再处理
+func (d *Component) keys(prefix []byte) ([]string, error) {
+	var keys []string
+	collect := func(key []byte) error {
+		keys = append(keys, string(key))
+		return nil
+	}
+	if len(prefix) == 0 {
+		return keys, d.db.Fold(collect)
+	}
+	return keys, d.db.Scan(prefix, collect)
+}
+
+func each(keys []string, f func(key string) error) error {
+	for _, key := range keys {
+		if err := f(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bucketIndexKey(bucket string, key string) string {
+	return bucketNamespace + bucket + ":" + key
+}
+
+func expiryKey(key string) string {
+	return expiryNamespace + key
+}
+
+// internal bucket 索引和过期时间
+func internal(key string) bool {
+	return strings.HasPrefix(key, bucketNamespace) || strings.HasPrefix(key, expiryNamespace)
 }
@@ -2,6 +2,8 @@ package ibitcask
 
 import (
 	"git.mills.io/prologic/bitcask"
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
 	"github.com/cute-angelia/go-xutils/syntax/ijson"
 	"log"
 	"testing"
@@ -14,16 +16,16 @@ func TestCache(t *testing.T) {
 	defer db.Close()
 	// db.Put([]byte("Hello"), []byte("World"))
 	val, _ := db.Get([]byte("Hello"))
-	log.Printf(string(val))
+	log.Print(string(val))
 
 	db.PutWithTTL([]byte("Hello2"), []byte("World"), time.Second*2)
 	val2, _ := db.Get([]byte("Hello2"))
-	log.Printf(string(val2))
+	log.Print(string(val2))
 
 	<-time.After(time.Second * 2)
 
 	val3, err := db.Get([]byte("Hello2"))
-	log.Printf(string(val3))
+	log.Print(string(val3))
 	log.Println(err)
 }
 
@@ -37,7 +39,7 @@ func TestComponent(t *testing.T) {
 
 	db, _ := bitcaskC.GetDb()
 	stats, _ := db.Stats()
-	log.Println(ijson.Pretty(stats))
+	ijson.LogPretty(stats)
 
 	db.Scan([]byte("t"), func(key []byte) error {
 		val, err := db.Get(key)
@@ -64,3 +66,27 @@ func TestComponent(t *testing.T) {
 	<-time.After(time.Second * 2)
 	log.Println(bitcaskC.Get("test"))
 }
+
+func TestConformance(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		c := New(WithPath(t.TempDir()))
+		t.Cleanup(func() { c.Close() })
+		return c
+	})
+}
+
+func TestAtomic(t *testing.T) {
+	cachetest.RunAtomic(t, func(t *testing.T) caches.Cache {
+		c := New(WithPath(t.TempDir()))
+		t.Cleanup(func() { c.Close() })
+		return c
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	cachetest.RunSnapshot(t, func(t *testing.T) caches.Cache {
+		c := New(WithPath(t.TempDir()))
+		t.Cleanup(func() { c.Close() })
+		return c
+	})
+}
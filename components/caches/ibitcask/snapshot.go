@@ -0,0 +1,58 @@
+package ibitcask
+
+import (
+	"io"
+	"strings"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+var _ caches.Snapshotter = (*Component)(nil)
+
+// Snapshot 导出期间持有写锁，通过 Component 的写操作会等待，保证快照一致
+// bucket 索引转换为 SnapshotEntry.Bucket，过期时间来自 put 记录的过期时间
+func (d *Component) Snapshot(w io.Writer) error {
+	if d.closed {
+		return ErrClosed
+	}
+	d.writeLk.Lock()
+	defer d.writeLk.Unlock()
+
+	all, err := d.keys(nil)
+	if err != nil {
+		return err
+	}
+
+	buckets := make(map[string]string)
+	for _, key := range all {
+		if rest, ok := strings.CutPrefix(key, bucketNamespace); ok {
+			if i := strings.IndexByte(rest, ':'); i >= 0 {
+				buckets[rest[i+1:]] = rest[:i]
+			}
+		}
+	}
+
+	return caches.WriteSnapshot(w, func(write func(e caches.SnapshotEntry) error) error {
+		for _, key := range all {
+			if internal(key) {
+				continue
+			}
+			v, err := d.db.Get([]byte(key))
+			if err != nil {
+				// 已过期
+				continue
+			}
+			e := caches.SnapshotEntry{Key: key, Value: string(v), Bucket: buckets[key]}
+			e.ExpireAt, _ = d.expiry(key)
+			if err := write(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Restore 写入快照中未过期的数据，保留剩余的过期时间
+func (d *Component) Restore(r io.Reader) error {
+	return caches.Restore(d, r)
+}
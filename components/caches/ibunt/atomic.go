@@ -0,0 +1,94 @@
+package ibunt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/tidwall/buntdb"
+)
+
+var _ caches.Atomic = (*Component)(nil)
+
+// Incr buntdb 的写事务是串行的，读改写在一个 Update 中完成
+func (c *Component) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return Incr(c.config.Name, key, delta, ttl)
+}
+
+func (c *Component) Decr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.Incr(key, -delta, ttl)
+}
+
+func (c *Component) SetNX(key string, value string, ttl time.Duration) (ok bool, err error) {
+	db, err := c.getDb()
+	if err != nil {
+		return false, err
+	}
+	err = db.Update(func(tx *buntdb.Tx) error {
+		if _, err := tx.Get(key); !errors.Is(err, buntdb.ErrNotFound) {
+			return err
+		}
+		_, _, err := tx.Set(key, value, setOptions(ttl))
+		ok = err == nil
+		return err
+	})
+	return ok, err
+}
+
+func (c *Component) CompareAndSwap(key string, old string, new string, ttl time.Duration) (ok bool, err error) {
+	db, err := c.getDb()
+	if err != nil {
+		return false, err
+	}
+	err = db.Update(func(tx *buntdb.Tx) error {
+		val, err := tx.Get(key)
+		if errors.Is(err, buntdb.ErrNotFound) {
+			return nil
+		}
+		if err != nil || val != old {
+			return err
+		}
+		_, _, err = tx.Set(key, new, setOptions(ttl))
+		ok = err == nil
+		return err
+	})
+	return ok, err
+}
+
+// Incr 原子加 delta，key 不存在时从 0 开始并使用 ttl，已存在时保留原有的过期时间
+func Incr(dbname string, key string, delta int64, ttl time.Duration) (n int64, err error) {
+	db := GetDb(dbname)
+	if db == nil {
+		return 0, fmt.Errorf("无法找到 db%s", dbname)
+	}
+	err = db.Update(func(tx *buntdb.Tx) error {
+		val, err := tx.Get(key)
+		if errors.Is(err, buntdb.ErrNotFound) {
+			n = delta
+			_, _, err = tx.Set(key, strconv.FormatInt(n, 10), setOptions(ttl))
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if n, err = strconv.ParseInt(val, 10, 64); err != nil {
+			return caches.ErrNotInteger
+		}
+		n += delta
+		_, _, err = tx.Set(key, strconv.FormatInt(n, 10), keepOptions(tx, key))
+		return err
+	})
+	return n, err
+}
+
+// keepOptions 保留 key 剩余的过期时间
+func keepOptions(tx *buntdb.Tx, key string) *buntdb.SetOptions {
+	ttl, err := tx.TTL(key)
+	if err != nil {
+		return nil
+	}
+	return setOptions(ttl)
+}
@@ -0,0 +1,45 @@
+package ibunt
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tidwall/buntdb"
+)
+
+// bucketNamespace bucket 索引的 key 前缀
+const bucketNamespace = "__bucket__:"
+
+func bucketIndexKey(bucket string, key string) string {
+	return bucketNamespace + bucket + ":" + key
+}
+
+// setOptions ttl 为 0 时不过期
+func setOptions(ttl time.Duration) *buntdb.SetOptions {
+	if ttl <= 0 {
+		return nil
+	}
+	return &buntdb.SetOptions{Expires: true, TTL: ttl}
+}
+
+// keysWithPrefix 按 key 顺序收集前缀匹配的 key
+func keysWithPrefix(tx *buntdb.Tx, prefix string) []string {
+	var keys []string
+	tx.AscendGreaterOrEqual("", prefix, func(key, value string) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return false
+		}
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func each(keys []string, f func(key string) error) error {
+	for _, key := range keys {
+		if err := f(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,7 +2,10 @@ package ibunt
 
 import (
 	"fmt"
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
 	"github.com/tidwall/buntdb"
+	"github.com/stretchr/testify/assert"
 	"log"
 	"testing"
 	"time"
@@ -166,3 +169,54 @@ func writeCache2(db *buntdb.DB) {
 		})
 	}
 }
+
+// TestLockerLimit 达到次数后不再计数，每次通过都重新设置过期时间
+func TestLockerLimit(t *testing.T) {
+	initDb()
+	key := fmt.Sprintf("TestLockerLimit_%d", time.Now().UnixNano())
+	opt := NewLockerOpt(WithLimit(2))
+
+	ok, err := IsNotLockedInLimit("cache", key, 150*time.Millisecond, opt)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	time.Sleep(100 * time.Millisecond)
+	ok, _ = IsNotLockedInLimit("cache", key, 150*time.Millisecond, opt)
+	assert.True(t, ok)
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		ok, _ = IsNotLockedInLimit("cache", key, 150*time.Millisecond, opt)
+		assert.False(t, ok)
+	}
+	v := Get("cache", key)
+	assert.Equal(t, "2", v)
+}
+
+func TestConformance(t *testing.T) {
+	n := 0
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		n++
+		c := New(WithName(fmt.Sprintf("conformance%d", n)), WithDbFile(t.TempDir()+"/cache.db"))
+		t.Cleanup(func() { c.Close() })
+		return c
+	})
+}
+
+func TestAtomic(t *testing.T) {
+	n := 0
+	cachetest.RunAtomic(t, func(t *testing.T) caches.Cache {
+		n++
+		c := New(WithName(fmt.Sprintf("atomic%d", n)), WithDbFile(t.TempDir()+"/cache.db"))
+		t.Cleanup(func() { c.Close() })
+		return c
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	n := 0
+	cachetest.RunSnapshot(t, func(t *testing.T) caches.Cache {
+		n++
+		c := New(WithName(fmt.Sprintf("snapshot%d", n)), WithDbFile(t.TempDir()+"/cache.db"))
+		t.Cleanup(func() { c.Close() })
+		return c
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/cute-angelia/go-xutils/components/caches"
 	"github.com/cute-angelia/go-xutils/syntax/ijson"
 	"github.com/tidwall/buntdb"
 	"log"
@@ -20,6 +21,7 @@ const PackageName = "component.ibunt"
 
 type Component struct {
 	config *config
+	stats  caches.StatsCounter
 }
 
 func GetComponent(dbname string) *Component {
@@ -132,13 +134,13 @@ func SetDb(name string, db *buntdb.DB) {
 	BuntCaches.Store(name, db)
 }
 
+// Set ttl 为 0 时不过期
 func Set(dbname string, key string, val string, ttl time.Duration) error {
 	if db := GetDb(dbname); db != nil {
-		db.Update(func(tx *buntdb.Tx) error {
-			tx.Set(key, val, &buntdb.SetOptions{Expires: true, TTL: ttl})
-			return nil
+		return db.Update(func(tx *buntdb.Tx) error {
+			_, _, err := tx.Set(key, val, setOptions(ttl))
+			return err
 		})
-		return nil
 	} else {
 		return fmt.Errorf("无法找到 db")
 	}
@@ -171,7 +173,7 @@ func Get(dbname string, key string) string {
 			return val
 		}
 	} else {
-		log.Println(fmt.Errorf("无法找到 db" + dbname))
+		log.Println(fmt.Errorf("无法找到 db%s", dbname))
 		return ""
 	}
 }
@@ -215,7 +217,7 @@ func ShowTttl(dbname string, key string) string {
 			return val
 		}
 	} else {
-		log.Println(fmt.Errorf("无法找到 db" + dbname))
+		log.Println(fmt.Errorf("无法找到 db%s", dbname))
 		return ""
 	}
 }
@@ -223,12 +225,14 @@ func ShowTttl(dbname string, key string) string {
 func Delete(dbname string, key string) error {
 	if db := GetDb(dbname); db != nil {
 		return db.Update(func(tx *buntdb.Tx) error {
-			_, err := tx.Delete(key)
-			return err
+			if _, err := tx.Delete(key); err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+				return err
+			}
+			return nil
 		})
 	} else {
-		log.Println(fmt.Errorf("无法找到 db" + dbname))
-		return fmt.Errorf("无法找到 db" + dbname)
+		log.Println(fmt.Errorf("无法找到 db%s", dbname))
+		return fmt.Errorf("无法找到 db%s", dbname)
 	}
 }
 
@@ -236,14 +240,40 @@ func (c *Component) GenerateCacheKey(bucket string, key string) string {
 	return fmt.Sprintf("%s:%s", bucket, key)
 }
 
+func (c *Component) getDb() (*buntdb.DB, error) {
+	if db := GetDb(c.config.Name); db != nil {
+		return db, nil
+	}
+	return nil, fmt.Errorf("无法找到 db %s", c.config.Name)
+}
+
+// Get 不存在或过期返回 caches.ErrNotFound
 func (c *Component) Get(key string) (string, error) {
-	return Get(c.config.Name, key), nil
+	db, err := c.getDb()
+	if err != nil {
+		return "", err
+	}
+	var val string
+	err = db.View(func(tx *buntdb.Tx) error {
+		val, err = tx.Get(key)
+		return err
+	})
+	if errors.Is(err, buntdb.ErrNotFound) {
+		c.stats.Miss()
+		return "", caches.ErrNotFound
+	}
+	if err == nil {
+		c.stats.Hit()
+	}
+	return val, err
 }
 
 func (c *Component) GetMulti(keys []string) map[string]string {
 	result := make(map[string]string)
 	for _, key := range keys {
-		result[key] = Get(c.config.Name, key)
+		if v, err := c.Get(key); err == nil {
+			result[key] = v
+		}
 	}
 	return result
 }
@@ -252,37 +282,115 @@ func (c *Component) Set(key string, value string, ttl time.Duration) error {
 	return Set(c.config.Name, key, value, ttl)
 }
 
+// SetWithBucket 保存数据，同时写入一条 bucket 索引，过期时间相同
 func (c *Component) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
-	return Set(c.config.Name, key, value, ttl)
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *buntdb.Tx) error {
+		if _, _, err := tx.Set(key, value, setOptions(ttl)); err != nil {
+			return err
+		}
+		_, _, err := tx.Set(bucketIndexKey(bucket, key), "", setOptions(ttl))
+		return err
+	})
 }
 
 func (c *Component) Contains(key string) bool {
-	v, _ := c.Get(key)
-	return len(v) > 0
+	_, err := c.Get(key)
+	return err == nil
 }
 
 func (c *Component) Delete(key string) error {
 	return Delete(c.config.Name, key)
 }
 
+// Flush 删除所有数据
 func (c *Component) Flush() error {
-	return GetDb(c.config.Name).Shrink()
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *buntdb.Tx) error {
+		return tx.DeleteAll()
+	})
 }
 
-func (c *Component) Scan(prefix string, f func(key string) error) (err error) {
-	if db := GetDb(c.config.Name); db != nil {
-		return db.View(func(tx *buntdb.Tx) error {
-			err := tx.Ascend(prefix, func(key, value string) bool {
-				f(key)
-				return true
-			})
-			return err
+// Scan 遍历 bucket 内的 key，顺便清理失效的索引
+func (c *Component) Scan(bucket string, f func(key string) error) (err error) {
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+
+	prefix := bucketIndexKey(bucket, "")
+	var keys, stale []string
+	db.View(func(tx *buntdb.Tx) error {
+		for _, indexKey := range keysWithPrefix(tx, prefix) {
+			key := strings.TrimPrefix(indexKey, prefix)
+			if _, err := tx.Get(key); err == nil {
+				keys = append(keys, key)
+			} else {
+				stale = append(stale, indexKey)
+			}
+		}
+		return nil
+	})
+
+	if len(stale) > 0 {
+		db.Update(func(tx *buntdb.Tx) error {
+			for _, indexKey := range stale {
+				tx.Delete(indexKey)
+			}
+			return nil
 		})
-	} else {
-		return errors.New(fmt.Sprintf("无法找到 db" + c.config.Name))
 	}
+	return each(keys, f)
+}
+
+// ScanPrefix 遍历前缀匹配的 key，不包含 bucket 索引
+func (c *Component) ScanPrefix(prefix string, f func(key string) error) (err error) {
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	db.View(func(tx *buntdb.Tx) error {
+		for _, key := range keysWithPrefix(tx, prefix) {
+			if !strings.HasPrefix(key, bucketNamespace) {
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	return each(keys, f)
 }
 
+// Fold 遍历所有 key，不包含 bucket 索引
 func (c *Component) Fold(f func(key string) error) (err error) {
-	panic("implement me")
+	return c.ScanPrefix("", f)
+}
+
+// Close 关闭 db
+func (c *Component) Close() error {
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+	BuntCaches.Delete(c.config.Name)
+	return db.Close()
+}
+
+// Stats 命中统计，Size 为当前 key 数量
+func (c *Component) Stats() caches.Stats {
+	size := -1
+	if db := GetDb(c.config.Name); db != nil {
+		db.View(func(tx *buntdb.Tx) error {
+			size, _ = tx.Len()
+			return nil
+		})
+	}
+	return c.stats.Snapshot(size)
 }
@@ -1,10 +1,13 @@
 package ibunt
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"time"
+
+	"github.com/tidwall/buntdb"
 )
 
 var DefaultLockerOpt LockerOpts
@@ -64,25 +67,35 @@ func IsNotLockedInLimit(dbname string, key string, ttl time.Duration, opt Locker
 	if opt.Uid > 0 {
 		key = fmt.Sprintf("%s_%d", key, opt.Uid)
 	}
-	value := Get(dbname, key)
-	if len(value) > 0 {
-		n, _ := strconv.Atoi(value)
-		if n >= opt.Limit {
-			return false, nil
-		} else {
-			if err := Set(dbname, key, fmt.Sprintf("%d", n+1), ttl); err != nil {
-				log.Println("IsLockedLimit error:", err.Error())
-				return true, err
-			}
-			return true, nil
+	ok, err := incrInLimit(GetDb(dbname), key, opt.Limit, ttl)
+	if err != nil {
+		log.Println("IsLockedLimit error:", err.Error())
+		return true, err
+	}
+	return ok, nil
+}
+
+// incrInLimit 未达到 limit 时计数加 1 并重新设置过期时间（滑动窗口），达到时不计数
+// 读改写在一个 Update 中完成，并发调用不会超过 limit
+func incrInLimit(db *buntdb.DB, key string, limit int, ttl time.Duration) (ok bool, err error) {
+	if db == nil {
+		return true, fmt.Errorf("[%s] 无法找到 db", PackageName)
+	}
+	err = db.Update(func(tx *buntdb.Tx) error {
+		n := 0
+		if value, err := tx.Get(key); err == nil {
+			n, _ = strconv.Atoi(value)
+		} else if !errors.Is(err, buntdb.ErrNotFound) {
+			return err
 		}
-	} else {
-		if err := Set(dbname, key, "1", ttl); err != nil {
-			log.Println("IsLockedLimit error:", err.Error())
-			return true, err
+		if n >= limit {
+			return nil
 		}
-		return true, nil
-	}
+		_, _, err := tx.Set(key, strconv.Itoa(n+1), setOptions(ttl))
+		ok = err == nil
+		return err
+	})
+	return ok, err
 }
 
 // IsLockedInLimit 被锁定
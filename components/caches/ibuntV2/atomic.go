@@ -0,0 +1,99 @@
+package ibuntV2
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/tidwall/buntdb"
+)
+
+var _ caches.Atomic = (*Component)(nil)
+
+// Incr buntdb 的写事务是串行的，读改写在一个 Update 中完成；已存在时保留原有的过期时间戳
+func (c *Component) Incr(key string, delta int64, ttl time.Duration) (n int64, err error) {
+	db, err := c.getDb()
+	if err != nil {
+		return 0, err
+	}
+	err = db.Update(func(tx *buntdb.Tx) error {
+		val, ok, err := get(tx, key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			n = delta
+			_, _, err = tx.Set(key, encodeValue(strconv.FormatInt(n, 10), ttl), setOptions(ttl))
+			return err
+		}
+
+		if n, err = strconv.ParseInt(val[expireLen:], 10, 64); err != nil {
+			return caches.ErrNotInteger
+		}
+		n += delta
+		_, _, err = tx.Set(key, val[:expireLen]+strconv.FormatInt(n, 10), keepOptions(tx, key))
+		return err
+	})
+	return n, err
+}
+
+func (c *Component) Decr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.Incr(key, -delta, ttl)
+}
+
+func (c *Component) SetNX(key string, value string, ttl time.Duration) (ok bool, err error) {
+	db, err := c.getDb()
+	if err != nil {
+		return false, err
+	}
+	err = db.Update(func(tx *buntdb.Tx) error {
+		_, exists, err := get(tx, key)
+		if err != nil || exists {
+			return err
+		}
+		_, _, err = tx.Set(key, encodeValue(value, ttl), setOptions(ttl))
+		ok = err == nil
+		return err
+	})
+	return ok, err
+}
+
+func (c *Component) CompareAndSwap(key string, old string, new string, ttl time.Duration) (ok bool, err error) {
+	db, err := c.getDb()
+	if err != nil {
+		return false, err
+	}
+	err = db.Update(func(tx *buntdb.Tx) error {
+		val, exists, err := get(tx, key)
+		if err != nil || !exists || val[expireLen:] != old {
+			return err
+		}
+		_, _, err = tx.Set(key, encodeValue(new, ttl), setOptions(ttl))
+		ok = err == nil
+		return err
+	})
+	return ok, err
+}
+
+// get 返回带过期时间戳的原始值，不存在或已过期时 ok 为 false
+func get(tx *buntdb.Tx, key string) (raw string, ok bool, err error) {
+	raw, err = tx.Get(key)
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	_, ok, _ = decodeValue(raw)
+	return raw, ok, nil
+}
+
+// keepOptions 保留 key 剩余的过期时间
+func keepOptions(tx *buntdb.Tx, key string) *buntdb.SetOptions {
+	ttl, err := tx.TTL(key)
+	if err != nil {
+		return nil
+	}
+	return setOptions(ttl)
+}
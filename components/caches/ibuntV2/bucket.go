@@ -0,0 +1,74 @@
+package ibuntV2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/buntdb"
+)
+
+// bucketNamespace bucket 索引的 key 前缀
+const bucketNamespace = "__bucket__:"
+
+// expireLen 值前面的过期时间戳长度，全 0 表示不过期
+const expireLen = 10
+
+func bucketIndexKey(bucket string, key string) string {
+	return bucketNamespace + bucket + ":" + key
+}
+
+// setOptions ttl 为 0 时不过期
+func setOptions(ttl time.Duration) *buntdb.SetOptions {
+	if ttl <= 0 {
+		return nil
+	}
+	return &buntdb.SetOptions{Expires: true, TTL: ttl}
+}
+
+// encodeValue 过期时间戳 + 值
+func encodeValue(value string, ttl time.Duration) string {
+	var endTime int64
+	if ttl > 0 {
+		endTime = time.Now().Add(ttl).Unix()
+	}
+	return fmt.Sprintf("%0*d%s", expireLen, endTime, value)
+}
+
+// decodeValue ok 为 false 时，expired 表示是否因为过期
+func decodeValue(val string) (value string, ok bool, expired bool) {
+	if len(val) < expireLen {
+		return "", false, false
+	}
+	endTime, err := strconv.ParseInt(val[:expireLen], 10, 64)
+	if err != nil {
+		return "", false, false
+	}
+	if endTime > 0 && !time.Now().Before(time.Unix(endTime, 0)) {
+		return "", false, true
+	}
+	return val[expireLen:], true, false
+}
+
+// keysWithPrefix 按 key 顺序收集前缀匹配的 key
+func keysWithPrefix(tx *buntdb.Tx, prefix string) []string {
+	var keys []string
+	tx.AscendGreaterOrEqual("", prefix, func(key, value string) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return false
+		}
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func each(keys []string, f func(key string) error) error {
+	for _, key := range keys {
+		if err := f(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package ibuntV2
 
 import (
+	"fmt"
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
 	"github.com/cute-angelia/go-xutils/syntax/irandom"
+	"github.com/stretchr/testify/assert"
 	"log"
 	"testing"
 	"time"
@@ -85,3 +89,49 @@ func TestLocker(t *testing.T) {
 	}
 
 }
+
+// TestLockerLimit 达到次数后不再计数
+func TestLockerLimit(t *testing.T) {
+	db := getComponent()
+	key := fmt.Sprintf("TestLockerLimit_%d", time.Now().UnixNano())
+	opt := NewLockerOpt(WithLimit(2))
+
+	for i := 0; i < 5; i++ {
+		ok, err := IsNotLockedInLimit("cache", key, time.Hour, opt)
+		assert.Nil(t, err)
+		assert.Equal(t, i < 2, ok)
+	}
+	v, _ := db.Get(key)
+	assert.Equal(t, "2", v)
+}
+
+func TestConformance(t *testing.T) {
+	n := 0
+	// 过期时间精度为秒
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		n++
+		c := New(WithName(fmt.Sprintf("conformance%d", n)), WithDbFile(t.TempDir()+"/cache.db"))
+		t.Cleanup(func() { c.Close() })
+		return c
+	}, cachetest.WithTTL(2*time.Second))
+}
+
+func TestAtomic(t *testing.T) {
+	n := 0
+	cachetest.RunAtomic(t, func(t *testing.T) caches.Cache {
+		n++
+		c := New(WithName(fmt.Sprintf("atomic%d", n)), WithDbFile(t.TempDir()+"/cache.db"))
+		t.Cleanup(func() { c.Close() })
+		return c
+	}, cachetest.WithTTL(2*time.Second))
+}
+
+func TestSnapshot(t *testing.T) {
+	n := 0
+	cachetest.RunSnapshot(t, func(t *testing.T) caches.Cache {
+		n++
+		c := New(WithName(fmt.Sprintf("snapshot%d", n)), WithDbFile(t.TempDir()+"/cache.db"))
+		t.Cleanup(func() { c.Close() })
+		return c
+	}, cachetest.WithTTL(2*time.Second))
+}
@@ -3,10 +3,10 @@ package ibuntV2
 import (
 	"errors"
 	"fmt"
+	"github.com/cute-angelia/go-xutils/components/caches"
 	"github.com/tidwall/buntdb"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +19,7 @@ const PackageName = "component.ibunt"
 
 type Component struct {
 	config *config
+	stats  caches.StatsCounter
 }
 
 func (c *Component) GenerateCacheKey(bucket string, key string) string {
@@ -157,7 +158,7 @@ func ShowTttl(dbname string, key string) string {
 			return val
 		}
 	} else {
-		log.Println(fmt.Errorf("无法找到 db" + dbname))
+		log.Println(fmt.Errorf("无法找到 db%s", dbname))
 		return ""
 	}
 }
@@ -165,108 +166,193 @@ func ShowTttl(dbname string, key string) string {
 func Delete(dbname string, key string) error {
 	if db := GetDb(dbname); db != nil {
 		return db.Update(func(tx *buntdb.Tx) error {
-			_, err := tx.Delete(key)
-			return err
+			if _, err := tx.Delete(key); err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+				return err
+			}
+			return nil
 		})
 	} else {
-		log.Println(fmt.Errorf("无法找到 db" + dbname))
-		return fmt.Errorf("无法找到 db" + dbname)
+		log.Println(fmt.Errorf("无法找到 db%s", dbname))
+		return fmt.Errorf("无法找到 db%s", dbname)
 	}
 }
 
-func (c *Component) Get(key string) (string, error) {
+func (c *Component) getDb() (*buntdb.DB, error) {
 	if db := GetDb(c.config.Name); db != nil {
-		val := ""
-		db.View(func(tx *buntdb.Tx) error {
-			val, _ = tx.Get(key)
-			return nil
-		})
-		if len(val) == 0 {
-			return "", nil
-		} else {
-			if len(val) <= 10 {
-				return "", nil
-			} else {
-				endTime := val[:10]
-				vv := val[len(val)-10:]
-				endTimeInt, _ := strconv.Atoi(endTime)
-				if time.Now().Before(time.Unix(int64(endTimeInt), 0)) {
-					return vv, nil
-				} else {
-					return "", nil
-				}
-			}
+		return db, nil
+	}
+	return nil, fmt.Errorf("无法找到 db%s", c.config.Name)
+}
+
+// Get 不存在或过期返回 caches.ErrNotFound
+// 值的前 10 位为过期时间戳，不依赖 buntdb 重新打开后的 TTL
+func (c *Component) Get(key string) (string, error) {
+	db, err := c.getDb()
+	if err != nil {
+		return "", err
+	}
+	var val string
+	err = db.View(func(tx *buntdb.Tx) error {
+		val, err = tx.Get(key)
+		return err
+	})
+	if errors.Is(err, buntdb.ErrNotFound) {
+		c.stats.Miss()
+		return "", caches.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	v, ok, expired := decodeValue(val)
+	if !ok {
+		c.stats.Miss()
+		if expired {
+			c.stats.Expire()
 		}
-	} else {
-		log.Println(fmt.Errorf("无法找到 db" + c.config.Name))
-		return "", nil
+		return "", caches.ErrNotFound
 	}
+	c.stats.Hit()
+	return v, nil
 }
 
 func (c *Component) GetMulti(keys []string) map[string]string {
 	result := make(map[string]string)
 	for _, key := range keys {
-		result[key], _ = c.Get(key)
+		if v, err := c.Get(key); err == nil {
+			result[key] = v
+		}
 	}
 	return result
 }
 
+// Set ttl 为 0 时不过期
 func (c *Component) Set(key string, value string, ttl time.Duration) error {
-	endTime := time.Now().Add(ttl)
-	value = fmt.Sprintf("%d%s", endTime.Unix(), value)
-	if db := GetDb(c.config.Name); db != nil {
-		db.Update(func(tx *buntdb.Tx) error {
-			tx.Set(key, value, &buntdb.SetOptions{Expires: true, TTL: ttl})
-			return nil
-		})
-		return nil
-	} else {
-		return fmt.Errorf("无法找到 db")
+	db, err := c.getDb()
+	if err != nil {
+		return err
 	}
+	return db.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(key, encodeValue(value, ttl), setOptions(ttl))
+		return err
+	})
 }
 
+// SetWithBucket 保存数据，同时写入一条 bucket 索引，过期时间相同
 func (c *Component) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
-	endTime := time.Now().Add(ttl)
-	value = fmt.Sprintf("%d%s", endTime.Unix(), value)
-	if db := GetDb(c.config.Name); db != nil {
-		db.CreateIndex(bucket, bucket+":*", buntdb.IndexString)
-		db.Update(func(tx *buntdb.Tx) error {
-			tx.Set(key, value, &buntdb.SetOptions{Expires: true, TTL: ttl})
-			return nil
-		})
-		return nil
-	} else {
-		return fmt.Errorf("无法找到 db")
+	db, err := c.getDb()
+	if err != nil {
+		return err
 	}
+	return db.Update(func(tx *buntdb.Tx) error {
+		if _, _, err := tx.Set(key, encodeValue(value, ttl), setOptions(ttl)); err != nil {
+			return err
+		}
+		_, _, err := tx.Set(bucketIndexKey(bucket, key), encodeValue("", ttl), setOptions(ttl))
+		return err
+	})
 }
 
 func (c *Component) Contains(key string) bool {
-	v, _ := c.Get(key)
-	return len(v) > 0
+	_, err := c.Get(key)
+	return err == nil
 }
 
 func (c *Component) Delete(key string) error {
 	return Delete(c.config.Name, key)
 }
 
+// Flush 删除所有数据
 func (c *Component) Flush() error {
-	return GetDb(c.config.Name).Shrink()
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *buntdb.Tx) error {
+		return tx.DeleteAll()
+	})
 }
 
+// Scan 遍历 bucket 内的 key，顺便清理失效的索引
 func (c *Component) Scan(bucket string, f func(key string) error) (err error) {
-	if db := GetDb(c.config.Name); db != nil {
-		return db.View(func(tx *buntdb.Tx) error {
-			err := tx.Ascend(bucket, func(key, value string) bool {
-				f(key)
-				return true
-			})
-			return err
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+
+	prefix := bucketIndexKey(bucket, "")
+	var keys, stale []string
+	db.View(func(tx *buntdb.Tx) error {
+		for _, indexKey := range keysWithPrefix(tx, prefix) {
+			key := strings.TrimPrefix(indexKey, prefix)
+			if val, err := tx.Get(key); err == nil {
+				if _, ok, _ := decodeValue(val); ok {
+					keys = append(keys, key)
+					continue
+				}
+			}
+			stale = append(stale, indexKey)
+		}
+		return nil
+	})
+
+	if len(stale) > 0 {
+		db.Update(func(tx *buntdb.Tx) error {
+			for _, indexKey := range stale {
+				tx.Delete(indexKey)
+			}
+			return nil
 		})
-	} else {
-		return errors.New(fmt.Sprintf("无法找到 db" + c.config.Name))
 	}
+	return each(keys, f)
 }
 
+// ScanPrefix 遍历前缀匹配且未过期的 key，不包含 bucket 索引
+func (c *Component) ScanPrefix(prefix string, f func(key string) error) (err error) {
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	db.View(func(tx *buntdb.Tx) error {
+		tx.AscendGreaterOrEqual("", prefix, func(key, value string) bool {
+			if !strings.HasPrefix(key, prefix) {
+				return false
+			}
+			if _, ok, _ := decodeValue(value); ok && !strings.HasPrefix(key, bucketNamespace) {
+				keys = append(keys, key)
+			}
+			return true
+		})
+		return nil
+	})
+	return each(keys, f)
+}
+
+// Fold 遍历所有 key，不包含 bucket 索引
 func (c *Component) Fold(f func(key string) error) (err error) {
-	panic("implement me")
+	return c.ScanPrefix("", f)
+}
+
+// Close 关闭 db
+func (c *Component) Close() error {
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+	BuntCaches.Delete(c.config.Name)
+	return db.Close()
+}
+
+// Stats 命中统计，Size 为当前 key 数量
+func (c *Component) Stats() caches.Stats {
+	size := -1
+	if db := GetDb(c.config.Name); db != nil {
+		db.View(func(tx *buntdb.Tx) error {
+			size, _ = tx.Len()
+			return nil
+		})
+	}
+	return c.stats.Snapshot(size)
 }
@@ -5,6 +5,8 @@ import (
 	"log"
 	"strconv"
 	"time"
+
+	"github.com/tidwall/buntdb"
 )
 
 // LockerOpts
@@ -64,25 +66,38 @@ func IsNotLockedInLimit(dbname string, key string, ttl time.Duration, opt Locker
 	}
 
 	db := New(WithName("cache"))
-	value, _ := db.Get(key)
-	if len(value) > 0 {
-		n, _ := strconv.Atoi(value)
-		if n >= opt.Limit {
-			return false, nil
-		} else {
-			if err := db.Set(key, fmt.Sprintf("%d", n+1), ttl); err != nil {
-				log.Println("IsLockedLimit error:", err.Error())
-				return true, err
-			}
-			return true, nil
+	ok, err := db.incrInLimit(key, opt.Limit, ttl)
+	if err != nil {
+		log.Println("IsLockedLimit error:", err.Error())
+		return true, err
+	}
+	return ok, nil
+}
+
+// incrInLimit 未达到 limit 时计数加 1 并重新设置过期时间（滑动窗口），达到时不计数
+// 读改写在一个 Update 中完成，并发调用不会超过 limit
+func (c *Component) incrInLimit(key string, limit int, ttl time.Duration) (ok bool, err error) {
+	db, err := c.getDb()
+	if err != nil {
+		return true, err
+	}
+	err = db.Update(func(tx *buntdb.Tx) error {
+		val, exists, err := get(tx, key)
+		if err != nil {
+			return err
 		}
-	} else {
-		if err := db.Set(key, "1", ttl); err != nil {
-			log.Println("IsLockedLimit error:", err.Error())
-			return true, err
+		n := 0
+		if exists {
+			n, _ = strconv.Atoi(val[expireLen:])
 		}
-		return true, nil
-	}
+		if n >= limit {
+			return nil
+		}
+		_, _, err = tx.Set(key, encodeValue(strconv.Itoa(n+1), ttl), setOptions(ttl))
+		ok = err == nil
+		return err
+	})
+	return ok, err
 }
 
 // IsLockedInLimit 被锁定
@@ -0,0 +1,63 @@
+package ibuntV2
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/tidwall/buntdb"
+)
+
+var _ caches.Snapshotter = (*Component)(nil)
+
+// Snapshot 在一个读事务中导出，期间写操作会等待；值中的过期时间戳转换为 SnapshotEntry.ExpireAt
+func (c *Component) Snapshot(w io.Writer) error {
+	db, err := c.getDb()
+	if err != nil {
+		return err
+	}
+	return db.View(func(tx *buntdb.Tx) error {
+		return caches.WriteSnapshot(w, func(write func(e caches.SnapshotEntry) error) error {
+			return snapshot(tx, write)
+		})
+	})
+}
+
+// Restore 写入快照中未过期的数据，保留剩余的过期时间
+func (c *Component) Restore(r io.Reader) error {
+	return caches.Restore(c, r)
+}
+
+func snapshot(tx *buntdb.Tx, write func(e caches.SnapshotEntry) error) (err error) {
+	buckets := snapshotBuckets(tx)
+	now := time.Now()
+	tx.Ascend("", func(key, raw string) bool {
+		if strings.HasPrefix(key, bucketNamespace) {
+			return true
+		}
+		value, ok, _ := decodeValue(raw)
+		if !ok {
+			return true
+		}
+		e := caches.SnapshotEntry{Key: key, Value: value, Bucket: buckets[key]}
+		if ttl, _ := tx.TTL(key); ttl > 0 {
+			e.ExpireAt = now.Add(ttl)
+		}
+		err = write(e)
+		return err == nil
+	})
+	return err
+}
+
+// snapshotBuckets key => bucket，bucket 中不能含有 ":"
+func snapshotBuckets(tx *buntdb.Tx) map[string]string {
+	buckets := make(map[string]string)
+	for _, indexKey := range keysWithPrefix(tx, bucketNamespace) {
+		rest := strings.TrimPrefix(indexKey, bucketNamespace)
+		if i := strings.IndexByte(rest, ':'); i >= 0 {
+			buckets[rest[i+1:]] = rest[:i]
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,70 @@
+package iredis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/go-redis/redis/v8"
+)
+
+var _ caches.Atomic = (*Component)(nil)
+
+// incrScript key 不存在时带 ttl 创建，已存在时 INCRBY 保留原有 ttl
+var incrScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	if tonumber(ARGV[2]) > 0 then
+		redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	else
+		redis.call('SET', KEYS[1], ARGV[1])
+	end
+	return tonumber(ARGV[1])
+end
+return redis.call('INCRBY', KEYS[1], ARGV[1])
+`)
+
+// casScript 当前值等于 ARGV[1] 时写入 ARGV[2]
+var casScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+func (c *Component) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+	n, err := incrScript.Run(context.Background(), c.client, []string{c.realKey(key)}, delta, milliseconds(ttl)).Int64()
+	if err != nil && strings.Contains(err.Error(), "not an integer") {
+		return 0, caches.ErrNotInteger
+	}
+	return n, err
+}
+
+func (c *Component) Decr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.Incr(key, -delta, ttl)
+}
+
+func (c *Component) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(context.Background(), c.realKey(key), value, ttl).Result()
+}
+
+func (c *Component) CompareAndSwap(key string, old string, new string, ttl time.Duration) (bool, error) {
+	n, err := casScript.Run(context.Background(), c.client, []string{c.realKey(key)}, old, new, milliseconds(ttl)).Int64()
+	return n == 1, err
+}
+
+// milliseconds 不足 1ms 的 ttl 按 1ms 计算，0 表示不过期
+func milliseconds(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ms := ttl.Milliseconds(); ms > 0 {
+		return ms
+	}
+	return 1
+}
@@ -0,0 +1,295 @@
+package iredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/iredisV2"
+	"github.com/go-redis/redis/v8"
+)
+
+var iComponent *Component
+
+const PackageName = "component.caches.iredis"
+
+// bucketNamespace bucket 索引集合的 key 前缀，Fold 时跳过
+const bucketNamespace = "__bucket__:"
+
+// ownerNamespace 记录 key 所属 bucket 的 key 前缀，Delete 时据此清理索引，Fold 时跳过
+const ownerNamespace = "__bucketof__:"
+
+// scanCount SCAN / SSCAN 每批数量
+const scanCount = 100
+
+type Component struct {
+	config *config
+	client redis.UniversalClient
+	stats  caches.StatsCounter
+}
+
+func GetComponent() *Component {
+	if iComponent == nil {
+		panic("iredis is need init")
+	}
+	return iComponent
+}
+
+// newComponent ...
+func newComponent(config *config) *Component {
+	// Flush / Fold 按前缀 SCAN，空前缀会波及共享 redis 里的所有数据
+	if config.Prefix == "" {
+		panic(fmt.Sprintf("[%s] 初始化失败: prefix 不能为空", PackageName))
+	}
+
+	comp := &Component{
+		config: config,
+		client: config.client,
+	}
+
+	// 未指定客户端时，使用 iredisV2 初始化过的连接
+	if comp.client == nil {
+		client, err := iredisV2.GetClient(config.RedisName)
+		if err != nil {
+			panic(fmt.Sprintf("[%s] 初始化失败: %s", PackageName, err))
+		}
+		comp.client = client
+	}
+
+	// 赋值
+	iComponent = comp
+
+	return comp
+}
+
+// GetClient 获取原生客户端
+func (c *Component) GetClient() redis.UniversalClient {
+	return c.client
+}
+
+func (c *Component) realKey(key string) string {
+	return c.config.Prefix + key
+}
+
+func (c *Component) bucketKey(bucket string) string {
+	return c.config.Prefix + bucketNamespace + bucket
+}
+
+func (c *Component) ownerKey(key string) string {
+	return c.config.Prefix + ownerNamespace + key
+}
+
+func (c *Component) GenerateCacheKey(bucket string, key string) string {
+	return fmt.Sprintf("%s:%s", bucket, key)
+}
+
+// Get 获取数据，不存在时返回 caches.ErrNotFound
+func (c *Component) Get(key string) (string, error) {
+	val, err := c.client.Get(context.Background(), c.realKey(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		c.stats.Miss()
+		return "", caches.ErrNotFound
+	}
+	if err == nil {
+		c.stats.Hit()
+	}
+	return val, err
+}
+
+// Stats 命中统计，共享 redis 中无法得到本前缀的条目数，Size 为 -1
+func (c *Component) Stats() caches.Stats {
+	return c.stats.Snapshot(-1)
+}
+
+func (c *Component) GetMulti(keys []string) map[string]string {
+	result := make(map[string]string)
+	if len(keys) == 0 {
+		return result
+	}
+
+	realKeys := make([]string, len(keys))
+	for i, key := range keys {
+		realKeys[i] = c.realKey(key)
+	}
+
+	vals, err := c.client.MGet(context.Background(), realKeys...).Result()
+	if err != nil {
+		return result
+	}
+	for i, val := range vals {
+		if s, ok := val.(string); ok {
+			result[keys[i]] = s
+			c.stats.Hit()
+		} else {
+			c.stats.Miss()
+		}
+	}
+	return result
+}
+
+// Set 保存数据，ttl 为 0 时不过期
+func (c *Component) Set(key string, value string, ttl time.Duration) error {
+	return c.client.Set(context.Background(), c.realKey(key), value, ttl).Err()
+}
+
+// SetWithBucket 保存数据，同时把 key 记录到 bucket 的索引集合，
+// 所属 bucket 另存一份（与数据同 ttl），供 Delete 清理索引
+func (c *Component) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
+	ctx := context.Background()
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, c.realKey(key), value, ttl)
+		pipe.Set(ctx, c.ownerKey(key), bucket, ttl)
+		pipe.SAdd(ctx, c.bucketKey(bucket), key)
+		return nil
+	})
+	return err
+}
+
+func (c *Component) Contains(key string) bool {
+	n, err := c.client.Exists(context.Background(), c.realKey(key)).Result()
+	return err == nil && n > 0
+}
+
+// Delete 删除数据，通过 SetWithBucket 保存的同时移出 bucket 索引
+func (c *Component) Delete(key string) error {
+	ctx := context.Background()
+	bucket, err := c.client.Get(ctx, c.ownerKey(key)).Result()
+	inBucket := err == nil
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	// 逐个 DEL，集群模式下 key 可能不在同一个 slot
+	_, err = c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, c.realKey(key))
+		pipe.Del(ctx, c.ownerKey(key))
+		if inBucket {
+			pipe.SRem(ctx, c.bucketKey(bucket), key)
+		}
+		return nil
+	})
+	return err
+}
+
+// Flush 删除前缀下所有数据，不会影响共享 redis 里的其他数据
+func (c *Component) Flush() error {
+	ctx := context.Background()
+	return c.scanKeys(ctx, func(keys []string) error {
+		// 逐个 DEL，集群模式下 key 可能不在同一个 slot
+		_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Del(ctx, key)
+			}
+			return nil
+		})
+		return err
+	})
+}
+
+// Scan 查询 bucket 里面所有数据，顺便清理已经过期的索引
+func (c *Component) Scan(bucket string, f func(key string) error) (err error) {
+	ctx := context.Background()
+	bucketKey := c.bucketKey(bucket)
+
+	var cursor uint64
+	for {
+		members, next, err := c.client.SScan(ctx, bucketKey, cursor, "", scanCount).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(members) > 0 {
+			pipe := c.client.Pipeline()
+			exists := make([]*redis.IntCmd, len(members))
+			for i, member := range members {
+				exists[i] = pipe.Exists(ctx, c.realKey(member))
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+
+			var expired []interface{}
+			for i, member := range members {
+				if exists[i].Val() == 0 {
+					expired = append(expired, member)
+					continue
+				}
+				if err := f(member); err != nil {
+					return err
+				}
+			}
+			if len(expired) > 0 {
+				c.client.SRem(ctx, bucketKey, expired...)
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// Fold 遍历前缀下所有 key
+func (c *Component) Fold(f func(key string) error) (err error) {
+	indexPrefix := c.config.Prefix + bucketNamespace
+	ownerPrefix := c.config.Prefix + ownerNamespace
+	return c.scanKeys(context.Background(), func(keys []string) error {
+		for _, key := range keys {
+			if strings.HasPrefix(key, indexPrefix) || strings.HasPrefix(key, ownerPrefix) {
+				continue
+			}
+			if err := f(strings.TrimPrefix(key, c.config.Prefix)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scanKeys 使用 SCAN 游标分批遍历前缀下的 redis key，集群模式下遍历所有 master
+func (c *Component) scanKeys(ctx context.Context, f func(keys []string) error) error {
+	match := escapePattern(c.config.Prefix) + "*"
+
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scanNode(ctx, node, match, f)
+		})
+	}
+	return scanNode(ctx, c.client, match, f)
+}
+
+func scanNode(ctx context.Context, client redis.Cmdable, match string, f func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, match, scanCount).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := f(keys); err != nil {
+				return err
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// escapePattern 转义 glob 特殊字符
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,19 @@
+package iredis
+
+import "github.com/go-redis/redis/v8"
+
+// config options
+type config struct {
+	RedisName string // iredisV2 初始化时的 alias
+	Prefix    string // key 前缀，多个服务共享一个 redis 时用于隔离
+
+	client redis.UniversalClient
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *config {
+	return &config{
+		RedisName: "cache",
+		Prefix:    "xcache:",
+	}
+}
@@ -0,0 +1,65 @@
+package iredis
+
+import (
+	"log"
+
+	"github.com/cute-angelia/go-xutils/syntax/ijson"
+	"github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
+)
+
+type Option func(c *Container)
+
+type Container struct {
+	config *config
+}
+
+// Load viper 加载 配置
+func Load(key string) *Component {
+	iconfig := DefaultConfig()
+	configData := viper.GetStringMap(key)
+	jsonstr, _ := ijson.Marshal(configData)
+	if err := ijson.Unmarshal(jsonstr, &iconfig); err != nil {
+		log.Println(err)
+	}
+	return newComponent(iconfig)
+}
+
+// New options 模式
+func New(options ...Option) *Component {
+	c := &Container{
+		config: DefaultConfig(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return newComponent(c.config)
+}
+
+// WithRedisName 使用 iredisV2.RedisInit 初始化过的 alias
+func WithRedisName(name string) Option {
+	return func(c *Container) {
+		c.config.RedisName = name
+	}
+}
+
+// WithClient 直接使用外部客户端，优先于 WithRedisName
+func WithClient(client redis.UniversalClient) Option {
+	return func(c *Container) {
+		c.config.client = client
+	}
+}
+
+func WithPrefix(prefix string) Option {
+	return func(c *Container) {
+		c.config.Prefix = prefix
+	}
+}
+
+// Build ...
+func (c *Container) Build(options ...Option) *Component {
+	for _, option := range options {
+		option(c)
+	}
+	return newComponent(c.config)
+}
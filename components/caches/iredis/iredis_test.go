@@ -0,0 +1,157 @@
+package iredis
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ caches.Cache = (*Component)(nil)
+
+func newTestComponent(t *testing.T) (*Component, *miniredis.Miniredis) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New(WithClient(client), WithPrefix("test:")), s
+}
+
+func TestGetSet(t *testing.T) {
+	c, s := newTestComponent(t)
+
+	assert.Nil(t, c.Set("a", "apple", time.Minute))
+	v, err := c.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "apple", v)
+	assert.True(t, s.Exists("test:a"))
+
+	v, err = c.Get("nope")
+	assert.True(t, errors.Is(err, caches.ErrNotFound))
+	assert.Equal(t, "", v)
+
+	assert.True(t, c.Contains("a"))
+	assert.Nil(t, c.Delete("a"))
+	assert.False(t, c.Contains("a"))
+}
+
+func TestTTL(t *testing.T) {
+	c, s := newTestComponent(t)
+
+	c.Set("short", "v", time.Second)
+	c.Set("forever", "v", 0)
+	s.FastForward(2 * time.Second)
+
+	assert.False(t, c.Contains("short"))
+	assert.True(t, c.Contains("forever"))
+}
+
+func TestGetMulti(t *testing.T) {
+	c, _ := newTestComponent(t)
+
+	c.Set("a", "1", time.Minute)
+	c.Set("b", "2", time.Minute)
+
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, c.GetMulti([]string{"a", "b", "c"}))
+	assert.Empty(t, c.GetMulti(nil))
+}
+
+func TestBucket(t *testing.T) {
+	c, s := newTestComponent(t)
+
+	key1 := c.GenerateCacheKey("foo", "1")
+	key2 := c.GenerateCacheKey("foo", "2")
+	key3 := c.GenerateCacheKey("bar", "1")
+	c.SetWithBucket("foo", key1, "v1", time.Minute)
+	c.SetWithBucket("foo", key2, "v2", time.Second)
+	c.SetWithBucket("bar", key3, "v3", time.Minute)
+
+	var keys []string
+	err := c.Scan("foo", func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	sort.Strings(keys)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{key1, key2}, keys)
+
+	// 过期 key 的索引在 Scan 时被清理
+	s.FastForward(2 * time.Second)
+	keys = nil
+	c.Scan("foo", func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	assert.Equal(t, []string{key1}, keys)
+	members, _ := s.SMembers("test:" + bucketNamespace + "foo")
+	assert.Equal(t, []string{key1}, members)
+
+	// 回调错误中断遍历
+	stop := errors.New("stop")
+	assert.Equal(t, stop, c.Scan("foo", func(key string) error { return stop }))
+
+	// Delete 同时移出 bucket 索引
+	assert.Nil(t, c.Delete(key1))
+	members, _ = s.SMembers("test:" + bucketNamespace + "foo")
+	assert.Empty(t, members)
+	assert.False(t, s.Exists("test:"+ownerNamespace+key1))
+	isMember, _ := s.SIsMember("test:"+bucketNamespace+"bar", key3)
+	assert.True(t, isMember)
+}
+
+func TestFoldAndFlush(t *testing.T) {
+	c, s := newTestComponent(t)
+	s.Set("other", "not mine")
+
+	c.Set("a", "1", time.Minute)
+	c.SetWithBucket("foo", "foo:b", "2", time.Minute)
+
+	var keys []string
+	err := c.Fold(func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	sort.Strings(keys)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "foo:b"}, keys)
+
+	assert.Nil(t, c.Flush())
+	assert.False(t, c.Contains("a"))
+	assert.False(t, c.Contains("foo:b"))
+	assert.False(t, s.Exists("test:"+bucketNamespace+"foo"))
+	assert.True(t, s.Exists("other"))
+}
+
+func TestEmptyPrefix(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() { client.Close() })
+	assert.Panics(t, func() { New(WithClient(client), WithPrefix("")) })
+}
+
+func TestEscapePattern(t *testing.T) {
+	assert.Equal(t, `a\*b\?\[c\]\\`, escapePattern(`a*b?[c]\`))
+}
+
+func TestConformance(t *testing.T) {
+	var s *miniredis.Miniredis
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		var c *Component
+		c, s = newTestComponent(t)
+		return c
+	}, cachetest.WithSleep(func(d time.Duration) { s.FastForward(d) }))
+}
+
+func TestAtomic(t *testing.T) {
+	var s *miniredis.Miniredis
+	cachetest.RunAtomic(t, func(t *testing.T) caches.Cache {
+		var c *Component
+		c, s = newTestComponent(t)
+		return c
+	}, cachetest.WithSleep(func(d time.Duration) { s.FastForward(d) }))
+}
@@ -0,0 +1,275 @@
+package loader
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/syntax/isingleflight"
+)
+
+const PackageName = "component.caches.loader"
+
+// 缓存内容格式：kind + 新鲜期截止时间(毫秒, 36 进制) + ":" + value
+const (
+	kindValue    = 'v'
+	kindNotFound = 'n'
+)
+
+// Component 通用的 load-through 缓存
+//   - 相同 key 并发 miss 时只调用一次 load
+//   - 新鲜期过后的 StaleTtl 内返回旧值，后台刷新
+//   - load 返回 caches.ErrNotFound 时缓存 NotFoundTtl
+//   - ttl 随机抖动，避免同时过期
+//
+// 写入的内容带有元数据，只能通过 Component 读取
+type Component struct {
+	config     *config
+	cache      caches.Cache
+	group      isingleflight.Group[string]
+	refreshing sync.Map
+
+	flightLk sync.Mutex
+	flights  map[string]*flight
+}
+
+// flight 正在进行的 load，只在 load 期间存在
+// Delete 递增 gen，load 返回时 gen 已变化则不写回，避免旧值覆盖删除
+type flight struct {
+	mu  sync.Mutex
+	n   int
+	gen uint64
+}
+
+type entry struct {
+	found      bool
+	freshUntil time.Time
+	value      string
+}
+
+// newComponent ...
+func newComponent(config *config, cache caches.Cache) *Component {
+	return &Component{
+		config:  config,
+		cache:   cache,
+		flights: map[string]*flight{},
+	}
+}
+
+// Get 读取缓存，不存在时调用 load 并写回
+// load 返回 caches.ErrNotFound 表示数据不存在，会被缓存 NotFoundTtl
+func (c *Component) Get(key string, load func() (string, error)) (string, error) {
+	if e, ok := c.read(key); ok {
+		if e.fresh() {
+			return e.result()
+		}
+		if c.config.StaleTtl > 0 {
+			c.refresh(key, load)
+			return e.result()
+		}
+	}
+
+	v, err, _ := c.group.Do(key, func() (string, error) {
+		// 等待期间可能已经被其他请求写入
+		if e, ok := c.read(key); ok && e.fresh() {
+			return e.result()
+		}
+		return c.load(key, load)
+	})
+	return v, err
+}
+
+// Delete 删除缓存，下次 Get 重新 load；正在进行的 load 结果不会再写回
+func (c *Component) Delete(key string) error {
+	c.group.Forget(key)
+
+	c.flightLk.Lock()
+	f := c.flights[key]
+	c.flightLk.Unlock()
+	if f == nil {
+		return c.cache.Delete(key)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gen++
+	return c.cache.Delete(key)
+}
+
+// GetTyped 通过 codec 读取结构化数据，codec 为 nil 时使用 caches.JSONCodec
+func GetTyped[T any](c *Component, key string, codec caches.Codec, load func() (T, error)) (T, error) {
+	if codec == nil {
+		codec = caches.JSONCodec
+	}
+
+	var v T
+	raw, err := c.Get(key, func() (string, error) {
+		v, err := load()
+		if err != nil {
+			return "", err
+		}
+		data, err := codec.Marshal(v)
+		return string(data), err
+	})
+	if err != nil {
+		return v, err
+	}
+	if err := codec.Unmarshal([]byte(raw), &v); err != nil {
+		return v, &caches.DecodeError{Key: key, Err: err}
+	}
+	return v, nil
+}
+
+// refresh 后台刷新，同一个 key 同时只有一个
+func (c *Component) refresh(key string, load func() (string, error)) {
+	if _, loaded := c.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	go func() {
+		defer c.refreshing.Delete(key)
+		_, err, _ := c.group.Do(key, func() (string, error) {
+			return c.load(key, load)
+		})
+		if err != nil && !errors.Is(err, caches.ErrNotFound) {
+			c.onError(key, err)
+		}
+	}()
+}
+
+func (c *Component) load(key string, load func() (string, error)) (string, error) {
+	f, gen := c.beginFlight(key)
+	defer c.endFlight(key, f)
+
+	v, err := load()
+
+	// 写回与 Delete 互斥，load 期间被删除时结果只返回给调用方
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.gen != gen {
+		if errors.Is(err, caches.ErrNotFound) {
+			return "", caches.ErrNotFound
+		}
+		return v, err
+	}
+
+	if errors.Is(err, caches.ErrNotFound) {
+		if c.config.NotFoundTtl > 0 {
+			c.store(key, entry{found: false}, c.config.NotFoundTtl, 0)
+		} else {
+			// 不缓存 "不存在"，但要清掉旧值
+			c.cache.Delete(key)
+		}
+		return "", caches.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	c.store(key, entry{found: true, value: v}, c.jitter(c.config.Ttl), c.config.StaleTtl)
+	return v, nil
+}
+
+func (c *Component) beginFlight(key string) (*flight, uint64) {
+	c.flightLk.Lock()
+	defer c.flightLk.Unlock()
+	f := c.flights[key]
+	if f == nil {
+		f = &flight{}
+		c.flights[key] = f
+	}
+	f.n++
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f, f.gen
+}
+
+func (c *Component) endFlight(key string, f *flight) {
+	c.flightLk.Lock()
+	defer c.flightLk.Unlock()
+	if f.n--; f.n == 0 {
+		delete(c.flights, key)
+	}
+}
+
+// store fresh 为新鲜期，缓存实际保存 fresh + stale
+func (c *Component) store(key string, e entry, fresh time.Duration, stale time.Duration) {
+	ttl := time.Duration(0)
+	if fresh > 0 {
+		e.freshUntil = time.Now().Add(fresh)
+		ttl = fresh + stale
+	}
+	if err := c.cache.Set(key, e.encode(), ttl); err != nil {
+		c.onError(key, err)
+	}
+}
+
+func (c *Component) read(key string) (entry, bool) {
+	raw, err := c.cache.Get(key)
+	if err != nil || len(raw) == 0 {
+		return entry{}, false
+	}
+	return decodeEntry(raw)
+}
+
+func (c *Component) jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 || c.config.Jitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Float64()*c.config.Jitter*float64(ttl))
+}
+
+func (c *Component) onError(key string, err error) {
+	if c.config.OnError != nil {
+		c.config.OnError(key, err)
+	}
+}
+
+func (e entry) fresh() bool {
+	return e.freshUntil.IsZero() || time.Now().Before(e.freshUntil)
+}
+
+func (e entry) result() (string, error) {
+	if !e.found {
+		return "", caches.ErrNotFound
+	}
+	return e.value, nil
+}
+
+func (e entry) encode() string {
+	var b strings.Builder
+	if e.found {
+		b.WriteByte(kindValue)
+	} else {
+		b.WriteByte(kindNotFound)
+	}
+	if !e.freshUntil.IsZero() {
+		b.WriteString(strconv.FormatInt(e.freshUntil.UnixMilli(), 36))
+	}
+	b.WriteByte(':')
+	b.WriteString(e.value)
+	return b.String()
+}
+
+func decodeEntry(raw string) (entry, bool) {
+	if len(raw) < 2 || (raw[0] != kindValue && raw[0] != kindNotFound) {
+		return entry{}, false
+	}
+	i := strings.IndexByte(raw, ':')
+	if i < 0 {
+		return entry{}, false
+	}
+
+	e := entry{found: raw[0] == kindValue, value: raw[i+1:]}
+	if i > 1 {
+		ms, err := strconv.ParseInt(raw[1:i], 36, 64)
+		if err != nil {
+			return entry{}, false
+		}
+		e.freshUntil = time.UnixMilli(ms)
+	}
+	return e, true
+}
@@ -0,0 +1,23 @@
+package loader
+
+import "time"
+
+// config options
+type config struct {
+	Ttl         time.Duration // 新鲜期，期间直接返回缓存
+	StaleTtl    time.Duration // 新鲜期过后仍可返回旧值的时长，同时后台刷新；0 表示不启用
+	NotFoundTtl time.Duration // load 返回 caches.ErrNotFound 时的缓存时长；0 表示不缓存
+	Jitter      float64       // ttl 随机增加 [0, Jitter*ttl)，避免同时过期
+
+	OnError func(key string, err error) // 后台刷新失败
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *config {
+	return &config{
+		Ttl:         time.Minute * 10,
+		StaleTtl:    0,
+		NotFoundTtl: time.Minute,
+		Jitter:      0.1,
+	}
+}
@@ -0,0 +1,60 @@
+package loader
+
+import (
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+type Option func(c *Container)
+
+type Container struct {
+	config *config
+}
+
+// New options 模式
+func New(cache caches.Cache, options ...Option) *Component {
+	c := &Container{
+		config: DefaultConfig(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if cache == nil {
+		panic(PackageName + " need cache")
+	}
+	return newComponent(c.config, cache)
+}
+
+func WithTtl(ttl time.Duration) Option {
+	return func(c *Container) {
+		c.config.Ttl = ttl
+	}
+}
+
+// WithStaleTtl 过期后继续返回旧值的时长，后台刷新
+func WithStaleTtl(ttl time.Duration) Option {
+	return func(c *Container) {
+		c.config.StaleTtl = ttl
+	}
+}
+
+// WithNotFoundTtl 缓存 "不存在" 的时长
+func WithNotFoundTtl(ttl time.Duration) Option {
+	return func(c *Container) {
+		c.config.NotFoundTtl = ttl
+	}
+}
+
+// WithJitter ttl 随机抖动比例，0 ~ 1
+func WithJitter(jitter float64) Option {
+	return func(c *Container) {
+		c.config.Jitter = jitter
+	}
+}
+
+func WithOnError(f func(key string, err error)) Option {
+	return func(c *Container) {
+		c.config.OnError = f
+	}
+}
@@ -0,0 +1,171 @@
+package loader
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleflight(t *testing.T) {
+	l := New(mem.NewLRU(100, 0), WithTtl(time.Minute))
+
+	var calls int32
+	release := make(chan struct{})
+	load := func() (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "v", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := l.Get("k", load)
+			assert.Nil(t, err)
+			assert.Equal(t, "v", v)
+		}()
+	}
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+
+	// 命中缓存
+	v, _ := l.Get("k", load)
+	assert.Equal(t, "v", v)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestNotFound(t *testing.T) {
+	cache := mem.NewLRU(100, 0)
+	l := New(cache, WithNotFoundTtl(time.Minute))
+
+	calls := 0
+	load := func() (string, error) {
+		calls++
+		return "", caches.ErrNotFound
+	}
+	for i := 0; i < 3; i++ {
+		_, err := l.Get("missing", load)
+		assert.True(t, errors.Is(err, caches.ErrNotFound))
+	}
+	assert.Equal(t, 1, calls)
+
+	// 其他错误不缓存
+	loadErr := errors.New("db down")
+	for i := 0; i < 2; i++ {
+		_, err := l.Get("err", func() (string, error) {
+			calls++
+			return "", loadErr
+		})
+		assert.Equal(t, loadErr, err)
+	}
+	assert.Equal(t, 3, calls)
+	assert.False(t, cache.Contains("err"))
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	l := New(mem.NewLRU(100, 0), WithTtl(time.Millisecond*20), WithStaleTtl(time.Minute), WithJitter(0))
+
+	var version int32
+	refreshed := make(chan struct{}, 1)
+	load := func() (string, error) {
+		n := atomic.AddInt32(&version, 1)
+		if n > 1 {
+			refreshed <- struct{}{}
+		}
+		return string(rune('0' + n)), nil
+	}
+
+	v, _ := l.Get("k", load)
+	assert.Equal(t, "1", v)
+
+	time.Sleep(time.Millisecond * 30)
+	// 过期后立即返回旧值，后台刷新
+	v, _ = l.Get("k", load)
+	assert.Equal(t, "1", v)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("background refresh did not run")
+	}
+	time.Sleep(time.Millisecond * 10)
+	v, _ = l.Get("k", load)
+	assert.Equal(t, "2", v)
+}
+
+func TestGetTyped(t *testing.T) {
+	l := New(mem.NewLRU(100, 0))
+
+	type item struct{ Id int }
+	calls := 0
+	for i := 0; i < 2; i++ {
+		v, err := GetTyped(l, "item", caches.MsgpackCodec, func() (item, error) {
+			calls++
+			return item{Id: 7}, nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 7, v.Id)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestJitter(t *testing.T) {
+	l := New(mem.NewLRU(1, 0), WithJitter(0.5))
+	for i := 0; i < 100; i++ {
+		d := l.jitter(time.Second)
+		assert.True(t, d >= time.Second && d < time.Second*3/2)
+	}
+}
+
+func TestEntry(t *testing.T) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+	for _, e := range []entry{
+		{found: true, value: "a:b:c", freshUntil: now},
+		{found: true, value: ""},
+		{found: false, freshUntil: now},
+	} {
+		got, ok := decodeEntry(e.encode())
+		assert.True(t, ok)
+		assert.Equal(t, e, got)
+	}
+
+	_, ok := decodeEntry("plain value")
+	assert.False(t, ok)
+}
+
+func TestDeleteDuringLoad(t *testing.T) {
+	cache := mem.NewLRU(100, 0)
+	l := New(cache, WithTtl(time.Minute))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan string)
+	go func() {
+		v, _ := l.Get("k", func() (string, error) {
+			close(started)
+			<-release
+			return "stale", nil
+		})
+		done <- v
+	}()
+
+	<-started
+	assert.Nil(t, l.Delete("k"))
+	close(release)
+	assert.Equal(t, "stale", <-done)
+
+	// 删除前开始的 load 不写回
+	assert.False(t, cache.Contains("k"))
+	v, _ := l.Get("k", func() (string, error) { return "fresh", nil })
+	assert.Equal(t, "fresh", v)
+	assert.Empty(t, l.flights)
+}
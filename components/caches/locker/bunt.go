@@ -0,0 +1,83 @@
+package locker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/tidwall/buntdb"
+)
+
+// fenceSuffix fencing token 计数器的 key 后缀，不过期
+const fenceSuffix = ":__fence__"
+
+type buntBackend struct {
+	db *buntdb.DB
+}
+
+// NewBuntBackend 单机多进程之间无法共享，适合单机部署，如 ibunt.GetDb("cache")
+func NewBuntBackend(db *buntdb.DB) Backend {
+	return &buntBackend{db: db}
+}
+
+func (b *buntBackend) Acquire(ctx context.Context, key string, owner string, ttl time.Duration) (token int64, ok bool, err error) {
+	err = b.db.Update(func(tx *buntdb.Tx) error {
+		if _, err := tx.Get(key); !errors.Is(err, buntdb.ErrNotFound) {
+			return err
+		}
+
+		if v, err := tx.Get(key + fenceSuffix); err == nil {
+			token, _ = strconv.ParseInt(v, 10, 64)
+		}
+		token++
+		if _, _, err := tx.Set(key+fenceSuffix, strconv.FormatInt(token, 10), nil); err != nil {
+			return err
+		}
+		if _, _, err := tx.Set(key, owner, setOptions(ttl)); err != nil {
+			return err
+		}
+		ok = true
+		return nil
+	})
+	return token, ok, err
+}
+
+func (b *buntBackend) Renew(ctx context.Context, key string, owner string, ttl time.Duration) (ok bool, err error) {
+	err = b.db.Update(func(tx *buntdb.Tx) error {
+		if v, err := tx.Get(key); err != nil || v != owner {
+			return ignoreNotFound(err)
+		}
+		_, _, err := tx.Set(key, owner, setOptions(ttl))
+		ok = err == nil
+		return err
+	})
+	return ok, err
+}
+
+func (b *buntBackend) Release(ctx context.Context, key string, owner string) (ok bool, err error) {
+	err = b.db.Update(func(tx *buntdb.Tx) error {
+		if v, err := tx.Get(key); err != nil || v != owner {
+			return ignoreNotFound(err)
+		}
+		_, err := tx.Delete(key)
+		ok = err == nil
+		return err
+	})
+	return ok, err
+}
+
+// setOptions ttl 为 0 时不过期
+func setOptions(ttl time.Duration) *buntdb.SetOptions {
+	if ttl <= 0 {
+		return nil
+	}
+	return &buntdb.SetOptions{Expires: true, TTL: ttl}
+}
+
+func ignoreNotFound(err error) error {
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return nil
+	}
+	return err
+}
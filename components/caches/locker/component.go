@@ -0,0 +1,209 @@
+package locker
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/syntax/irandom"
+)
+
+const PackageName = "component.caches.locker"
+
+// minRenewInterval 自动续期的最小间隔，避免 ttl 很小时 ttl/3 为 0
+const minRenewInterval = time.Millisecond
+
+var (
+	// ErrNotAcquired 锁被其他人持有
+	ErrNotAcquired = errors.New("locker: not acquired")
+	// ErrLockLost 锁已过期或被其他人持有，不能再续期或释放
+	ErrLockLost = errors.New("locker: lock lost")
+)
+
+// Backend 锁的存储，操作需是原子的
+type Backend interface {
+	// Acquire key 不存在时写入 owner 并设置 ttl，返回递增的 fencing token；已被持有时返回 false
+	Acquire(ctx context.Context, key string, owner string, ttl time.Duration) (token int64, ok bool, err error)
+
+	// Renew key 的 owner 一致时重新设置 ttl
+	Renew(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error)
+
+	// Release key 的 owner 一致时删除
+	Release(ctx context.Context, key string, owner string) (bool, error)
+}
+
+// Component 基于租约的分布式锁
+//   - 租约到期自动释放，进程崩溃不会死锁
+//   - 持有期间自动续期，续期失败时 Lease.Context 被取消
+//   - 只有持有者可以释放
+//   - 每次获取返回递增的 fencing token，下游据此拒绝过期持有者的写入
+type Component struct {
+	config  *config
+	backend Backend
+}
+
+// Lease 一次持有
+type Lease struct {
+	Key   string
+	Token int64 // fencing token，同一个 key 单调递增
+
+	c      *Component
+	owner  string
+	ttl    time.Duration
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// newComponent ...
+func newComponent(config *config, backend Backend) *Component {
+	return &Component{
+		config:  config,
+		backend: backend,
+	}
+}
+
+// TryLock 尝试获取一次，被持有时返回 ErrNotAcquired
+func (c *Component) TryLock(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	owner := hex.EncodeToString(irandom.RandBytes(16))
+	token, ok, err := c.backend.Acquire(ctx, c.config.Prefix+key, owner, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotAcquired
+	}
+	return c.newLease(key, owner, token, ttl), nil
+}
+
+// Lock 获取锁，被持有时每 RetryInterval 重试，直到 ctx 结束
+func (c *Component) Lock(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	ticker := time.NewTicker(c.config.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		lease, err := c.TryLock(ctx, key, ttl)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lease, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Do 获取到锁时执行 f，f 的 ctx 在锁丢失时取消；被持有时返回 ErrNotAcquired 不执行
+// 适合多实例部署的定时任务，只有一个实例执行
+func (c *Component) Do(ctx context.Context, key string, ttl time.Duration, f func(ctx context.Context) error) error {
+	lease, err := c.TryLock(ctx, key, ttl)
+	if err != nil {
+		return err
+	}
+	defer lease.Unlock(context.Background())
+
+	fctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(lease.Context(), cancel)
+	defer stop()
+	return f(fctx)
+}
+
+func (c *Component) newLease(key string, owner string, token int64, ttl time.Duration) *Lease {
+	l := &Lease{
+		Key:   key,
+		Token: token,
+		c:     c,
+		owner: owner,
+		ttl:   ttl,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	l.ctx, l.cancel = context.WithCancelCause(context.Background())
+
+	if c.config.AutoRenew && ttl > 0 {
+		go l.keepAlive()
+	} else {
+		close(l.done)
+	}
+	return l
+}
+
+// Context 锁丢失或释放后取消，context.Cause 为 ErrLockLost 或 context.Canceled
+func (l *Lease) Context() context.Context {
+	return l.ctx
+}
+
+// Renew 手动续期，关闭自动续期时使用
+func (l *Lease) Renew(ctx context.Context) error {
+	ok, err := l.c.backend.Renew(ctx, l.c.config.Prefix+l.Key, l.owner, l.ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		l.lost(ErrLockLost)
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Unlock 停止续期并释放，锁已被其他人持有时返回 ErrLockLost
+func (l *Lease) Unlock(ctx context.Context) error {
+	l.once.Do(func() { close(l.stop) })
+	<-l.done
+
+	ok, err := l.c.backend.Release(ctx, l.c.config.Prefix+l.Key, l.owner)
+	l.cancel(context.Canceled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// keepAlive 每 ttl/3（至少 minRenewInterval）续期，连续失败超过 ttl 视为丢失
+func (l *Lease) keepAlive() {
+	defer close(l.done)
+
+	interval := max(l.ttl/3, minRenewInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(l.ctx, interval)
+		ok, err := l.c.backend.Renew(ctx, l.c.config.Prefix+l.Key, l.owner, l.ttl)
+		cancel()
+
+		switch {
+		case err == nil && ok:
+			renewed = time.Now()
+		case err == nil && !ok:
+			l.lost(ErrLockLost)
+			return
+		case time.Since(renewed) >= l.ttl:
+			l.lost(errors.Join(ErrLockLost, err))
+			return
+		}
+	}
+}
+
+func (l *Lease) lost(err error) {
+	l.cancel(err)
+	if l.c.config.OnLost != nil {
+		l.c.config.OnLost(l.Key, err)
+	}
+}
@@ -0,0 +1,21 @@
+package locker
+
+import "time"
+
+// config options
+type config struct {
+	Prefix        string        // 锁 key 前缀
+	RetryInterval time.Duration // Lock 等待时的重试间隔
+	AutoRenew     bool          // 持有期间每 ttl/3 自动续期
+
+	OnLost func(key string, err error) // 自动续期失败，锁已丢失
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *config {
+	return &config{
+		Prefix:        "lock:",
+		RetryInterval: time.Millisecond * 100,
+		AutoRenew:     true,
+	}
+}
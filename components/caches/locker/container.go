@@ -0,0 +1,50 @@
+package locker
+
+import (
+	"time"
+)
+
+type Option func(c *Container)
+
+type Container struct {
+	config *config
+}
+
+// New options 模式，backend 见 NewBuntBackend / NewRedisBackend
+func New(backend Backend, options ...Option) *Component {
+	c := &Container{
+		config: DefaultConfig(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if backend == nil {
+		panic(PackageName + " need backend")
+	}
+	return newComponent(c.config, backend)
+}
+
+func WithPrefix(prefix string) Option {
+	return func(c *Container) {
+		c.config.Prefix = prefix
+	}
+}
+
+func WithRetryInterval(interval time.Duration) Option {
+	return func(c *Container) {
+		c.config.RetryInterval = interval
+	}
+}
+
+// WithAutoRenew 关闭后需要自己调用 Lease.Renew
+func WithAutoRenew(autoRenew bool) Option {
+	return func(c *Container) {
+		c.config.AutoRenew = autoRenew
+	}
+}
+
+func WithOnLost(f func(key string, err error)) Option {
+	return func(c *Container) {
+		c.config.OnLost = f
+	}
+}
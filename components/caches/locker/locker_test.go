@@ -0,0 +1,202 @@
+package locker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/buntdb"
+)
+
+func newBunt(t *testing.T) Backend {
+	db, err := buntdb.Open(":memory:")
+	assert.Nil(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewBuntBackend(db)
+}
+
+func newRedis(t *testing.T) (Backend, *miniredis.Miniredis) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisBackend(client), s
+}
+
+func backends(t *testing.T) map[string]Backend {
+	r, _ := newRedis(t)
+	return map[string]Backend{
+		"bunt":  newBunt(t),
+		"redis": r,
+	}
+}
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			l := New(backend, WithAutoRenew(false))
+
+			t.Run("Exclusive", func(t *testing.T) {
+				lease, err := l.TryLock(ctx, "exclusive", time.Minute)
+				assert.Nil(t, err)
+
+				_, err = l.TryLock(ctx, "exclusive", time.Minute)
+				assert.ErrorIs(t, err, ErrNotAcquired)
+
+				assert.Nil(t, lease.Unlock(ctx))
+				lease, err = l.TryLock(ctx, "exclusive", time.Minute)
+				assert.Nil(t, err)
+				assert.Nil(t, lease.Unlock(ctx))
+			})
+
+			t.Run("FencingToken", func(t *testing.T) {
+				var last int64
+				for i := 0; i < 3; i++ {
+					lease, err := l.TryLock(ctx, "fence", time.Minute)
+					assert.Nil(t, err)
+					assert.Greater(t, lease.Token, last)
+					last = lease.Token
+					assert.Nil(t, lease.Unlock(ctx))
+				}
+			})
+
+			t.Run("SafeUnlock", func(t *testing.T) {
+				lease, err := l.TryLock(ctx, "safe", time.Minute)
+				assert.Nil(t, err)
+
+				// 被其他人持有后不能释放和续期
+				_, err = backend.Release(ctx, l.config.Prefix+"safe", lease.owner)
+				assert.Nil(t, err)
+				other, err := l.TryLock(ctx, "safe", time.Minute)
+				assert.Nil(t, err)
+
+				assert.ErrorIs(t, lease.Renew(ctx), ErrLockLost)
+				assert.ErrorIs(t, context.Cause(lease.Context()), ErrLockLost)
+				assert.ErrorIs(t, lease.Unlock(ctx), ErrLockLost)
+
+				_, err = l.TryLock(ctx, "safe", time.Minute)
+				assert.ErrorIs(t, err, ErrNotAcquired)
+				assert.Nil(t, other.Unlock(ctx))
+			})
+
+			t.Run("LockWaits", func(t *testing.T) {
+				l := New(backend, WithAutoRenew(false), WithRetryInterval(10*time.Millisecond))
+				lease, err := l.TryLock(ctx, "wait", time.Minute)
+				assert.Nil(t, err)
+
+				time.AfterFunc(50*time.Millisecond, func() { lease.Unlock(ctx) })
+				next, err := l.Lock(ctx, "wait", time.Minute)
+				assert.Nil(t, err)
+				assert.Greater(t, next.Token, lease.Token)
+
+				tctx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+				defer cancel()
+				_, err = l.Lock(tctx, "wait", time.Minute)
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.Nil(t, next.Unlock(ctx))
+			})
+
+			t.Run("Do", func(t *testing.T) {
+				var running, runs int32
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						err := l.Do(ctx, "do", time.Minute, func(ctx context.Context) error {
+							assert.Equal(t, int32(1), atomic.AddInt32(&running, 1))
+							atomic.AddInt32(&runs, 1)
+							time.Sleep(20 * time.Millisecond)
+							atomic.AddInt32(&running, -1)
+							return nil
+						})
+						if err != nil {
+							assert.ErrorIs(t, err, ErrNotAcquired)
+						}
+					}()
+				}
+				wg.Wait()
+				assert.GreaterOrEqual(t, runs, int32(1))
+
+				want := errors.New("job failed")
+				assert.Equal(t, want, l.Do(ctx, "do", time.Minute, func(ctx context.Context) error { return want }))
+			})
+		})
+	}
+}
+
+func TestLockerExpires(t *testing.T) {
+	ctx := context.Background()
+	backend, s := newRedis(t)
+	l := New(backend, WithAutoRenew(false))
+
+	lease, err := l.TryLock(ctx, "expire", time.Second)
+	assert.Nil(t, err)
+	s.FastForward(2 * time.Second)
+
+	other, err := l.TryLock(ctx, "expire", time.Second)
+	assert.Nil(t, err)
+	assert.ErrorIs(t, lease.Unlock(ctx), ErrLockLost)
+	assert.Nil(t, other.Unlock(ctx))
+}
+
+func TestLockerAutoRenew(t *testing.T) {
+	ctx := context.Background()
+	backend := newBunt(t)
+
+	var lost int32
+	l := New(backend, WithOnLost(func(key string, err error) { atomic.AddInt32(&lost, 1) }))
+
+	lease, err := l.TryLock(ctx, "renew", 150*time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(500 * time.Millisecond)
+
+	_, err = l.TryLock(ctx, "renew", 150*time.Millisecond)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+	assert.Nil(t, lease.Context().Err())
+	assert.Nil(t, lease.Unlock(ctx))
+	assert.ErrorIs(t, context.Cause(lease.Context()), context.Canceled)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&lost))
+
+	// 被删除后续期失败，通知丢失
+	lease, err = l.TryLock(ctx, "renew", 150*time.Millisecond)
+	assert.Nil(t, err)
+	_, err = backend.Release(ctx, "lock:renew", lease.owner)
+	assert.Nil(t, err)
+
+	select {
+	case <-lease.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("lease not lost")
+	}
+	assert.ErrorIs(t, context.Cause(lease.Context()), ErrLockLost)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lost))
+}
+
+// TestLockerSubMillisecond 不足 1ms 的 ttl 仍会过期，自动续期不会 panic
+func TestLockerSubMillisecond(t *testing.T) {
+	ctx := context.Background()
+	backend, s := newRedis(t)
+	l := New(backend, WithAutoRenew(false))
+
+	lease, err := l.TryLock(ctx, "short", 500*time.Microsecond)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Millisecond, s.TTL("{lock:short}"))
+	assert.Nil(t, lease.Renew(ctx))
+	assert.Equal(t, time.Millisecond, s.TTL("{lock:short}"))
+	s.FastForward(time.Millisecond)
+	_, err = l.TryLock(ctx, "short", time.Minute)
+	assert.Nil(t, err)
+
+	lease, err = New(newBunt(t)).TryLock(ctx, "tiny", 2*time.Nanosecond)
+	assert.Nil(t, err)
+	time.Sleep(5 * time.Millisecond)
+	lease.Unlock(ctx)
+}
@@ -0,0 +1,81 @@
+package locker
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// acquireScript 锁和计数器使用相同的 hash tag，cluster 下在同一个 slot
+var acquireScript = redis.NewScript(`
+local ok
+if tonumber(ARGV[2]) > 0 then
+	ok = redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2])
+else
+	ok = redis.call('SET', KEYS[1], ARGV[1], 'NX')
+end
+if not ok then
+	return 0
+end
+return redis.call('INCR', KEYS[2])
+`)
+
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+else
+	redis.call('PERSIST', KEYS[1])
+end
+return 1
+`)
+
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+type redisBackend struct {
+	client redis.UniversalClient
+}
+
+// NewRedisBackend 多机共享，如 iredisV2.GetClient("cache")
+func NewRedisBackend(client redis.UniversalClient) Backend {
+	return &redisBackend{client: client}
+}
+
+func (b *redisBackend) Acquire(ctx context.Context, key string, owner string, ttl time.Duration) (int64, bool, error) {
+	lockKey, fenceKey := redisKeys(key)
+	token, err := acquireScript.Run(ctx, b.client, []string{lockKey, fenceKey}, owner, milliseconds(ttl)).Int64()
+	return token, err == nil && token > 0, err
+}
+
+func (b *redisBackend) Renew(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	lockKey, _ := redisKeys(key)
+	n, err := renewScript.Run(ctx, b.client, []string{lockKey}, owner, milliseconds(ttl)).Int64()
+	return n == 1, err
+}
+
+func (b *redisBackend) Release(ctx context.Context, key string, owner string) (bool, error) {
+	lockKey, _ := redisKeys(key)
+	n, err := releaseScript.Run(ctx, b.client, []string{lockKey}, owner).Int64()
+	return n == 1, err
+}
+
+// milliseconds 不足 1ms 的 ttl 向上取整，只有 0 表示不过期
+func milliseconds(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return int64((ttl + time.Millisecond - 1) / time.Millisecond)
+}
+
+func redisKeys(key string) (lockKey string, fenceKey string) {
+	lockKey = "{" + key + "}"
+	return lockKey, lockKey + fenceSuffix
+}
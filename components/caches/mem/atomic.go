@@ -0,0 +1,93 @@
+package mem
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+var _ caches.Atomic = (*AtomicLRU)(nil)
+
+// ErrNotStored the value is larger than MaxBytes or the new key is rejected by the admission policy
+var ErrNotStored = errors.New("mem: value not stored")
+
+// AtomicLRU 以 caches.Atomic 使用 LRU，CompareAndSwap 按字符串比较并支持 ttl，
+// LRU 自身的 CompareAndSwap 保持原有的 interface{} 签名
+type AtomicLRU struct {
+	*LRU
+}
+
+// Atomic 返回实现 caches.Atomic 的包装，与 c 共享数据
+func (c *LRU) Atomic() *AtomicLRU {
+	return &AtomicLRU{LRU: c}
+}
+
+// Incr adds delta to the integer stored under key, a missing key starts from 0 with the given ttl, 0 never expires.
+// The expiration of an existing key is kept. ErrNotStored is returned if the result is not kept in the cache.
+func (c *LRU) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	n := delta
+	elt := c.liveWithMutexHold(key)
+	if elt != nil {
+		entry := elt.Value.(*cacheEntry)
+		s, _ := entry.value.(string)
+		old, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, caches.ErrNotInteger
+		}
+		n += old
+		ttl = 0
+		if !entry.expiration.IsZero() {
+			// 刚好到期时也不能变成 0（永不过期）
+			ttl = max(entry.expiration.Sub(c.TimeNow()), time.Nanosecond)
+		}
+	}
+
+	// 经过 putWithMutexHold 更新大小，新 key 可能不被准入
+	c.putWithMutexHold(key, strconv.FormatInt(n, 10), elt, ttl)
+	if c.byKey[key] == nil {
+		return 0, ErrNotStored
+	}
+	return n, nil
+}
+
+// Decr subtracts delta, see Incr
+func (c *LRU) Decr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.Incr(key, -delta, ttl)
+}
+
+// SetNX puts the value only if the key is missing or expired, false is also returned if the value is not stored
+func (c *LRU) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.liveWithMutexHold(key) != nil {
+		return false, nil
+	}
+	c.putWithMutexHold(key, value, nil, ttl)
+	return c.byKey[key] != nil, nil
+}
+
+// CompareAndSwap puts new with the given ttl only if the current string value equals old
+func (a *AtomicLRU) CompareAndSwap(key string, old string, new string, ttl time.Duration) (bool, error) {
+	return a.compareAndSwapString(key, old, new, ttl)
+}
+
+func (c *LRU) compareAndSwapString(key string, old string, new string, ttl time.Duration) (bool, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	elt := c.liveWithMutexHold(key)
+	if elt == nil {
+		return false, nil
+	}
+	if v, ok := elt.Value.(*cacheEntry).value.(string); !ok || v != old {
+		return false, nil
+	}
+	c.putWithMutexHold(key, new, elt, ttl)
+	return c.byKey[key] != nil, nil
+}
@@ -51,6 +51,35 @@ type Options struct {
 
 	// TimeNow is used to override the behavior of default time.Now(), e.g. in tests.
 	TimeNow func() time.Time
+
+	// MaxBytes bounds the total size of entries measured by Sizer, 0 means unbounded.
+	// Entries larger than MaxBytes are not stored.
+	MaxBytes int64
+
+	// Sizer measures an entry when MaxBytes is set, DefaultSizer is used if nil.
+	Sizer Sizer
+
+	// Admission creates an optional admission policy deciding whether a new key may evict
+	// the least recently used one, e.g. NewTinyLFU. The capacity is the maxSize of the cache.
+	Admission func(capacity int) Admission
+
+	// WarmStartFile is loaded when the cache is created and written by Close,
+	// so a restarted process starts with the entries and remaining ttls of the previous one.
+	WarmStartFile string
+}
+
+// Sizer returns the size of an entry in bytes.
+type Sizer func(key string, value interface{}) int64
+
+// DefaultSizer counts the bytes of the key and of string or []byte values, other values count as 0.
+func DefaultSizer(key string, value interface{}) int64 {
+	switch v := value.(type) {
+	case string:
+		return int64(len(key) + len(v))
+	case []byte:
+		return int64(len(key) + len(v))
+	}
+	return int64(len(key))
 }
 
 // EvictCallback is a type for notifying applications when an item is
@@ -19,17 +19,30 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
 )
 
 // LRU is a concurrent fixed size cache that evicts elements in LRU order as well as by TTL.
+// The size is bounded by the number of entries, the total bytes of entries (Options.MaxBytes) or both.
+// Options.TTL is the default ttl of PutInterface, the caches.Cache and caches.Atomic (see Atomic) methods
+// use the ttl they are given and a zero ttl never expires.
 type LRU struct {
-	mux      sync.Mutex
-	byAccess *list.List
-	byKey    map[string]*list.Element
-	maxSize  int
-	ttl      time.Duration
-	TimeNow  func() time.Time
-	onEvict  EvictCallback
+	mux       sync.Mutex
+	byAccess  *list.List
+	byKey     map[string]*list.Element
+	maxSize   int
+	maxBytes  int64
+	bytes     int64
+	sizer     Sizer
+	admission Admission
+	ttl       time.Duration
+	TimeNow   func() time.Time
+	onEvict   EvictCallback
+	stats     caches.StatsCounter
+	buckets   map[string]map[string]struct{} // bucket => keys, written by SetWithBucket
+
+	warmStartFile string
 }
 
 // NewLRU creates a new LRU cache with default options.
@@ -41,6 +54,7 @@ func NewLRU(maxSize int, ttl time.Duration) *LRU {
 }
 
 // NewLRUWithOptions creates a new LRU cache with the given options.
+// A maxSize <= 0 does not bound the number of entries, use it with Options.MaxBytes.
 func NewLRUWithOptions(maxSize int, opts *Options) *LRU {
 	if opts == nil {
 		opts = &Options{}
@@ -48,14 +62,30 @@ func NewLRUWithOptions(maxSize int, opts *Options) *LRU {
 	if opts.TimeNow == nil {
 		opts.TimeNow = time.Now
 	}
-	return &LRU{
+	c := &LRU{
 		byAccess: list.New(),
 		byKey:    make(map[string]*list.Element, opts.InitialCapacity),
 		ttl:      opts.TTL,
 		maxSize:  maxSize,
+		maxBytes: opts.MaxBytes,
+		sizer:    opts.Sizer,
 		TimeNow:  opts.TimeNow,
 		onEvict:  opts.OnEvict,
+		buckets:  make(map[string]map[string]struct{}),
+	}
+	if c.sizer == nil {
+		c.sizer = DefaultSizer
+	}
+	if opts.Admission != nil {
+		capacity := maxSize
+		if capacity <= 0 {
+			capacity = defaultAdmissionCapacity
+		}
+		c.admission = opts.Admission(capacity)
 	}
+	c.warmStartFile = opts.WarmStartFile
+	warmStart(c.warmStartFile, c.LoadFile)
+	return c
 }
 
 // GetInterface retrieves the value stored under the given key
@@ -63,6 +93,23 @@ func (c *LRU) GetInterface(key string) interface{} {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
+	if c.admission != nil {
+		c.admission.Record(key)
+	}
+	elt := c.liveWithMutexHold(key)
+	if elt == nil {
+		c.stats.Miss()
+		return nil
+	}
+
+	c.byAccess.MoveToFront(elt)
+	c.stats.Hit()
+	return elt.Value.(*cacheEntry).value
+}
+
+// liveWithMutexHold returns the element of an unexpired entry, expired entries are removed.
+// Caller is expected to hold the c.mut mutex before calling.
+func (c *LRU) liveWithMutexHold(key string) *list.Element {
 	elt := c.byKey[key]
 	if elt == nil {
 		return nil
@@ -71,16 +118,24 @@ func (c *LRU) GetInterface(key string) interface{} {
 	cacheEntry := elt.Value.(*cacheEntry)
 	if !cacheEntry.expiration.IsZero() && c.TimeNow().After(cacheEntry.expiration) {
 		// Entry has expired
-		if c.onEvict != nil {
-			c.onEvict(cacheEntry.key, cacheEntry.value)
-		}
-		c.byAccess.Remove(elt)
-		delete(c.byKey, cacheEntry.key)
+		c.removeWithMutexHold(elt)
+		c.stats.Expire()
 		return nil
 	}
+	return elt
+}
 
-	c.byAccess.MoveToFront(elt)
-	return cacheEntry.value
+// removeWithMutexHold removes the element and calls OnEvict.
+// Caller is expected to hold the c.mut mutex before calling.
+func (c *LRU) removeWithMutexHold(elt *list.Element) *cacheEntry {
+	entry := c.byAccess.Remove(elt).(*cacheEntry)
+	if c.onEvict != nil {
+		c.onEvict(entry.key, entry.value)
+	}
+	delete(c.byKey, entry.key)
+	c.unindex(entry)
+	c.bytes -= entry.size
+	return entry
 }
 
 // PutInterface puts a new value associated with a given key, returning the existing value (if present)
@@ -88,7 +143,18 @@ func (c *LRU) PutInterface(key string, value interface{}) interface{} {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 	elt := c.byKey[key]
-	return c.putWithMutexHold(key, value, elt)
+	return c.putWithMutexHold(key, value, elt, c.ttl)
+}
+
+// PutInterfaceWithTTL puts a new value with its own ttl, a zero ttl falls back to the default ttl of the cache
+func (c *LRU) PutInterfaceWithTTL(key string, value interface{}, ttl time.Duration) interface{} {
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	elt := c.byKey[key]
+	return c.putWithMutexHold(key, value, elt, ttl)
 }
 
 // CompareAndSwap puts a new value associated with a given key if existing value matches oldValue.
@@ -110,56 +176,86 @@ func (c *LRU) CompareAndSwap(key string, oldValue, newValue interface{}) (itemIn
 			return entry.value, false
 		}
 	}
-	c.putWithMutexHold(key, newValue, elt)
+	c.putWithMutexHold(key, newValue, elt, c.ttl)
 	return newValue, true
 }
 
-// putWithMutexHold populates the cache and returns the inserted value.
+// putWithMutexHold populates the cache and returns the existing value.
+// A value larger than MaxBytes is not stored, and a new key may be rejected by the admission policy.
 // Caller is expected to hold the c.mut mutex before calling.
-func (c *LRU) putWithMutexHold(key string, value interface{}, elt *list.Element) interface{} {
+func (c *LRU) putWithMutexHold(key string, value interface{}, elt *list.Element, ttl time.Duration) interface{} {
+	var size int64
+	if c.maxBytes > 0 {
+		size = c.sizer(key, value)
+		if size > c.maxBytes {
+			if elt != nil {
+				return c.removeWithMutexHold(elt).value
+			}
+			return nil
+		}
+	}
+
 	if elt != nil {
 		entry := elt.Value.(*cacheEntry)
 		existing := entry.value
 		entry.value = value
-		if c.ttl != 0 {
-			entry.expiration = c.TimeNow().Add(c.ttl)
+		c.bytes += size - entry.size
+		entry.size = size
+		if ttl != 0 {
+			entry.expiration = c.TimeNow().Add(ttl)
+		} else {
+			entry.expiration = time.Time{}
 		}
 		c.byAccess.MoveToFront(elt)
+		c.evictWithMutexHold()
 		return existing
 	}
 
+	if c.admission != nil {
+		if victim := c.byAccess.Back(); victim != nil && c.fullWithMutexHold(size) &&
+			!c.admission.Admit(key, victim.Value.(*cacheEntry).key) {
+			return nil
+		}
+	}
+
 	entry := &cacheEntry{
 		key:   key,
 		value: value,
+		size:  size,
 	}
 
-	if c.ttl != 0 {
-		entry.expiration = c.TimeNow().Add(c.ttl)
+	if ttl != 0 {
+		entry.expiration = c.TimeNow().Add(ttl)
 	}
 	c.byKey[key] = c.byAccess.PushFront(entry)
-	for len(c.byKey) > c.maxSize {
-		oldest := c.byAccess.Remove(c.byAccess.Back()).(*cacheEntry)
-		if c.onEvict != nil {
-			c.onEvict(oldest.key, oldest.value)
-		}
-		delete(c.byKey, oldest.key)
-	}
+	c.bytes += size
+	c.evictWithMutexHold()
 
 	return nil
 }
 
+// fullWithMutexHold reports whether adding an entry of the given size needs an eviction.
+// Caller is expected to hold the c.mut mutex before calling.
+func (c *LRU) fullWithMutexHold(size int64) bool {
+	return (c.maxSize > 0 && len(c.byKey) >= c.maxSize) || (c.maxBytes > 0 && c.bytes+size > c.maxBytes)
+}
+
+// evictWithMutexHold evicts the least recently used entries until the cache is within its bounds.
+// Caller is expected to hold the c.mut mutex before calling.
+func (c *LRU) evictWithMutexHold() {
+	for (c.maxSize > 0 && len(c.byKey) > c.maxSize) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeWithMutexHold(c.byAccess.Back())
+		c.stats.Evict()
+	}
+}
+
 // Delete deletes a key, value pair associated with a key
 func (c *LRU) Delete(key string) error {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
-	elt := c.byKey[key]
-	if elt != nil {
-		entry := c.byAccess.Remove(elt).(*cacheEntry)
-		if c.onEvict != nil {
-			c.onEvict(entry.key, entry.value)
-		}
-		delete(c.byKey, key)
+	if elt := c.byKey[key]; elt != nil {
+		c.removeWithMutexHold(elt)
 	}
 
 	return nil
@@ -173,10 +269,39 @@ func (c *LRU) Size() int {
 	return len(c.byKey)
 }
 
+// Bytes returns the total size of entries currently in the lru, it is always 0 unless MaxBytes is set
+func (c *LRU) Bytes() int64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.bytes
+}
+
+// Stats returns hits, misses, evictions and expirations since the cache was created
+func (c *LRU) Stats() caches.Stats {
+	return c.stats.Snapshot(c.Size())
+}
+
 type cacheEntry struct {
 	key        string
+	bucket     string
 	expiration time.Time
 	value      interface{}
+	size       int64
+}
+
+// unindex removes the entry from its bucket, caller must hold the mutex
+func (c *LRU) unindex(entry *cacheEntry) {
+	if entry.bucket == "" {
+		return
+	}
+	if keys := c.buckets[entry.bucket]; keys != nil {
+		delete(keys, entry.key)
+		if len(keys) == 0 {
+			delete(c.buckets, entry.bucket)
+		}
+	}
+	entry.bucket = ""
 }
 
 func (c *LRU) GenerateCacheKey(bucket string, key string) string {
@@ -184,8 +309,11 @@ func (c *LRU) GenerateCacheKey(bucket string, key string) string {
 }
 
 func (c *LRU) Get(key string) (string, error) {
-	v := c.GetInterface(key)
-	return v.(string), nil
+	v, ok := c.GetInterface(key).(string)
+	if !ok {
+		return "", caches.ErrNotFound
+	}
+	return v, nil
 }
 
 func (c *LRU) GetMulti(keys []string) map[string]string {
@@ -198,29 +326,99 @@ func (c *LRU) GetMulti(keys []string) map[string]string {
 	return result
 }
 
+// Set follows caches.Cache, a zero ttl never expires. The default ttl only applies to PutInterface.
 func (c *LRU) Set(key string, value string, ttl time.Duration) error {
-	c.PutInterface(key, value)
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.putWithMutexHold(key, value, c.byKey[key], ttl)
 	return nil
 }
 
+// SetWithBucket follows caches.Cache, a zero ttl never expires.
 func (c *LRU) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
-	c.PutInterface(key, value)
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	elt := c.byKey[key]
+	c.putWithMutexHold(key, value, elt, ttl)
+
+	// the entry may have been evicted right away when maxSize is tiny
+	if elt = c.byKey[key]; elt == nil {
+		return nil
+	}
+	entry := elt.Value.(*cacheEntry)
+	if entry.bucket != bucket {
+		c.unindex(entry)
+		entry.bucket = bucket
+		if c.buckets[bucket] == nil {
+			c.buckets[bucket] = make(map[string]struct{})
+		}
+		c.buckets[bucket][key] = struct{}{}
+	}
 	return nil
 }
 
 func (c *LRU) Contains(key string) bool {
-	v := c.GetInterface(key)
-	return len(v.(string)) > 0
+	_, err := c.Get(key)
+	return err == nil
 }
 
+// Flush removes all entries, OnEvict is called for each of them
 func (c *LRU) Flush() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.onEvict != nil {
+		for elt := c.byAccess.Front(); elt != nil; elt = elt.Next() {
+			entry := elt.Value.(*cacheEntry)
+			c.onEvict(entry.key, entry.value)
+		}
+	}
+	c.byAccess.Init()
+	c.byKey = make(map[string]*list.Element)
+	c.buckets = make(map[string]map[string]struct{})
+	c.bytes = 0
 	return nil
 }
 
-func (c *LRU) Scan(prefix string, f func(key string) error) (err error) {
+// Scan calls f for every unexpired key written by SetWithBucket, the lock is not held while f runs
+func (c *LRU) Scan(bucket string, f func(key string) error) (err error) {
+	c.mux.Lock()
+	now := c.TimeNow()
+	keys := make([]string, 0, len(c.buckets[bucket]))
+	for key := range c.buckets[bucket] {
+		entry := c.byKey[key].Value.(*cacheEntry)
+		if entry.expiration.IsZero() || !now.After(entry.expiration) {
+			keys = append(keys, key)
+		}
+	}
+	c.mux.Unlock()
+
+	for _, key := range keys {
+		if err := f(key); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Fold calls f for every unexpired key, the lock is not held while f runs
 func (c *LRU) Fold(f func(key string) error) (err error) {
+	c.mux.Lock()
+	now := c.TimeNow()
+	keys := make([]string, 0, len(c.byKey))
+	for elt := c.byAccess.Front(); elt != nil; elt = elt.Next() {
+		entry := elt.Value.(*cacheEntry)
+		if entry.expiration.IsZero() || !now.After(entry.expiration) {
+			keys = append(keys, entry.key)
+		}
+	}
+	c.mux.Unlock()
+
+	for _, key := range keys {
+		if err := f(key); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,77 @@
+package mem
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+// go test -run xxx -bench . -benchmem ./components/caches/mem
+
+const (
+	benchKeys = 1 << 16
+	benchSize = 1 << 12
+)
+
+// benchKeySet zipf distributed keys, a few are hot and most are rarely used
+func benchKeySet() []string {
+	r := rand.New(rand.NewSource(1))
+	z := rand.NewZipf(r, 1.1, 1, benchKeys-1)
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprint("key:", z.Uint64())
+	}
+	return keys
+}
+
+// benchCache read-through workload in parallel, reports the hit ratio
+func benchCache(b *testing.B, cache caches.Cache) {
+	keys := benchKeySet()
+	value := string(make([]byte, 256))
+	var seed int64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(atomic.AddInt64(&seed, 7919))
+		for pb.Next() {
+			key := keys[i&(benchKeys-1)]
+			if _, err := cache.Get(key); err != nil {
+				cache.Set(key, value, time.Minute)
+			}
+			i++
+		}
+	})
+	b.StopTimer()
+
+	if p, ok := cache.(caches.StatsProvider); ok {
+		stats := p.Stats()
+		if total := stats.Hits + stats.Misses; total > 0 {
+			b.ReportMetric(float64(stats.Hits)/float64(total)*100, "hit%")
+		}
+	}
+}
+
+func BenchmarkLRU(b *testing.B) {
+	benchCache(b, NewLRU(benchSize, 0))
+}
+
+func BenchmarkLRUTinyLFU(b *testing.B) {
+	benchCache(b, NewLRUWithOptions(benchSize, &Options{Admission: NewTinyLFU}))
+}
+
+func BenchmarkLRUMaxBytes(b *testing.B) {
+	benchCache(b, NewLRUWithOptions(0, &Options{MaxBytes: benchSize * 256}))
+}
+
+func BenchmarkShardedLRU(b *testing.B) {
+	benchCache(b, NewShardedLRU(0, benchSize, nil))
+}
+
+func BenchmarkShardedLRUTinyLFU(b *testing.B) {
+	benchCache(b, NewShardedLRU(0, benchSize, &Options{Admission: NewTinyLFU}))
+}
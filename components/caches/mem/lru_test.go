@@ -19,6 +19,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,35 +31,35 @@ func TestLRU(t *testing.T) {
 		},
 	})
 
-	cache.Put("A", "Foo")
-	assert.Equal(t, "Foo", cache.Get("A"))
-	assert.Nil(t, cache.Get("B"))
+	cache.PutInterface("A", "Foo")
+	assert.Equal(t, "Foo", cache.GetInterface("A"))
+	assert.Nil(t, cache.GetInterface("B"))
 	assert.Equal(t, 1, cache.Size())
 
-	cache.Put("B", "Bar")
-	cache.Put("C", "Cid")
-	cache.Put("D", "Delt")
+	cache.PutInterface("B", "Bar")
+	cache.PutInterface("C", "Cid")
+	cache.PutInterface("D", "Delt")
 	assert.Equal(t, 4, cache.Size())
 
-	assert.Equal(t, "Bar", cache.Get("B"))
-	assert.Equal(t, "Cid", cache.Get("C"))
-	assert.Equal(t, "Delt", cache.Get("D"))
+	assert.Equal(t, "Bar", cache.GetInterface("B"))
+	assert.Equal(t, "Cid", cache.GetInterface("C"))
+	assert.Equal(t, "Delt", cache.GetInterface("D"))
 
-	cache.Put("A", "Foo2")
-	assert.Equal(t, "Foo2", cache.Get("A"))
+	cache.PutInterface("A", "Foo2")
+	assert.Equal(t, "Foo2", cache.GetInterface("A"))
 
-	cache.Put("E", "Epsi")
-	assert.Equal(t, "Epsi", cache.Get("E"))
-	assert.Equal(t, "Foo2", cache.Get("A"))
-	assert.Nil(t, cache.Get("B")) // Oldest, should be evicted
+	cache.PutInterface("E", "Epsi")
+	assert.Equal(t, "Epsi", cache.GetInterface("E"))
+	assert.Equal(t, "Foo2", cache.GetInterface("A"))
+	assert.Nil(t, cache.GetInterface("B")) // Oldest, should be evicted
 
 	// Access C, D is now LRU
-	cache.Get("C")
-	cache.Put("F", "Felp")
-	assert.Nil(t, cache.Get("D"))
+	cache.GetInterface("C")
+	cache.PutInterface("F", "Felp")
+	assert.Nil(t, cache.GetInterface("D"))
 
 	cache.Delete("A")
-	assert.Nil(t, cache.Get("A"))
+	assert.Nil(t, cache.GetInterface("A"))
 }
 
 func TestCompareAndSwap(t *testing.T) {
@@ -66,42 +68,42 @@ func TestCompareAndSwap(t *testing.T) {
 	item, ok := cache.CompareAndSwap("A", nil, "Foo")
 	assert.Equal(t, true, ok)
 	assert.Equal(t, "Foo", item)
-	assert.Equal(t, "Foo", cache.Get("A"))
-	assert.Nil(t, cache.Get("B"))
+	assert.Equal(t, "Foo", cache.GetInterface("A"))
+	assert.Nil(t, cache.GetInterface("B"))
 	assert.Equal(t, 1, cache.Size())
 
 	item, ok = cache.CompareAndSwap("B", nil, "Bar")
 	assert.True(t, ok)
 	assert.Equal(t, 2, cache.Size())
 	assert.Equal(t, "Bar", item)
-	assert.Equal(t, "Bar", cache.Get("B"))
+	assert.Equal(t, "Bar", cache.GetInterface("B"))
 
 	item, ok = cache.CompareAndSwap("A", "Foo", "Foo2")
 	assert.True(t, ok)
 	assert.Equal(t, "Foo2", item)
-	assert.Equal(t, "Foo2", cache.Get("A"))
+	assert.Equal(t, "Foo2", cache.GetInterface("A"))
 
 	item, ok = cache.CompareAndSwap("A", nil, "Foo3")
 	assert.False(t, ok)
 	assert.Equal(t, "Foo2", item)
-	assert.Equal(t, "Foo2", cache.Get("A"))
+	assert.Equal(t, "Foo2", cache.GetInterface("A"))
 
 	item, ok = cache.CompareAndSwap("A", "Foo", "Foo3")
 	assert.False(t, ok)
 	assert.Equal(t, "Foo2", item)
-	assert.Equal(t, "Foo2", cache.Get("A"))
+	assert.Equal(t, "Foo2", cache.GetInterface("A"))
 
 	item, ok = cache.CompareAndSwap("F", "foo", "Foo3")
 	assert.False(t, ok)
 	assert.Nil(t, item)
-	assert.Nil(t, cache.Get("F"))
+	assert.Nil(t, cache.GetInterface("F"))
 
 	// Evict the oldest entry
 	item, ok = cache.CompareAndSwap("E", nil, "Epsi")
 	assert.True(t, ok)
 	assert.Equal(t, "Epsi", item)
-	assert.Equal(t, "Foo2", cache.Get("A"))
-	assert.Nil(t, cache.Get("B")) // Oldest, should be evicted
+	assert.Equal(t, "Foo2", cache.GetInterface("A"))
+	assert.Nil(t, cache.GetInterface("B")) // Oldest, should be evicted
 }
 
 func TestLRUWithTTL(t *testing.T) {
@@ -110,18 +112,18 @@ func TestLRUWithTTL(t *testing.T) {
 		TTL:     time.Millisecond * 100,
 		TimeNow: clk.Now,
 	})
-	cache.Put("A", "Foo")
-	assert.Equal(t, "Foo", cache.Get("A"))
+	cache.PutInterface("A", "Foo")
+	assert.Equal(t, "Foo", cache.GetInterface("A"))
 
 	item, _ := cache.CompareAndSwap("A", "Foo", "Foo2")
 	assert.Equal(t, "Foo2", item)
-	assert.Equal(t, "Foo2", cache.Get("A"))
+	assert.Equal(t, "Foo2", cache.GetInterface("A"))
 
 	clk.Elapse(time.Millisecond * 50)
-	assert.Equal(t, "Foo2", cache.Get("A"))
+	assert.Equal(t, "Foo2", cache.GetInterface("A"))
 
 	clk.Elapse(time.Millisecond * 100)
-	assert.Nil(t, cache.Get("A"))
+	assert.Nil(t, cache.GetInterface("A"))
 	assert.Equal(t, 0, cache.Size())
 }
 
@@ -129,10 +131,10 @@ func TestDefaultClock(t *testing.T) {
 	cache := NewLRUWithOptions(5, &Options{
 		TTL: time.Millisecond * 1,
 	})
-	cache.Put("A", "foo")
-	assert.Equal(t, "foo", cache.Get("A"))
+	cache.PutInterface("A", "foo")
+	assert.Equal(t, "foo", cache.GetInterface("A"))
 	time.Sleep(time.Millisecond * 3)
-	assert.Nil(t, cache.Get("A"))
+	assert.Nil(t, cache.GetInterface("A"))
 	assert.Equal(t, 0, cache.Size())
 }
 
@@ -147,7 +149,7 @@ func TestLRUCacheConcurrentAccess(t *testing.T) {
 	}
 
 	for k, v := range values {
-		cache.Put(k, v)
+		cache.PutInterface(k, v)
 	}
 
 	start := make(chan struct{})
@@ -161,7 +163,7 @@ func TestLRUCacheConcurrentAccess(t *testing.T) {
 			<-start
 
 			for i := 0; i < 1000; i++ {
-				cache.Get("A")
+				cache.GetInterface("A")
 			}
 		}()
 	}
@@ -182,9 +184,9 @@ func TestRemoveFunc(t *testing.T) {
 		},
 	})
 
-	cache.Put("testing", t)
+	cache.PutInterface("testing", t)
 	cache.Delete("testing")
-	assert.Nil(t, cache.Get("testing"))
+	assert.Nil(t, cache.GetInterface("testing"))
 
 	timeout := time.NewTimer(time.Millisecond * 300)
 	select {
@@ -208,10 +210,10 @@ func TestRemovedFuncWithTTL(t *testing.T) {
 		},
 	})
 
-	cache.Put("A", t)
-	assert.Equal(t, t, cache.Get("A"))
+	cache.PutInterface("A", t)
+	assert.Equal(t, t, cache.GetInterface("A"))
 	time.Sleep(time.Millisecond * 10)
-	assert.Nil(t, cache.Get("A"))
+	assert.Nil(t, cache.GetInterface("A"))
 
 	timeout := time.NewTimer(time.Millisecond * 30)
 	select {
@@ -239,3 +241,183 @@ func (c *simulatedClock) Elapse(d time.Duration) time.Time {
 	c.currTime = c.currTime.Add(d)
 	return c.currTime
 }
+
+func TestCacheInterface(t *testing.T) {
+	clk := &simulatedClock{}
+	cache := NewLRUWithOptions(5, &Options{
+		TTL:     time.Minute,
+		TimeNow: clk.Now,
+	})
+
+	v, err := cache.Get("A")
+	assert.Equal(t, caches.ErrNotFound, err)
+	assert.Equal(t, "", v)
+	assert.False(t, cache.Contains("A"))
+
+	cache.Set("A", "foo", time.Second)
+	cache.Set("B", "bar", 0)
+	assert.True(t, cache.Contains("A"))
+	assert.Equal(t, map[string]string{"A": "foo", "B": "bar"}, cache.GetMulti([]string{"A", "B"}))
+
+	// A uses its own ttl, B never expires: the default ttl only applies to PutInterface
+	cache.PutInterface("C", "zed")
+	clk.Elapse(time.Second * 2)
+	assert.False(t, cache.Contains("A"))
+	assert.True(t, cache.Contains("B"))
+	clk.Elapse(time.Minute)
+	assert.True(t, cache.Contains("B"))
+	assert.False(t, cache.Contains("C"))
+
+	var keys []string
+	cache.Fold(func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	assert.Equal(t, []string{"B"}, keys)
+
+	cache.Flush()
+	assert.Equal(t, 0, cache.Size())
+	assert.False(t, cache.Contains("B"))
+}
+
+func TestStats(t *testing.T) {
+	clk := &simulatedClock{}
+	cache := NewLRUWithOptions(2, &Options{
+		TTL:     time.Minute,
+		TimeNow: clk.Now,
+	})
+
+	cache.PutInterface("A", "foo")
+	cache.PutInterface("B", "bar")
+	cache.PutInterface("C", "zed") // evicts A
+	cache.GetInterface("A")
+	cache.GetInterface("B")
+	clk.Elapse(time.Minute * 2)
+	cache.GetInterface("C")
+
+	assert.Equal(t, caches.Stats{Hits: 1, Misses: 2, Evictions: 1, Expirations: 1, Size: 1}, cache.Stats())
+}
+
+func TestConformance(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		return NewLRU(1000, 0)
+	}, cachetest.WithTTL(time.Millisecond*50))
+}
+
+// TestConformanceDefaultTTL the default ttl is shorter than the sleeps of the suite, ttl 0 must still never expire
+func TestConformanceDefaultTTL(t *testing.T) {
+	factory := func(t *testing.T) caches.Cache {
+		return NewLRU(1000, 20*time.Millisecond).Atomic()
+	}
+	cachetest.Run(t, factory, cachetest.WithTTL(50*time.Millisecond))
+	cachetest.RunAtomic(t, factory, cachetest.WithTTL(50*time.Millisecond))
+}
+
+func TestAtomic(t *testing.T) {
+	cachetest.RunAtomic(t, func(t *testing.T) caches.Cache {
+		return NewLRU(1000, 0).Atomic()
+	}, cachetest.WithTTL(50*time.Millisecond))
+}
+
+// TestAtomicAdmission keys rejected by the admission policy are reported, not counted as stored
+func TestAtomicAdmission(t *testing.T) {
+	cache := NewLRUWithOptions(2, &Options{Admission: NewTinyLFU}).Atomic()
+	cache.Set("a", "1", 0)
+	cache.Set("b", "1", 0)
+	for i := 0; i < 5; i++ {
+		cache.Get("a")
+		cache.Get("b")
+	}
+
+	_, err := cache.Incr("c", 1, 0)
+	assert.ErrorIs(t, err, ErrNotStored)
+	ok, err := cache.SetNX("d", "1", 0)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.False(t, cache.Contains("c"))
+	assert.False(t, cache.Contains("d"))
+
+	n, err := cache.Incr("a", 2, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+}
+
+func TestAtomicMaxBytes(t *testing.T) {
+	cache := NewLRUWithOptions(0, &Options{MaxBytes: 10}).Atomic()
+
+	_, err := cache.Incr("n", 1, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), cache.Bytes())
+	n, err := cache.Incr("n", 99, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), n)
+	assert.Equal(t, int64(4), cache.Bytes())
+
+	// a value larger than MaxBytes drops the key
+	_, err = cache.Incr("n", 1000000000, 0)
+	assert.ErrorIs(t, err, ErrNotStored)
+	assert.Equal(t, int64(0), cache.Bytes())
+	assert.Equal(t, 0, cache.Size())
+
+	ok, _ := cache.SetNX("n", "1234567890", 0)
+	assert.False(t, ok)
+	cache.Set("n", "1", 0)
+	ok, _ = cache.CompareAndSwap("n", "1", "1234567890", 0)
+	assert.False(t, ok)
+}
+
+func TestMaxBytes(t *testing.T) {
+	evicted := 0
+	cache := NewLRUWithOptions(0, &Options{
+		MaxBytes: 10,
+		OnEvict:  func(k string, i interface{}) { evicted++ },
+	})
+
+	cache.PutInterface("a", "1234") // 5 bytes
+	cache.PutInterface("b", "1234")
+	assert.Equal(t, int64(10), cache.Bytes())
+
+	cache.PutInterface("c", "12")
+	assert.Nil(t, cache.GetInterface("a"))
+	assert.Equal(t, int64(8), cache.Bytes())
+	assert.Equal(t, 1, evicted)
+
+	// growing an entry evicts others
+	cache.PutInterface("c", "123456789")
+	assert.Nil(t, cache.GetInterface("b"))
+	assert.Equal(t, "123456789", cache.GetInterface("c"))
+	assert.Equal(t, int64(10), cache.Bytes())
+
+	// entries larger than MaxBytes are not stored and drop the old value
+	cache.PutInterface("c", "12345678901")
+	assert.Nil(t, cache.GetInterface("c"))
+	assert.Equal(t, 0, cache.Size())
+	assert.Equal(t, int64(0), cache.Bytes())
+
+	cache.PutInterface("d", "1")
+	cache.Delete("d")
+	assert.Equal(t, int64(0), cache.Bytes())
+
+	cache.PutInterface("e", "1")
+	cache.Flush()
+	assert.Equal(t, int64(0), cache.Bytes())
+}
+
+func TestMaxBytesWithSizer(t *testing.T) {
+	cache := NewLRUWithOptions(2, &Options{
+		MaxBytes: 100,
+		Sizer:    func(key string, value interface{}) int64 { return 40 },
+	})
+	cache.PutInterface("a", 1)
+	cache.PutInterface("b", 2)
+	cache.PutInterface("c", 3)
+	// bounded by count first
+	assert.Equal(t, 2, cache.Size())
+	assert.Equal(t, int64(80), cache.Bytes())
+}
+
+func TestMaxBytesConformance(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		return NewLRUWithOptions(0, &Options{MaxBytes: 1 << 20})
+	}, cachetest.WithTTL(50*time.Millisecond))
+}
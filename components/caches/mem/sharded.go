@@ -0,0 +1,197 @@
+package mem
+
+import (
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+// defaultShards is used when NewShardedLRU is called with shards <= 0
+const defaultShards = 16
+
+// ShardedLRU spreads keys over several LRU shards by hash, each with its own mutex,
+// to reduce lock contention under concurrent load. Eviction and admission are per shard.
+type ShardedLRU struct {
+	shards []*LRU
+	shift  uint
+
+	warmStartFile string
+}
+
+var (
+	_ caches.Cache         = (*ShardedLRU)(nil)
+	_ caches.Atomic        = (*ShardedLRU)(nil)
+	_ caches.StatsProvider = (*ShardedLRU)(nil)
+)
+
+// NewShardedLRU creates a sharded LRU, shards is rounded up to a power of two.
+// maxSize and Options.MaxBytes are the totals and are split evenly over the shards.
+func NewShardedLRU(shards int, maxSize int, opts *Options) *ShardedLRU {
+	if shards <= 0 {
+		shards = defaultShards
+	}
+	n := 1 << bits.Len(uint(shards-1))
+
+	shardOpts := Options{}
+	if opts != nil {
+		shardOpts = *opts
+	}
+	if shardOpts.MaxBytes > 0 {
+		shardOpts.MaxBytes = (shardOpts.MaxBytes + int64(n) - 1) / int64(n)
+	}
+	shardOpts.InitialCapacity = (shardOpts.InitialCapacity + n - 1) / n
+	// the snapshot is loaded and saved by the ShardedLRU, not by each shard
+	shardOpts.WarmStartFile = ""
+	shardSize := maxSize
+	if shardSize > 0 {
+		shardSize = (maxSize + n - 1) / n
+	}
+
+	c := &ShardedLRU{
+		shards: make([]*LRU, n),
+		shift:  uint(64 - bits.Len(uint(n-1))),
+	}
+	for i := range c.shards {
+		o := shardOpts
+		c.shards[i] = NewLRUWithOptions(shardSize, &o)
+	}
+	if opts != nil {
+		c.warmStartFile = opts.WarmStartFile
+		warmStart(c.warmStartFile, c.LoadFile)
+	}
+	return c
+}
+
+// shard picks the shard by the high bits of the hash, the low bits are left to TinyLFU
+func (c *ShardedLRU) shard(key string) *LRU {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[hash(key)>>c.shift]
+}
+
+// GetInterface retrieves the value stored under the given key
+func (c *ShardedLRU) GetInterface(key string) interface{} {
+	return c.shard(key).GetInterface(key)
+}
+
+// PutInterface puts a new value associated with a given key, returning the existing value (if present)
+func (c *ShardedLRU) PutInterface(key string, value interface{}) interface{} {
+	return c.shard(key).PutInterface(key, value)
+}
+
+// PutInterfaceWithTTL puts a new value with its own ttl, a zero ttl falls back to the default ttl of the cache
+func (c *ShardedLRU) PutInterfaceWithTTL(key string, value interface{}, ttl time.Duration) interface{} {
+	return c.shard(key).PutInterfaceWithTTL(key, value, ttl)
+}
+
+// Size returns the number of entries currently in all shards
+func (c *ShardedLRU) Size() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.Size()
+	}
+	return n
+}
+
+// Bytes returns the total size of entries in all shards
+func (c *ShardedLRU) Bytes() int64 {
+	var n int64
+	for _, s := range c.shards {
+		n += s.Bytes()
+	}
+	return n
+}
+
+// Stats sums the stats of all shards
+func (c *ShardedLRU) Stats() caches.Stats {
+	var stats caches.Stats
+	for _, s := range c.shards {
+		st := s.Stats()
+		stats.Hits += st.Hits
+		stats.Misses += st.Misses
+		stats.Evictions += st.Evictions
+		stats.Expirations += st.Expirations
+		stats.Size += st.Size
+	}
+	return stats
+}
+
+func (c *ShardedLRU) GenerateCacheKey(bucket string, key string) string {
+	return fmt.Sprintf("%s:%s", bucket, key)
+}
+
+func (c *ShardedLRU) Get(key string) (string, error) {
+	return c.shard(key).Get(key)
+}
+
+func (c *ShardedLRU) GetMulti(keys []string) map[string]string {
+	result := make(map[string]string)
+	for _, key := range keys {
+		if value, err := c.Get(key); err == nil {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func (c *ShardedLRU) Set(key string, value string, ttl time.Duration) error {
+	return c.shard(key).Set(key, value, ttl)
+}
+
+func (c *ShardedLRU) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
+	return c.shard(key).SetWithBucket(bucket, key, value, ttl)
+}
+
+func (c *ShardedLRU) Contains(key string) bool {
+	return c.shard(key).Contains(key)
+}
+
+func (c *ShardedLRU) Delete(key string) error {
+	return c.shard(key).Delete(key)
+}
+
+func (c *ShardedLRU) Flush() error {
+	for _, s := range c.shards {
+		s.Flush()
+	}
+	return nil
+}
+
+// Scan calls f for every unexpired key of the bucket, shard by shard
+func (c *ShardedLRU) Scan(bucket string, f func(key string) error) (err error) {
+	for _, s := range c.shards {
+		if err := s.Scan(bucket, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fold calls f for every unexpired key, shard by shard
+func (c *ShardedLRU) Fold(f func(key string) error) (err error) {
+	for _, s := range c.shards {
+		if err := s.Fold(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ShardedLRU) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.shard(key).Incr(key, delta, ttl)
+}
+
+func (c *ShardedLRU) Decr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.shard(key).Decr(key, delta, ttl)
+}
+
+func (c *ShardedLRU) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return c.shard(key).SetNX(key, value, ttl)
+}
+
+func (c *ShardedLRU) CompareAndSwap(key string, old string, new string, ttl time.Duration) (bool, error) {
+	return c.shard(key).compareAndSwapString(key, old, new, ttl)
+}
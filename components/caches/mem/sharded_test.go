@@ -0,0 +1,46 @@
+package mem
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedLRU(t *testing.T) {
+	cache := NewShardedLRU(3, 100, nil)
+	assert.Len(t, cache.shards, 4)
+	assert.Equal(t, 25, cache.shards[0].maxSize)
+
+	for i := 0; i < 1000; i++ {
+		cache.PutInterface(fmt.Sprint(i), i)
+	}
+	assert.LessOrEqual(t, cache.Size(), 100)
+	assert.Greater(t, cache.Size(), 80)
+	for _, s := range cache.shards {
+		assert.Greater(t, s.Size(), 0, "keys should spread over all shards")
+	}
+	assert.Equal(t, cache.Size(), cache.Stats().Size)
+	assert.Equal(t, uint64(1000-cache.Size()), cache.Stats().Evictions)
+}
+
+func TestShardedLRUMaxBytes(t *testing.T) {
+	cache := NewShardedLRU(4, 0, &Options{MaxBytes: 400})
+	assert.Equal(t, int64(100), cache.shards[0].maxBytes)
+
+	for i := 0; i < 1000; i++ {
+		cache.Set(fmt.Sprintf("%04d", i), "123456", time.Minute)
+	}
+	assert.LessOrEqual(t, cache.Bytes(), int64(400))
+}
+
+func TestShardedConformance(t *testing.T) {
+	factory := func(t *testing.T) caches.Cache {
+		return NewShardedLRU(8, 1000, &Options{TTL: 20 * time.Millisecond})
+	}
+	cachetest.Run(t, factory, cachetest.WithTTL(50*time.Millisecond))
+	cachetest.RunAtomic(t, factory, cachetest.WithTTL(50*time.Millisecond))
+}
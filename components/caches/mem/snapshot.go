@@ -0,0 +1,157 @@
+package mem
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+var (
+	_ caches.Snapshotter = (*LRU)(nil)
+	_ caches.Snapshotter = (*ShardedLRU)(nil)
+)
+
+// Snapshot writes unexpired string entries from the least to the most recently used,
+// so that Restore rebuilds the same recency order. Values of other types are skipped.
+func (c *LRU) Snapshot(w io.Writer) error {
+	entries := c.snapshotEntries()
+	return caches.WriteSnapshot(w, func(write func(e caches.SnapshotEntry) error) error {
+		for _, e := range entries {
+			if err := write(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Restore puts the unexpired entries of the snapshot with their remaining ttl
+func (c *LRU) Restore(r io.Reader) error {
+	return caches.Restore(c, r)
+}
+
+// SaveFile writes a snapshot to path, through a temporary file so that a crash never leaves a partial snapshot
+func (c *LRU) SaveFile(path string) error {
+	return saveFile(path, c.Snapshot)
+}
+
+// LoadFile restores the snapshot at path, a missing file is not an error
+func (c *LRU) LoadFile(path string) error {
+	return loadFile(path, c.Restore)
+}
+
+// Close saves the snapshot to Options.WarmStartFile, if set
+func (c *LRU) Close() error {
+	if c.warmStartFile == "" {
+		return nil
+	}
+	return c.SaveFile(c.warmStartFile)
+}
+
+// snapshotEntries copies the unexpired string entries while holding the lock
+func (c *LRU) snapshotEntries() []caches.SnapshotEntry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	now := c.TimeNow()
+	entries := make([]caches.SnapshotEntry, 0, len(c.byKey))
+	for elt := c.byAccess.Back(); elt != nil; elt = elt.Prev() {
+		entry := elt.Value.(*cacheEntry)
+		value, ok := entry.value.(string)
+		if !ok || (!entry.expiration.IsZero() && now.After(entry.expiration)) {
+			continue
+		}
+		entries = append(entries, caches.SnapshotEntry{
+			Key:      entry.key,
+			Value:    value,
+			Bucket:   entry.bucket,
+			ExpireAt: entry.expiration,
+		})
+	}
+	return entries
+}
+
+// Snapshot writes the entries of all shards
+func (c *ShardedLRU) Snapshot(w io.Writer) error {
+	return caches.WriteSnapshot(w, func(write func(e caches.SnapshotEntry) error) error {
+		for _, s := range c.shards {
+			for _, e := range s.snapshotEntries() {
+				if err := write(e); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Restore puts the unexpired entries of the snapshot with their remaining ttl
+func (c *ShardedLRU) Restore(r io.Reader) error {
+	return caches.Restore(c, r)
+}
+
+// SaveFile writes a snapshot to path, see LRU.SaveFile
+func (c *ShardedLRU) SaveFile(path string) error {
+	return saveFile(path, c.Snapshot)
+}
+
+// LoadFile restores the snapshot at path, a missing file is not an error
+func (c *ShardedLRU) LoadFile(path string) error {
+	return loadFile(path, c.Restore)
+}
+
+// Close saves the snapshot to Options.WarmStartFile, if set
+func (c *ShardedLRU) Close() error {
+	if c.warmStartFile == "" {
+		return nil
+	}
+	return c.SaveFile(c.warmStartFile)
+}
+
+func saveFile(path string, snapshot func(w io.Writer) error) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := snapshot(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func loadFile(path string, restore func(r io.Reader) error) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return restore(f)
+}
+
+// warmStart loads Options.WarmStartFile at boot, a broken snapshot is logged and ignored
+func warmStart(path string, load func(path string) error) {
+	if path == "" {
+		return
+	}
+	if err := load(path); err != nil {
+		log.Printf("[mem] warm start from %s: %v", path, err)
+	}
+}
@@ -0,0 +1,68 @@
+package mem
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	cachetest.RunSnapshot(t, func(t *testing.T) caches.Cache {
+		return NewLRU(1000, 0)
+	}, cachetest.WithTTL(50*time.Millisecond))
+}
+
+func TestShardedSnapshot(t *testing.T) {
+	cachetest.RunSnapshot(t, func(t *testing.T) caches.Cache {
+		return NewShardedLRU(4, 1000, nil)
+	}, cachetest.WithTTL(50*time.Millisecond))
+}
+
+func TestSnapshotKeepsRecency(t *testing.T) {
+	src := NewLRU(3, 0)
+	src.Set("a", "1", 0)
+	src.Set("b", "2", 0)
+	src.Set("c", "3", 0)
+	src.Get("a")
+
+	path := filepath.Join(t.TempDir(), "lru.snap")
+	assert.Nil(t, src.SaveFile(path))
+
+	dst := NewLRU(3, 0)
+	assert.Nil(t, dst.LoadFile(path))
+	// b is the least recently used
+	dst.Set("d", "4", 0)
+	assert.False(t, dst.Contains("b"))
+	assert.True(t, dst.Contains("a"))
+}
+
+func TestWarmStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lru.snap")
+
+	// missing file is fine
+	c := NewLRUWithOptions(100, &Options{WarmStartFile: path})
+	c.Set("a", "1", time.Hour)
+	c.SetWithBucket("foo", "foo:1", "2", 0)
+	assert.Nil(t, c.Close())
+
+	c = NewLRUWithOptions(100, &Options{WarmStartFile: path})
+	v, err := c.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+
+	var keys []string
+	c.Scan("foo", func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	assert.Equal(t, []string{"foo:1"}, keys)
+
+	sharded := NewShardedLRU(4, 100, &Options{WarmStartFile: path})
+	assert.Equal(t, 2, sharded.Size())
+	assert.Nil(t, sharded.Close())
+	assert.Equal(t, 2, NewShardedLRU(2, 100, &Options{WarmStartFile: path}).Size())
+}
@@ -0,0 +1,141 @@
+package mem
+
+// defaultAdmissionCapacity is used to size the admission policy when the lru is bounded by bytes only
+const defaultAdmissionCapacity = 10000
+
+// Admission decides whether a new key is worth evicting the least recently used one.
+// It is called with the lock of the lru held, so implementations need not be safe for concurrent use.
+type Admission interface {
+	// Record is called on every read of key, hit or miss
+	Record(key string)
+
+	// Admit reports whether candidate should replace victim
+	Admit(candidate string, victim string) bool
+}
+
+// TinyLFU is an admission policy that keeps approximate access frequencies in a count-min sketch,
+// a new key is admitted only if it is used more often than the entry it would evict.
+// This keeps one-hit wonders, e.g. a crawler walking every page once, from flushing the hot entries.
+//
+// See "TinyLFU: A Highly Efficient Cache Admission Policy", Einziger et al.
+type TinyLFU struct {
+	sketch     [sketchDepth][]uint8
+	door       []uint64 // doorkeeper bloom filter, the first access of a key only lands here
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+const (
+	sketchDepth = 4
+	maxCount    = 15
+)
+
+var _ Admission = (*TinyLFU)(nil)
+
+// NewTinyLFU creates a TinyLFU sized for capacity entries, it matches Options.Admission
+func NewTinyLFU(capacity int) Admission {
+	width := 64
+	for width < capacity {
+		width <<= 1
+	}
+	t := &TinyLFU{
+		door:       make([]uint64, width/64),
+		mask:       uint64(width - 1),
+		sampleSize: 10 * width,
+	}
+	for i := range t.sketch {
+		t.sketch[i] = make([]uint8, width)
+	}
+	return t
+}
+
+func (t *TinyLFU) Record(key string) {
+	h := hash(key)
+	if !t.allowDoor(h) {
+		t.setDoor(h)
+	} else {
+		for i := range t.sketch {
+			idx := t.index(h, i)
+			if t.sketch[i][idx] < maxCount {
+				t.sketch[i][idx]++
+			}
+		}
+	}
+
+	t.additions++
+	if t.additions >= t.sampleSize {
+		t.reset()
+	}
+}
+
+func (t *TinyLFU) Admit(candidate string, victim string) bool {
+	return t.Estimate(candidate) > t.Estimate(victim)
+}
+
+// Estimate returns the approximate access frequency of key
+func (t *TinyLFU) Estimate(key string) int {
+	h := hash(key)
+	min := uint8(maxCount)
+	for i := range t.sketch {
+		if v := t.sketch[i][t.index(h, i)]; v < min {
+			min = v
+		}
+	}
+	n := int(min)
+	if t.allowDoor(h) {
+		n++
+	}
+	return n
+}
+
+// reset halves all counters and clears the doorkeeper so old popularity fades out
+func (t *TinyLFU) reset() {
+	t.additions = 0
+	for i := range t.sketch {
+		for j := range t.sketch[i] {
+			t.sketch[i][j] >>= 1
+		}
+	}
+	for i := range t.door {
+		t.door[i] = 0
+	}
+}
+
+// index derives the column of row i by double hashing
+func (t *TinyLFU) index(h uint64, i int) uint64 {
+	return (h + uint64(i)*(h>>32|1)) & t.mask
+}
+
+func (t *TinyLFU) allowDoor(h uint64) bool {
+	for i := 0; i < 2; i++ {
+		idx := t.index(h, i)
+		if t.door[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *TinyLFU) setDoor(h uint64) {
+	for i := 0; i < 2; i++ {
+		idx := t.index(h, i)
+		t.door[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+// hash is 64-bit FNV-1a with the murmur3 finalizer so that both high and low bits are well mixed,
+// inlined to avoid allocations
+func hash(key string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,76 @@
+package mem
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTinyLFUEstimate(t *testing.T) {
+	lfu := NewTinyLFU(100).(*TinyLFU)
+	assert.Equal(t, 0, lfu.Estimate("a"))
+
+	// the first access only lands in the doorkeeper
+	lfu.Record("a")
+	assert.Equal(t, 1, lfu.Estimate("a"))
+
+	for i := 0; i < 5; i++ {
+		lfu.Record("a")
+	}
+	assert.Equal(t, 6, lfu.Estimate("a"))
+	assert.True(t, lfu.Admit("a", "b"))
+	assert.False(t, lfu.Admit("b", "a"))
+
+	// counters saturate
+	for i := 0; i < 100; i++ {
+		lfu.Record("a")
+	}
+	assert.Equal(t, maxCount+1, lfu.Estimate("a"))
+}
+
+func TestTinyLFUReset(t *testing.T) {
+	lfu := NewTinyLFU(64).(*TinyLFU)
+	for i := 0; i < 9; i++ {
+		lfu.Record("a")
+	}
+	before := lfu.Estimate("a")
+
+	// fill up the sample to trigger aging
+	for i := lfu.additions; i < lfu.sampleSize; i++ {
+		lfu.Record(fmt.Sprint("x", i))
+	}
+	assert.Equal(t, 0, lfu.additions)
+	assert.Less(t, lfu.Estimate("a"), before)
+}
+
+// TestTinyLFUScanResistance bursts of one-hit keys do not flush the hot entries
+func TestTinyLFUScanResistance(t *testing.T) {
+	run := func(opts *Options) int {
+		cache := NewLRUWithOptions(100, opts)
+		get := func(key string) {
+			if cache.GetInterface(key) == nil {
+				cache.PutInterface(key, key)
+			}
+		}
+		for burst := 0; burst < 10; burst++ {
+			for i := 0; i < 50; i++ {
+				get(fmt.Sprint("hot", i))
+			}
+			for i := 0; i < 200; i++ {
+				get(fmt.Sprint("scan", burst, "-", i))
+			}
+		}
+
+		kept := 0
+		for i := 0; i < 50; i++ {
+			if cache.GetInterface(fmt.Sprint("hot", i)) != nil {
+				kept++
+			}
+		}
+		return kept
+	}
+
+	assert.Equal(t, 0, run(&Options{}))
+	assert.Equal(t, 50, run(&Options{Admission: NewTinyLFU}))
+}
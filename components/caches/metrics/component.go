@@ -0,0 +1,316 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+const PackageName = "component.caches.metrics"
+
+// 统计的操作
+const (
+	OpGet      = "get"
+	OpGetMulti = "get_multi"
+	OpSet      = "set"
+	OpContains = "contains"
+	OpDelete   = "delete"
+	OpFlush    = "flush"
+	OpScan     = "scan"
+	OpFold     = "fold"
+)
+
+// Component 统计任意 caches.Cache 的命中、写入、错误和延迟，按 bucket 分组
+// 实现了 http.Handler，可直接挂在管理后台输出 Snapshot
+type Component struct {
+	config  *config
+	cache   caches.Cache
+	buckets sync.Map // bucket => *bucketStats
+}
+
+var _ caches.Cache = (*Component)(nil)
+
+type bucketStats struct {
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	sets    atomic.Uint64
+	deletes atomic.Uint64
+	errors  atomic.Uint64
+	ops     sync.Map // op => *histogram
+}
+
+type histogram struct {
+	count  atomic.Uint64
+	sum    atomic.Int64
+	counts []atomic.Uint64 // len(bounds) + 1，最后一个为 +Inf
+}
+
+// Snapshot 某一时刻的统计
+type Snapshot struct {
+	Backend *caches.Stats             `json:"backend,omitempty"` // 后端实现了 caches.StatsProvider 时存在
+	Buckets map[string]BucketSnapshot `json:"buckets"`
+}
+
+type BucketSnapshot struct {
+	Hits    uint64               `json:"hits"`
+	Misses  uint64               `json:"misses"`
+	HitRate float64              `json:"hit_rate"`
+	Sets    uint64               `json:"sets"`
+	Deletes uint64               `json:"deletes"`
+	Errors  uint64               `json:"errors"`
+	Latency map[string]Histogram `json:"latency"` // op => 延迟
+}
+
+// Histogram 延迟直方图，Buckets 为累计计数
+type Histogram struct {
+	Count   uint64            `json:"count"`
+	Sum     time.Duration     `json:"sum"`
+	Avg     time.Duration     `json:"avg"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+type HistogramBucket struct {
+	Le    string `json:"le"`
+	Count uint64 `json:"count"`
+}
+
+// newComponent ...
+func newComponent(config *config, cache caches.Cache) *Component {
+	return &Component{
+		config: config,
+		cache:  cache,
+	}
+}
+
+// Cache 被统计的 cache
+func (c *Component) Cache() caches.Cache {
+	return c.cache
+}
+
+func (c *Component) GenerateCacheKey(bucket string, key string) string {
+	return c.cache.GenerateCacheKey(bucket, key)
+}
+
+func (c *Component) Get(key string) (string, error) {
+	start := time.Now()
+	v, err := c.cache.Get(key)
+	b := c.bucket(c.config.BucketFunc(key))
+	b.observe(c.config.Bounds, OpGet, time.Since(start))
+
+	switch {
+	case err == nil && len(v) > 0:
+		b.hits.Add(1)
+	case err == nil || errors.Is(err, caches.ErrNotFound):
+		b.misses.Add(1)
+	default:
+		b.errors.Add(1)
+	}
+	return v, err
+}
+
+// GetMulti 延迟记录到涉及的每个 bucket
+func (c *Component) GetMulti(keys []string) map[string]string {
+	start := time.Now()
+	result := c.cache.GetMulti(keys)
+	elapsed := time.Since(start)
+
+	seen := make(map[string]*bucketStats)
+	for _, key := range keys {
+		name := c.config.BucketFunc(key)
+		b, ok := seen[name]
+		if !ok {
+			b = c.bucket(name)
+			b.observe(c.config.Bounds, OpGetMulti, elapsed)
+			seen[name] = b
+		}
+		if len(result[key]) > 0 {
+			b.hits.Add(1)
+		} else {
+			b.misses.Add(1)
+		}
+	}
+	return result
+}
+
+func (c *Component) Set(key string, value string, ttl time.Duration) error {
+	start := time.Now()
+	err := c.cache.Set(key, value, ttl)
+	c.record(c.config.BucketFunc(key), OpSet, start, err)
+	return err
+}
+
+// SetWithBucket 按传入的 bucket 统计
+func (c *Component) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
+	start := time.Now()
+	err := c.cache.SetWithBucket(bucket, key, value, ttl)
+	c.record(bucket, OpSet, start, err)
+	return err
+}
+
+func (c *Component) Contains(key string) bool {
+	start := time.Now()
+	ok := c.cache.Contains(key)
+	c.bucket(c.config.BucketFunc(key)).observe(c.config.Bounds, OpContains, time.Since(start))
+	return ok
+}
+
+func (c *Component) Delete(key string) error {
+	start := time.Now()
+	err := c.cache.Delete(key)
+	c.record(c.config.BucketFunc(key), OpDelete, start, err)
+	return err
+}
+
+func (c *Component) Flush() error {
+	start := time.Now()
+	err := c.cache.Flush()
+	c.record(AllBucket, OpFlush, start, err)
+	return err
+}
+
+func (c *Component) Scan(bucket string, f func(key string) error) (err error) {
+	start := time.Now()
+	err = c.cache.Scan(bucket, f)
+	c.record(bucket, OpScan, start, err)
+	return err
+}
+
+func (c *Component) Fold(f func(key string) error) (err error) {
+	start := time.Now()
+	err = c.cache.Fold(f)
+	c.record(AllBucket, OpFold, start, err)
+	return err
+}
+
+// Stats 汇总所有 bucket，Evictions / Expirations / Size 来自后端
+func (c *Component) Stats() caches.Stats {
+	var stats caches.Stats
+	if p, ok := c.cache.(caches.StatsProvider); ok {
+		stats = p.Stats()
+	} else {
+		stats.Size = -1
+	}
+	stats.Hits, stats.Misses = 0, 0
+	c.buckets.Range(func(_, v any) bool {
+		b := v.(*bucketStats)
+		stats.Hits += b.hits.Load()
+		stats.Misses += b.misses.Load()
+		return true
+	})
+	return stats
+}
+
+// Snapshot 当前统计
+func (c *Component) Snapshot() Snapshot {
+	s := Snapshot{
+		Buckets: make(map[string]BucketSnapshot),
+	}
+	if p, ok := c.cache.(caches.StatsProvider); ok {
+		stats := p.Stats()
+		s.Backend = &stats
+	}
+
+	c.buckets.Range(func(k, v any) bool {
+		s.Buckets[k.(string)] = v.(*bucketStats).snapshot(c.config.Bounds)
+		return true
+	})
+	return s
+}
+
+// Reset 清空统计
+func (c *Component) Reset() {
+	c.buckets.Range(func(k, _ any) bool {
+		c.buckets.Delete(k)
+		return true
+	})
+}
+
+// ServeHTTP 输出 JSON 格式的 Snapshot
+func (c *Component) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c.Snapshot()); err != nil {
+		http.Error(w, err.Error(), 500)
+	}
+}
+
+func (c *Component) bucket(name string) *bucketStats {
+	if v, ok := c.buckets.Load(name); ok {
+		return v.(*bucketStats)
+	}
+	v, _ := c.buckets.LoadOrStore(name, &bucketStats{})
+	return v.(*bucketStats)
+}
+
+// record 记录写操作
+func (c *Component) record(bucket string, op string, start time.Time, err error) {
+	b := c.bucket(bucket)
+	b.observe(c.config.Bounds, op, time.Since(start))
+	if err != nil {
+		b.errors.Add(1)
+		return
+	}
+	switch op {
+	case OpSet:
+		b.sets.Add(1)
+	case OpDelete:
+		b.deletes.Add(1)
+	}
+}
+
+func (b *bucketStats) observe(bounds []time.Duration, op string, d time.Duration) {
+	v, ok := b.ops.Load(op)
+	if !ok {
+		v, _ = b.ops.LoadOrStore(op, &histogram{counts: make([]atomic.Uint64, len(bounds)+1)})
+	}
+	h := v.(*histogram)
+
+	i := sort.Search(len(bounds), func(i int) bool { return d <= bounds[i] })
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (b *bucketStats) snapshot(bounds []time.Duration) BucketSnapshot {
+	s := BucketSnapshot{
+		Hits:    b.hits.Load(),
+		Misses:  b.misses.Load(),
+		Sets:    b.sets.Load(),
+		Deletes: b.deletes.Load(),
+		Errors:  b.errors.Load(),
+		Latency: make(map[string]Histogram),
+	}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRate = float64(s.Hits) / float64(total)
+	}
+
+	b.ops.Range(func(k, v any) bool {
+		h := v.(*histogram)
+		hs := Histogram{
+			Count:   h.count.Load(),
+			Sum:     time.Duration(h.sum.Load()),
+			Buckets: make([]HistogramBucket, 0, len(h.counts)),
+		}
+		if hs.Count > 0 {
+			hs.Avg = hs.Sum / time.Duration(hs.Count)
+		}
+
+		var cumulative uint64
+		for i := range h.counts {
+			cumulative += h.counts[i].Load()
+			le := "+Inf"
+			if i < len(bounds) {
+				le = bounds[i].String()
+			}
+			hs.Buckets = append(hs.Buckets, HistogramBucket{Le: le, Count: cumulative})
+		}
+		s.Latency[k.(string)] = hs
+		return true
+	})
+	return s
+}
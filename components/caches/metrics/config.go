@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBucket key 中没有 bucket 前缀时的统计分组
+	DefaultBucket = "default"
+	// AllBucket Flush / Fold 等不针对单个 key 的操作
+	AllBucket = "*"
+)
+
+// config options
+type config struct {
+	BucketFunc func(key string) string // 从 key 中解析 bucket
+	Bounds     []time.Duration         // 延迟直方图的分桶上界，需从小到大
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *config {
+	return &config{
+		BucketFunc: BucketOf,
+		Bounds: []time.Duration{
+			time.Microsecond * 50,
+			time.Microsecond * 100,
+			time.Microsecond * 250,
+			time.Microsecond * 500,
+			time.Millisecond,
+			time.Millisecond * 2,
+			time.Millisecond * 5,
+			time.Millisecond * 10,
+			time.Millisecond * 25,
+			time.Millisecond * 50,
+			time.Millisecond * 100,
+			time.Millisecond * 250,
+			time.Second,
+		},
+	}
+}
+
+// BucketOf 默认的 bucket 解析，对应 GenerateCacheKey 生成的 "bucket:key"
+func BucketOf(key string) string {
+	if i := strings.IndexByte(key, ':'); i > 0 {
+		return key[:i]
+	}
+	return DefaultBucket
+}
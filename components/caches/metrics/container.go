@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+type Option func(c *Container)
+
+type Container struct {
+	config *config
+}
+
+// New options 模式，返回带统计的 cache
+func New(cache caches.Cache, options ...Option) *Component {
+	c := &Container{
+		config: DefaultConfig(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if cache == nil {
+		panic(PackageName + " need cache")
+	}
+	return newComponent(c.config, cache)
+}
+
+func WithBucketFunc(f func(key string) string) Option {
+	return func(c *Container) {
+		c.config.BucketFunc = f
+	}
+}
+
+func WithBounds(bounds []time.Duration) Option {
+	return func(c *Container) {
+		c.config.Bounds = bounds
+	}
+}
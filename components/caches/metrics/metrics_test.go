@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounters(t *testing.T) {
+	c := New(mem.NewLRU(100, 0))
+
+	c.Set("user:1", "a", time.Minute)
+	c.SetWithBucket("article", "article:1", "b", time.Minute)
+	c.Get("user:1")
+	c.Get("user:2")
+	c.GetMulti([]string{"user:1", "article:1", "article:2"})
+	c.Delete("user:1")
+	c.Get("plain")
+	c.Flush()
+
+	s := c.Snapshot()
+	user := s.Buckets["user"]
+	assert.Equal(t, uint64(2), user.Hits)
+	assert.Equal(t, uint64(1), user.Misses)
+	assert.Equal(t, uint64(1), user.Sets)
+	assert.Equal(t, uint64(1), user.Deletes)
+	assert.InDelta(t, 0.666, user.HitRate, 0.01)
+	assert.Equal(t, uint64(2), user.Latency[OpGet].Count)
+
+	article := s.Buckets["article"]
+	assert.Equal(t, uint64(1), article.Hits)
+	assert.Equal(t, uint64(1), article.Misses)
+	assert.Equal(t, uint64(1), article.Latency[OpGetMulti].Count)
+
+	assert.Equal(t, uint64(1), s.Buckets[DefaultBucket].Misses)
+	assert.Equal(t, uint64(1), s.Buckets[AllBucket].Latency[OpFlush].Count)
+
+	// 后端统计
+	assert.NotNil(t, s.Backend)
+	assert.Equal(t, 0, s.Backend.Size)
+	assert.Equal(t, uint64(3), c.Stats().Hits)
+
+	c.Reset()
+	assert.Empty(t, c.Snapshot().Buckets)
+}
+
+func TestHistogram(t *testing.T) {
+	b := &bucketStats{}
+	bounds := []time.Duration{time.Millisecond, time.Second}
+	b.observe(bounds, OpGet, time.Microsecond)
+	b.observe(bounds, OpGet, time.Millisecond*10)
+	b.observe(bounds, OpGet, time.Minute)
+
+	h := b.snapshot(bounds).Latency[OpGet]
+	assert.Equal(t, uint64(3), h.Count)
+	assert.Equal(t, []HistogramBucket{
+		{Le: "1ms", Count: 1},
+		{Le: "1s", Count: 2},
+		{Le: "+Inf", Count: 3},
+	}, h.Buckets)
+}
+
+func TestServeHTTP(t *testing.T) {
+	c := New(mem.NewLRU(100, 0))
+	c.Get("user:1")
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/cache/stats", nil))
+
+	var s Snapshot
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &s))
+	assert.Equal(t, uint64(1), s.Buckets["user"].Misses)
+}
+
+func TestBucketOf(t *testing.T) {
+	assert.Equal(t, "user", BucketOf("user:1"))
+	assert.Equal(t, "user", BucketOf("user:1:2"))
+	assert.Equal(t, DefaultBucket, BucketOf("plain"))
+	assert.Equal(t, DefaultBucket, BucketOf(":x"))
+}
+
+func TestConformance(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		return New(mem.NewLRU(1000, 0))
+	}, cachetest.WithTTL(50*time.Millisecond))
+}
@@ -0,0 +1,253 @@
+package caches
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// snapshotMagic 快照文件头，最后一位为格式版本
+const snapshotMagic = "XCSNAP\x00\x01"
+
+const (
+	recordEntry byte = 1
+	recordEnd   byte = 0
+)
+
+// ErrSnapshotCorrupt 快照被截断或校验失败
+var ErrSnapshotCorrupt = errors.New("caches: snapshot corrupt")
+
+// Snapshotter 可选接口，嵌入式后端实现，快照格式与后端无关，可以在不同后端之间迁移
+type Snapshotter interface {
+	// Snapshot 写入一致的快照，包含 bucket 和过期时间
+	Snapshot(w io.Writer) error
+
+	// Restore 写入快照中未过期的数据，保留剩余的过期时间；不会清空已有数据，需要时先 Flush
+	Restore(r io.Reader) error
+}
+
+// SnapshotEntry 快照中的一条数据
+type SnapshotEntry struct {
+	Key      string
+	Value    string
+	Bucket   string    // SetWithBucket 写入时的 bucket
+	ExpireAt time.Time // 零值表示不过期
+}
+
+// TTL 剩余的过期时间，不过期返回 0，已过期返回 false
+func (e SnapshotEntry) TTL(now time.Time) (time.Duration, bool) {
+	if e.ExpireAt.IsZero() {
+		return 0, true
+	}
+	ttl := e.ExpireAt.Sub(now)
+	return ttl, ttl > 0
+}
+
+// SnapshotWriter 快照格式：文件头 + 若干条记录 + 结束标记（条数和 crc32）
+// 每条记录为 key、value、bucket（uvarint 长度 + 内容）和过期时间（unix 毫秒，0 不过期）
+type SnapshotWriter struct {
+	w   *bufio.Writer
+	crc hash.Hash32
+	out io.Writer
+	n   uint64
+	buf [binary.MaxVarintLen64]byte
+}
+
+// NewSnapshotWriter 写入文件头，写完后需要 Close
+func NewSnapshotWriter(w io.Writer) (*SnapshotWriter, error) {
+	s := &SnapshotWriter{w: bufio.NewWriter(w), crc: crc32.NewIEEE()}
+	s.out = io.MultiWriter(s.w, s.crc)
+	if _, err := io.WriteString(s.out, snapshotMagic); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SnapshotWriter) Write(e SnapshotEntry) error {
+	s.n++
+	var expireAt int64
+	if !e.ExpireAt.IsZero() {
+		expireAt = e.ExpireAt.UnixMilli()
+	}
+	s.out.Write([]byte{recordEntry})
+	s.writeString(e.Key)
+	s.writeString(e.Value)
+	s.writeString(e.Bucket)
+	_, err := s.out.Write(s.buf[:binary.PutVarint(s.buf[:], expireAt)])
+	return err
+}
+
+// Close 写入结束标记，不关闭底层的 io.Writer
+func (s *SnapshotWriter) Close() error {
+	s.out.Write([]byte{recordEnd})
+	s.out.Write(s.buf[:binary.PutUvarint(s.buf[:], s.n)])
+	if err := binary.Write(s.w, binary.BigEndian, s.crc.Sum32()); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+func (s *SnapshotWriter) writeString(v string) {
+	s.out.Write(s.buf[:binary.PutUvarint(s.buf[:], uint64(len(v)))])
+	io.WriteString(s.out, v)
+}
+
+// SnapshotReader 读取 SnapshotWriter 写入的快照
+type SnapshotReader struct {
+	r  *bufio.Reader
+	in *crcReader
+	n  uint64
+}
+
+// NewSnapshotReader 校验文件头
+func NewSnapshotReader(r io.Reader) (*SnapshotReader, error) {
+	br := bufio.NewReader(r)
+	s := &SnapshotReader{r: br, in: &crcReader{r: br, crc: crc32.NewIEEE()}}
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(s.in, magic); err != nil || string(magic) != snapshotMagic {
+		return nil, fmt.Errorf("%w: bad header", ErrSnapshotCorrupt)
+	}
+	return s, nil
+}
+
+// Next 返回下一条数据，读完返回 io.EOF；截断或校验失败返回 ErrSnapshotCorrupt
+func (s *SnapshotReader) Next() (SnapshotEntry, error) {
+	kind, err := s.in.ReadByte()
+	if err != nil {
+		return SnapshotEntry{}, corrupt(err)
+	}
+	if kind == recordEnd {
+		return SnapshotEntry{}, s.end()
+	}
+	if kind != recordEntry {
+		return SnapshotEntry{}, fmt.Errorf("%w: bad record", ErrSnapshotCorrupt)
+	}
+
+	var e SnapshotEntry
+	if e.Key, err = s.readString(); err != nil {
+		return e, err
+	}
+	if e.Value, err = s.readString(); err != nil {
+		return e, err
+	}
+	if e.Bucket, err = s.readString(); err != nil {
+		return e, err
+	}
+	expireAt, err := binary.ReadVarint(s.in)
+	if err != nil {
+		return e, corrupt(err)
+	}
+	if expireAt != 0 {
+		e.ExpireAt = time.UnixMilli(expireAt)
+	}
+	s.n++
+	return e, nil
+}
+
+// end 校验条数和 crc，crc 本身不计入校验
+func (s *SnapshotReader) end() error {
+	n, err := binary.ReadUvarint(s.in)
+	if err != nil {
+		return corrupt(err)
+	}
+	sum := s.in.crc.Sum32()
+
+	var want uint32
+	if err := binary.Read(s.r, binary.BigEndian, &want); err != nil {
+		return corrupt(err)
+	}
+	if n != s.n || sum != want {
+		return fmt.Errorf("%w: checksum mismatch", ErrSnapshotCorrupt)
+	}
+	return io.EOF
+}
+
+func (s *SnapshotReader) readString() (string, error) {
+	n, err := binary.ReadUvarint(s.in)
+	if err != nil {
+		return "", corrupt(err)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(s.in, b); err != nil {
+		return "", corrupt(err)
+	}
+	return string(b), nil
+}
+
+// crcReader 计算已读取内容的 crc
+type crcReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+	b   [1]byte
+}
+
+func (c *crcReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc.Write(p[:n])
+	return n, err
+}
+
+func (c *crcReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.b[0] = b
+		c.crc.Write(c.b[:])
+	}
+	return b, err
+}
+
+func corrupt(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: truncated", ErrSnapshotCorrupt)
+	}
+	return err
+}
+
+// WriteSnapshot 把 entries 写成快照，供各后端的 Snapshot 使用
+func WriteSnapshot(w io.Writer, each func(write func(e SnapshotEntry) error) error) error {
+	sw, err := NewSnapshotWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := each(sw.Write); err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// Restore 把快照中未过期的数据写入任意 Cache，有 bucket 的数据使用 SetWithBucket
+// 校验在读到结尾时进行，快照损坏时已写入的数据不会回滚
+func Restore(c Cache, r io.Reader) error {
+	sr, err := NewSnapshotReader(r)
+	if err != nil {
+		return err
+	}
+	for {
+		e, err := sr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ttl, ok := e.TTL(time.Now())
+		if !ok {
+			continue
+		}
+		if len(e.Bucket) > 0 {
+			err = c.SetWithBucket(e.Bucket, e.Key, e.Value, ttl)
+		} else {
+			err = c.Set(e.Key, e.Value, ttl)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
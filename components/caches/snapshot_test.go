@@ -0,0 +1,84 @@
+package caches_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeSnapshot(t *testing.T, entries ...caches.SnapshotEntry) []byte {
+	var buf bytes.Buffer
+	assert.Nil(t, caches.WriteSnapshot(&buf, func(write func(e caches.SnapshotEntry) error) error {
+		for _, e := range entries {
+			if err := write(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	return buf.Bytes()
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	expireAt := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+	entries := []caches.SnapshotEntry{
+		{Key: "a", Value: "1"},
+		{Key: "foo:b", Value: "\x00\xffbinary", Bucket: "foo", ExpireAt: expireAt},
+		{Key: "", Value: ""},
+	}
+
+	sr, err := caches.NewSnapshotReader(bytes.NewReader(writeSnapshot(t, entries...)))
+	assert.Nil(t, err)
+	var got []caches.SnapshotEntry
+	for {
+		e, err := sr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.Nil(t, err)
+		got = append(got, e)
+	}
+	assert.Equal(t, entries, got)
+}
+
+func TestSnapshotCorrupt(t *testing.T) {
+	data := writeSnapshot(t, caches.SnapshotEntry{Key: "a", Value: "1"})
+
+	_, err := caches.NewSnapshotReader(bytes.NewReader([]byte("nope")))
+	assert.True(t, errors.Is(err, caches.ErrSnapshotCorrupt))
+
+	// 截断
+	err = caches.Restore(mem.NewLRU(10, 0), bytes.NewReader(data[:len(data)-3]))
+	assert.True(t, errors.Is(err, caches.ErrSnapshotCorrupt), "got %v", err)
+
+	// 内容被修改
+	flipped := append([]byte(nil), data...)
+	flipped[len(data)-8] ^= 0xff
+	err = caches.Restore(mem.NewLRU(10, 0), bytes.NewReader(flipped))
+	assert.True(t, errors.Is(err, caches.ErrSnapshotCorrupt), "got %v", err)
+}
+
+func TestRestore(t *testing.T) {
+	data := writeSnapshot(t,
+		caches.SnapshotEntry{Key: "a", Value: "1"},
+		caches.SnapshotEntry{Key: "foo:b", Value: "2", Bucket: "foo", ExpireAt: time.Now().Add(time.Hour)},
+		caches.SnapshotEntry{Key: "expired", Value: "3", ExpireAt: time.Now().Add(-time.Second)},
+	)
+
+	c := mem.NewLRU(10, 0)
+	assert.Nil(t, caches.Restore(c, bytes.NewReader(data)))
+	assert.Equal(t, map[string]string{"a": "1", "foo:b": "2"}, c.GetMulti([]string{"a", "foo:b", "expired"}))
+
+	var keys []string
+	c.Scan("foo", func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	assert.Equal(t, []string{"foo:b"}, keys)
+}
@@ -0,0 +1,41 @@
+package caches
+
+import "sync/atomic"
+
+// Stats 缓存后端自身的统计
+type Stats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`   // 容量淘汰
+	Expirations uint64 `json:"expirations"` // 读取时发现过期
+	Size        int    `json:"size"`        // 当前条目数，-1 表示未知
+}
+
+// StatsProvider 支持统计的缓存后端实现此接口
+type StatsProvider interface {
+	Stats() Stats
+}
+
+// StatsCounter 并发安全的计数器，供后端内部使用
+type StatsCounter struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+func (s *StatsCounter) Hit()    { s.hits.Add(1) }
+func (s *StatsCounter) Miss()   { s.misses.Add(1) }
+func (s *StatsCounter) Evict()  { s.evictions.Add(1) }
+func (s *StatsCounter) Expire() { s.expirations.Add(1) }
+
+// Snapshot 当前计数，size 由后端提供
+func (s *StatsCounter) Snapshot(size int) Stats {
+	return Stats{
+		Hits:        s.hits.Load(),
+		Misses:      s.misses.Load(),
+		Evictions:   s.evictions.Load(),
+		Expirations: s.expirations.Load(),
+		Size:        size,
+	}
+}
@@ -0,0 +1,312 @@
+package tagged
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/syntax/irandom"
+)
+
+const PackageName = "component.caches.tagged"
+
+// entryMarker 带 tag 的值的前缀，没有该前缀的值按原值返回
+// 不带 tag 的值原样保存，后端和其他读者看到的就是原值
+const entryMarker = "\x00t"
+
+// Component 给任意 caches.Cache 增加 tag 失效
+//
+// 每个 tag 在后端保存一个版本号，写入时把 tag 当前版本号和值一起保存；
+// InvalidateTags 只更新版本号，读到版本号不一致的条目视为不存在并顺手删除。
+// 失效是 O(tag 数)，不需要遍历 key，多个进程共享同一个后端时同样生效
+type Component struct {
+	config *config
+	cache  caches.Cache
+}
+
+var _ caches.Cache = (*Component)(nil)
+
+type entry struct {
+	tags     []string
+	versions []string
+	value    string
+}
+
+// newComponent ...
+func newComponent(config *config, cache caches.Cache) *Component {
+	return &Component{
+		config: config,
+		cache:  cache,
+	}
+}
+
+// Cache 被包装的 cache
+func (c *Component) Cache() caches.Cache {
+	return c.cache
+}
+
+func (c *Component) GenerateCacheKey(bucket string, key string) string {
+	return c.cache.GenerateCacheKey(bucket, key)
+}
+
+// Get 任意一个 tag 失效后返回 caches.ErrNotFound
+func (c *Component) Get(key string) (string, error) {
+	raw, err := c.cache.Get(key)
+	if err != nil {
+		return raw, err
+	}
+	e, ok := decodeEntry(raw)
+	if !ok {
+		return raw, nil
+	}
+	if len(e.tags) > 0 && !e.valid(c.versions(e.tags)) {
+		c.cache.Delete(key)
+		return "", caches.ErrNotFound
+	}
+	return e.value, nil
+}
+
+// GetMulti tag 版本号一次读取
+func (c *Component) GetMulti(keys []string) map[string]string {
+	raws := c.cache.GetMulti(keys)
+	result := make(map[string]string, len(raws))
+
+	entries := make(map[string]entry, len(raws))
+	var tags []string
+	for key, raw := range raws {
+		e, ok := decodeEntry(raw)
+		if !ok {
+			result[key] = raw
+			continue
+		}
+		entries[key] = e
+		tags = append(tags, e.tags...)
+	}
+
+	versions := c.versions(tags)
+	for key, e := range entries {
+		if e.valid(versions) {
+			result[key] = e.value
+		} else {
+			c.cache.Delete(key)
+		}
+	}
+	return result
+}
+
+func (c *Component) Set(key string, value string, ttl time.Duration) error {
+	return c.SetWithTags(key, value, ttl)
+}
+
+// SetWithTags 保存数据并打上 tag，InvalidateTags 任意一个 tag 后该条目失效
+func (c *Component) SetWithTags(key string, value string, ttl time.Duration, tags ...string) error {
+	e, err := c.newEntry(value, tags)
+	if err != nil {
+		return err
+	}
+	return c.cache.Set(key, e.encode(), ttl)
+}
+
+func (c *Component) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
+	return c.SetWithBucketAndTags(bucket, key, value, ttl)
+}
+
+// SetWithBucketAndTags 同 SetWithTags，同时写入 bucket
+func (c *Component) SetWithBucketAndTags(bucket string, key string, value string, ttl time.Duration, tags ...string) error {
+	e, err := c.newEntry(value, tags)
+	if err != nil {
+		return err
+	}
+	return c.cache.SetWithBucket(bucket, key, e.encode(), ttl)
+}
+
+// InvalidateTags 使带有这些 tag 的条目全部失效
+func (c *Component) InvalidateTags(tags ...string) error {
+	var errs []error
+	for _, tag := range unique(tags) {
+		if err := c.cache.Set(c.tagKey(tag), newVersion(), 0); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Component) Contains(key string) bool {
+	_, err := c.Get(key)
+	return err == nil
+}
+
+func (c *Component) Delete(key string) error {
+	return c.cache.Delete(key)
+}
+
+// Flush 同时清空 tag 版本号，之前写入的条目随之失效
+func (c *Component) Flush() error {
+	return c.cache.Flush()
+}
+
+// Scan 跳过已失效的条目
+func (c *Component) Scan(bucket string, f func(key string) error) (err error) {
+	return c.cache.Scan(bucket, func(key string) error {
+		if !c.Contains(key) {
+			return nil
+		}
+		return f(key)
+	})
+}
+
+// Fold 跳过 tag 版本号和已失效的条目
+func (c *Component) Fold(f func(key string) error) (err error) {
+	return c.cache.Fold(func(key string) error {
+		if strings.HasPrefix(key, c.config.TagPrefix) || !c.Contains(key) {
+			return nil
+		}
+		return f(key)
+	})
+}
+
+func (c *Component) tagKey(tag string) string {
+	return c.config.TagPrefix + tag
+}
+
+// versions 读取 tag 当前版本号，不存在的 tag 不在结果中
+func (c *Component) versions(tags []string) map[string]string {
+	tags = unique(tags)
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = c.tagKey(tag)
+	}
+	found := c.cache.GetMulti(keys)
+
+	versions := make(map[string]string, len(found))
+	for i, tag := range tags {
+		if v, ok := found[keys[i]]; ok {
+			versions[tag] = v
+		}
+	}
+	return versions
+}
+
+// newEntry 没有版本号的 tag 生成一个
+func (c *Component) newEntry(value string, tags []string) (entry, error) {
+	tags = unique(tags)
+	e := entry{tags: tags, versions: make([]string, len(tags)), value: value}
+	if len(tags) == 0 {
+		return e, nil
+	}
+
+	versions := c.versions(tags)
+	for i, tag := range tags {
+		v, ok := versions[tag]
+		if !ok {
+			v = newVersion()
+			if err := c.cache.Set(c.tagKey(tag), v, 0); err != nil {
+				return e, err
+			}
+		}
+		e.versions[i] = v
+	}
+	return e, nil
+}
+
+// valid 所有 tag 版本号一致；tag 版本号丢失（淘汰或 Flush）同样视为失效
+func (e entry) valid(versions map[string]string) bool {
+	for i, tag := range e.tags {
+		if v, ok := versions[tag]; !ok || v != e.versions[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// encode 格式：marker + tag 数 + ":" + 每个 tag 和版本号（长度 + ":" + 内容）+ 值
+// 没有 tag 时保存原值，除非原值恰好以 marker 开头
+func (e entry) encode() string {
+	if len(e.tags) == 0 && !strings.HasPrefix(e.value, entryMarker) {
+		return e.value
+	}
+
+	var b strings.Builder
+	b.WriteString(entryMarker)
+	b.WriteString(strconv.Itoa(len(e.tags)))
+	b.WriteByte(':')
+	for i, tag := range e.tags {
+		writeField(&b, tag)
+		writeField(&b, e.versions[i])
+	}
+	b.WriteString(e.value)
+	return b.String()
+}
+
+func decodeEntry(raw string) (entry, bool) {
+	if !strings.HasPrefix(raw, entryMarker) {
+		return entry{}, false
+	}
+	rest := raw[len(entryMarker):]
+
+	i := strings.IndexByte(rest, ':')
+	if i < 0 {
+		return entry{}, false
+	}
+	n, err := strconv.Atoi(rest[:i])
+	if err != nil || n < 0 {
+		return entry{}, false
+	}
+	rest = rest[i+1:]
+
+	e := entry{tags: make([]string, n), versions: make([]string, n)}
+	for j := 0; j < n; j++ {
+		var ok bool
+		if e.tags[j], rest, ok = readField(rest); !ok {
+			return entry{}, false
+		}
+		if e.versions[j], rest, ok = readField(rest); !ok {
+			return entry{}, false
+		}
+	}
+	e.value = rest
+	return e, true
+}
+
+func writeField(b *strings.Builder, s string) {
+	b.WriteString(strconv.Itoa(len(s)))
+	b.WriteByte(':')
+	b.WriteString(s)
+}
+
+func readField(s string) (field string, rest string, ok bool) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return "", s, false
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil || n < 0 || len(s) < i+1+n {
+		return "", s, false
+	}
+	return s[i+1 : i+1+n], s[i+1+n:], true
+}
+
+// newVersion 纳秒时间 + 随机串，多进程同时失效也不会重复
+func newVersion() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + irandom.RandString(4, irandom.LetterAll)
+}
+
+func unique(tags []string) []string {
+	if len(tags) < 2 {
+		return tags
+	}
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result
+}
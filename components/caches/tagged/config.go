@@ -0,0 +1,13 @@
+package tagged
+
+// config options
+type config struct {
+	TagPrefix string // tag 版本号的 key 前缀，Fold / Scan 时会被排除
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *config {
+	return &config{
+		TagPrefix: "__tag__:",
+	}
+}
@@ -0,0 +1,34 @@
+package tagged
+
+import (
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+type Option func(c *Container)
+
+type Container struct {
+	config *config
+}
+
+// New options 模式，返回支持 tag 失效的 cache
+func New(cache caches.Cache, options ...Option) *Component {
+	c := &Container{
+		config: DefaultConfig(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if cache == nil {
+		panic(PackageName + " need cache")
+	}
+	if len(c.config.TagPrefix) == 0 {
+		panic(PackageName + " tag prefix is empty")
+	}
+	return newComponent(c.config, cache)
+}
+
+func WithTagPrefix(prefix string) Option {
+	return func(c *Container) {
+		c.config.TagPrefix = prefix
+	}
+}
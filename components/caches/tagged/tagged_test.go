@@ -0,0 +1,102 @@
+package tagged
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidateTags(t *testing.T) {
+	c := New(mem.NewLRU(100, 0))
+
+	c.SetWithTags("page:1", "p1", time.Minute, "article:7", "user:42")
+	c.SetWithTags("page:2", "p2", time.Minute, "article:7")
+	c.SetWithTags("page:3", "p3", time.Minute, "user:42")
+	c.Set("page:4", "p4", time.Minute)
+
+	v, err := c.Get("page:1")
+	assert.Nil(t, err)
+	assert.Equal(t, "p1", v)
+
+	assert.Nil(t, c.InvalidateTags("article:7"))
+
+	_, err = c.Get("page:1")
+	assert.True(t, errors.Is(err, caches.ErrNotFound))
+	assert.False(t, c.Contains("page:2"))
+	assert.True(t, c.Contains("page:3"))
+	assert.True(t, c.Contains("page:4"))
+	assert.Equal(t, map[string]string{"page:3": "p3", "page:4": "p4"},
+		c.GetMulti([]string{"page:1", "page:2", "page:3", "page:4"}))
+
+	// 失效后重新写入
+	c.SetWithTags("page:1", "p1v2", time.Minute, "article:7")
+	v, _ = c.Get("page:1")
+	assert.Equal(t, "p1v2", v)
+}
+
+func TestSharedBackend(t *testing.T) {
+	backend := mem.NewLRU(100, 0)
+	a := New(backend)
+	b := New(backend)
+
+	a.SetWithTags("page:1", "p1", time.Minute, "article:7")
+	assert.True(t, b.Contains("page:1"))
+
+	// 另一个实例失效
+	b.InvalidateTags("article:7")
+	assert.False(t, a.Contains("page:1"))
+}
+
+func TestLostVersion(t *testing.T) {
+	backend := mem.NewLRU(100, 0)
+	c := New(backend)
+
+	c.SetWithTags("page:1", "p1", time.Minute, "article:7")
+	backend.Delete("__tag__:article:7")
+	assert.False(t, c.Contains("page:1"))
+}
+
+func TestPlainValue(t *testing.T) {
+	backend := mem.NewLRU(100, 0)
+	backend.Set("legacy", "raw", time.Minute)
+
+	c := New(backend)
+	v, err := c.Get("legacy")
+	assert.Nil(t, err)
+	assert.Equal(t, "raw", v)
+
+	// 不带 tag 原样写入后端
+	c.Set("plain", "p", time.Minute)
+	raw, _ := backend.Get("plain")
+	assert.Equal(t, "p", raw)
+
+	// 原值以 marker 开头时仍然包装，读回原值
+	c.Set("marked", entryMarker+"x", time.Minute)
+	v, _ = c.Get("marked")
+	assert.Equal(t, entryMarker+"x", v)
+}
+
+func TestEntry(t *testing.T) {
+	for _, e := range []entry{
+		{tags: []string{}, versions: []string{}, value: entryMarker + "v"},
+		{tags: []string{"a:b", ""}, versions: []string{"1", "22"}, value: "3:x"},
+	} {
+		got, ok := decodeEntry(e.encode())
+		assert.True(t, ok)
+		assert.Equal(t, e, got)
+	}
+
+	_, ok := decodeEntry(entryMarker + "2:1:a")
+	assert.False(t, ok)
+}
+
+func TestConformance(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		return New(mem.NewLRU(1000, 0))
+	}, cachetest.WithTTL(50*time.Millisecond))
+}
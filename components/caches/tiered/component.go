@@ -0,0 +1,285 @@
+package tiered
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+)
+
+const PackageName = "component.caches.tiered"
+
+type opKind int
+
+const (
+	opSet opKind = iota
+	opSetWithBucket
+	opDelete
+	opFlush
+	opBarrier // 不写 L2，处理到它时之前入队的写入都已完成
+)
+
+// op 一次对 L2 的写操作
+type op struct {
+	kind   opKind
+	bucket string
+	key    string
+	value  string
+	ttl    time.Duration
+	done   chan error // 非空时，写完后回传结果
+}
+
+// Component 两级缓存：L1 为进程内 mem.LRU，L2 为任意 caches.Cache
+// 读：L1 -> L2，L2 命中后回填 L1
+// 写：按 Mode 写入 L2，L1 同步更新
+type Component struct {
+	config *config
+	l1     *mem.LRU
+	l2     caches.Cache
+
+	// WriteBehind
+	queue     chan op
+	closeLk   sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+var _ caches.Cache = (*Component)(nil)
+
+// newComponent ...
+func newComponent(config *config, l1 *mem.LRU, l2 caches.Cache) *Component {
+	comp := &Component{
+		config: config,
+		l1:     l1,
+		l2:     l2,
+	}
+
+	if config.Mode == WriteBehind {
+		comp.queue = make(chan op, config.QueueSize)
+		comp.done = make(chan struct{})
+		go comp.writeLoop()
+	}
+
+	return comp
+}
+
+// L1 本地缓存
+func (c *Component) L1() *mem.LRU {
+	return c.l1
+}
+
+// L2 共享缓存
+func (c *Component) L2() caches.Cache {
+	return c.l2
+}
+
+// Close 停止后台写入，等待队列中的数据全部写入 L2
+func (c *Component) Close() error {
+	if c.config.Mode != WriteBehind {
+		return nil
+	}
+	c.closeOnce.Do(func() {
+		c.closeLk.Lock()
+		c.closed = true
+		close(c.queue)
+		c.closeLk.Unlock()
+	})
+	<-c.done
+	return nil
+}
+
+// Invalidate 只淘汰 L1，不写 L2 也不触发通知；keys 为空时清空 L1
+// 用于收到其他进程的变更通知
+func (c *Component) Invalidate(keys ...string) {
+	if len(keys) == 0 {
+		c.l1.Flush()
+		return
+	}
+	for _, key := range keys {
+		c.l1.Delete(key)
+	}
+}
+
+func (c *Component) GenerateCacheKey(bucket string, key string) string {
+	return c.l2.GenerateCacheKey(bucket, key)
+}
+
+func (c *Component) Get(key string) (string, error) {
+	if v, _ := c.l1.Get(key); len(v) > 0 {
+		return v, nil
+	}
+
+	v, err := c.l2.Get(key)
+	if err != nil {
+		return v, err
+	}
+	c.backfill(key, v)
+	return v, nil
+}
+
+func (c *Component) GetMulti(keys []string) map[string]string {
+	result := make(map[string]string, len(keys))
+
+	var missed []string
+	for _, key := range keys {
+		if v, _ := c.l1.Get(key); len(v) > 0 {
+			result[key] = v
+		} else {
+			missed = append(missed, key)
+		}
+	}
+
+	if len(missed) > 0 {
+		for key, v := range c.l2.GetMulti(missed) {
+			c.backfill(key, v)
+			result[key] = v
+		}
+	}
+	return result
+}
+
+func (c *Component) Set(key string, value string, ttl time.Duration) error {
+	return c.write(op{kind: opSet, key: key, value: value, ttl: ttl})
+}
+
+func (c *Component) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
+	return c.write(op{kind: opSetWithBucket, bucket: bucket, key: key, value: value, ttl: ttl})
+}
+
+func (c *Component) Contains(key string) bool {
+	return c.l1.Contains(key) || c.l2.Contains(key)
+}
+
+func (c *Component) Delete(key string) error {
+	return c.write(op{kind: opDelete, key: key})
+}
+
+func (c *Component) Flush() error {
+	return c.write(op{kind: opFlush})
+}
+
+// Scan bucket 索引只存在于 L2，WriteBehind 模式下先等待队列中的写入完成
+func (c *Component) Scan(bucket string, f func(key string) error) (err error) {
+	if err := c.wait(); err != nil {
+		return err
+	}
+	return c.l2.Scan(bucket, f)
+}
+
+// Fold 遍历 L2 所有 key，WriteBehind 模式下先等待队列中的写入完成
+func (c *Component) Fold(f func(key string) error) (err error) {
+	if err := c.wait(); err != nil {
+		return err
+	}
+	return c.l2.Fold(f)
+}
+
+// wait 等待此前入队的写入全部写入 L2
+func (c *Component) wait() error {
+	if c.config.Mode != WriteBehind {
+		return nil
+	}
+	return c.write(op{kind: opBarrier})
+}
+
+// backfill L2 命中后回填 L1
+// L2 的剩余过期时间未知，回填的数据最多缓存 L1Ttl，可能比 L2 晚过期至多 L1Ttl；
+// L1Ttl 不大于 0 时没有这个上限，不回填
+func (c *Component) backfill(key string, value string) {
+	if len(value) == 0 || c.config.L1Ttl <= 0 {
+		return
+	}
+	c.l1.Set(key, value, c.config.L1Ttl)
+}
+
+// l1Ttl L1 的过期时间不超过 L1Ttl
+func (c *Component) l1Ttl(ttl time.Duration) time.Duration {
+	if c.config.L1Ttl > 0 && (ttl <= 0 || ttl > c.config.L1Ttl) {
+		return c.config.L1Ttl
+	}
+	return ttl
+}
+
+// write 更新 L1 并按 Mode 写 L2
+// Delete / Flush 在 WriteBehind 模式下也走队列，保证和之前的写入顺序一致，但会等待完成
+func (c *Component) write(o op) error {
+	if c.config.Mode != WriteBehind {
+		if err := c.apply(o); err != nil {
+			return err
+		}
+		c.applyL1(o)
+		return nil
+	}
+
+	c.applyL1(o)
+	if o.kind == opDelete || o.kind == opFlush || o.kind == opBarrier {
+		o.done = make(chan error, 1)
+	}
+
+	c.closeLk.RLock()
+	if c.closed {
+		c.closeLk.RUnlock()
+		return c.apply(o)
+	}
+	c.queue <- o
+	c.closeLk.RUnlock()
+
+	if o.done != nil {
+		return <-o.done
+	}
+	return nil
+}
+
+func (c *Component) applyL1(o op) {
+	switch o.kind {
+	case opSet, opSetWithBucket:
+		c.l1.Set(o.key, o.value, c.l1Ttl(o.ttl))
+	case opDelete:
+		c.l1.Delete(o.key)
+	case opFlush:
+		c.l1.Flush()
+	}
+}
+
+// apply 写 L2，成功后通知其他进程
+func (c *Component) apply(o op) error {
+	var err error
+	switch o.kind {
+	case opSet:
+		err = c.l2.Set(o.key, o.value, o.ttl)
+	case opSetWithBucket:
+		err = c.l2.SetWithBucket(o.bucket, o.key, o.value, o.ttl)
+	case opDelete:
+		err = c.l2.Delete(o.key)
+	case opFlush:
+		err = c.l2.Flush()
+	case opBarrier:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.config.Invalidate != nil {
+		if o.kind == opFlush {
+			c.config.Invalidate(nil)
+		} else {
+			c.config.Invalidate([]string{o.key})
+		}
+	}
+	return nil
+}
+
+func (c *Component) writeLoop() {
+	defer close(c.done)
+	for o := range c.queue {
+		err := c.apply(o)
+		if o.done != nil {
+			o.done <- err
+		} else if err != nil && c.config.OnError != nil {
+			c.config.OnError(err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package tiered
+
+import "time"
+
+// WriteMode 写入 L2 的方式
+type WriteMode int
+
+const (
+	// WriteThrough 同步写 L2，成功后再写 L1
+	WriteThrough WriteMode = iota
+	// WriteBehind 先写 L1，L2 由后台协程按顺序异步写入
+	WriteBehind
+)
+
+// InvalidateFunc 本地数据变更后的通知，keys 为空表示 Flush
+// 一般用于广播给其他进程，对方收到后调用 Component.Invalidate 淘汰自己的 L1
+type InvalidateFunc func(keys []string)
+
+// config options
+type config struct {
+	L1Size int           // L1 最大条目数
+	L1Ttl  time.Duration // L1 最长缓存时间，不会超过写入时的 ttl；L2 回填的数据最多比 L2 晚过期 L1Ttl，不大于 0 时不回填
+
+	Mode       WriteMode
+	QueueSize  int // WriteBehind 队列长度，队列满时阻塞写入
+	OnError    func(err error)
+	Invalidate InvalidateFunc
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *config {
+	return &config{
+		L1Size:    10000,
+		L1Ttl:     time.Minute,
+		Mode:      WriteThrough,
+		QueueSize: 1024,
+	}
+}
@@ -0,0 +1,78 @@
+package tiered
+
+import (
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+)
+
+type Option func(c *Container)
+
+type Container struct {
+	config *config
+	l1     *mem.LRU
+}
+
+// New options 模式，l2 为共享的后端缓存
+func New(l2 caches.Cache, options ...Option) *Component {
+	c := &Container{
+		config: DefaultConfig(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if l2 == nil {
+		panic(PackageName + " need l2 cache")
+	}
+	if c.l1 == nil {
+		c.l1 = mem.NewLRU(c.config.L1Size, c.config.L1Ttl)
+	}
+	return newComponent(c.config, c.l1, l2)
+}
+
+// WithL1 使用外部创建的 LRU 作为 L1
+func WithL1(l1 *mem.LRU) Option {
+	return func(c *Container) {
+		c.l1 = l1
+	}
+}
+
+func WithL1Size(size int) Option {
+	return func(c *Container) {
+		c.config.L1Size = size
+	}
+}
+
+// WithL1Ttl L1 最长缓存时间，也是 L2 回填数据的过期上限，不大于 0 时不回填
+func WithL1Ttl(ttl time.Duration) Option {
+	return func(c *Container) {
+		c.config.L1Ttl = ttl
+	}
+}
+
+func WithMode(mode WriteMode) Option {
+	return func(c *Container) {
+		c.config.Mode = mode
+	}
+}
+
+func WithQueueSize(size int) Option {
+	return func(c *Container) {
+		c.config.QueueSize = size
+	}
+}
+
+// WithOnError WriteBehind 模式下异步写 L2 失败的回调
+func WithOnError(f func(err error)) Option {
+	return func(c *Container) {
+		c.config.OnError = f
+	}
+}
+
+// WithInvalidate 本地 Set/Delete/Flush 后的通知
+func WithInvalidate(f InvalidateFunc) Option {
+	return func(c *Container) {
+		c.config.Invalidate = f
+	}
+}
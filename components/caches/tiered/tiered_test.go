@@ -0,0 +1,149 @@
+package tiered
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/cachetest"
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadThrough(t *testing.T) {
+	l2 := mem.NewLRU(100, 0)
+	c := New(l2)
+
+	l2.Set("a", "apple", time.Hour)
+	assert.False(t, c.L1().Contains("a"))
+
+	v, err := c.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "apple", v)
+	assert.True(t, c.L1().Contains("a"))
+
+	l2.Set("b", "banana", time.Hour)
+	assert.Equal(t, map[string]string{"a": "apple", "b": "banana"}, c.GetMulti([]string{"a", "b"}))
+	assert.True(t, c.L1().Contains("b"))
+}
+
+func TestWriteThrough(t *testing.T) {
+	l2 := mem.NewLRU(100, 0)
+	c := New(l2)
+
+	c.SetWithBucket("foo", "foo:1", "v", time.Hour)
+	assert.True(t, c.L1().Contains("foo:1"))
+	assert.True(t, l2.Contains("foo:1"))
+
+	c.Delete("foo:1")
+	assert.False(t, c.L1().Contains("foo:1"))
+	assert.False(t, l2.Contains("foo:1"))
+
+	c.Set("a", "1", time.Hour)
+	c.Flush()
+	assert.Equal(t, 0, c.L1().Size())
+	assert.Equal(t, 0, l2.Size())
+}
+
+func TestWriteBehind(t *testing.T) {
+	l2 := mem.NewLRU(100, 0)
+	c := New(l2, WithMode(WriteBehind))
+
+	for i := 0; i < 100; i++ {
+		c.Set("a", "1", time.Hour)
+	}
+	assert.True(t, c.L1().Contains("a"))
+
+	// Delete 与之前的写入保持顺序，返回时 L2 已经删除
+	c.Delete("a")
+	assert.False(t, l2.Contains("a"))
+
+	c.Set("b", "2", time.Hour)
+	c.Close()
+	v, _ := l2.Get("b")
+	assert.Equal(t, "2", v)
+
+	// 关闭后同步写
+	c.Set("c", "3", time.Hour)
+	assert.True(t, l2.Contains("c"))
+}
+
+func TestL1Ttl(t *testing.T) {
+	c := New(mem.NewLRU(100, 0), WithL1Ttl(time.Minute))
+	assert.Equal(t, time.Minute, c.l1Ttl(0))
+	assert.Equal(t, time.Minute, c.l1Ttl(time.Hour))
+	assert.Equal(t, time.Second, c.l1Ttl(time.Second))
+
+	// 没有 L1Ttl 时不知道 L2 何时过期，不回填
+	l2 := mem.NewLRU(100, 0)
+	c = New(l2, WithL1Ttl(0))
+	l2.Set("a", "apple", time.Second)
+	v, _ := c.Get("a")
+	assert.Equal(t, "apple", v)
+	c.GetMulti([]string{"a"})
+	assert.False(t, c.L1().Contains("a"))
+}
+
+func TestInvalidate(t *testing.T) {
+	l2 := mem.NewLRU(100, 0)
+
+	var peer *Component
+	c := New(l2, WithInvalidate(func(keys []string) {
+		peer.Invalidate(keys...)
+	}))
+	peer = New(l2)
+
+	c.Set("a", "1", time.Hour)
+	peer.Get("a")
+	assert.True(t, peer.L1().Contains("a"))
+
+	// 本地更新后，peer 的 L1 被淘汰，重新从 L2 读到新值
+	c.Set("a", "2", time.Hour)
+	assert.False(t, peer.L1().Contains("a"))
+	v, _ := peer.Get("a")
+	assert.Equal(t, "2", v)
+
+	c.Flush()
+	assert.Equal(t, 0, peer.L1().Size())
+}
+
+func TestConformance(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		c := New(mem.NewLRU(1000, 0))
+		t.Cleanup(func() { c.Close() })
+		return c
+	}, cachetest.WithTTL(50*time.Millisecond))
+}
+
+func TestConformanceWriteBehind(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) caches.Cache {
+		c := New(mem.NewLRU(1000, 0), WithMode(WriteBehind))
+		t.Cleanup(func() { c.Close() })
+		return c
+	}, cachetest.WithTTL(50*time.Millisecond))
+}
+
+// slowCache 写 L2 较慢，WriteBehind 的队列中会积压数据
+type slowCache struct {
+	caches.Cache
+}
+
+func (s slowCache) SetWithBucket(bucket string, key string, value string, ttl time.Duration) error {
+	time.Sleep(time.Millisecond)
+	return s.Cache.SetWithBucket(bucket, key, value, ttl)
+}
+
+func TestWriteBehindScan(t *testing.T) {
+	c := New(slowCache{mem.NewLRU(100, 0)}, WithMode(WriteBehind))
+	defer c.Close()
+
+	for i := 0; i < 20; i++ {
+		c.SetWithBucket("foo", c.GenerateCacheKey("foo", string(rune('a'+i))), "v", time.Hour)
+	}
+	n := 0
+	assert.Nil(t, c.Scan("foo", func(key string) error {
+		n++
+		return nil
+	}))
+	assert.Equal(t, 20, n)
+}
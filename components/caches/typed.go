@@ -0,0 +1,109 @@
+package caches
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed 在任意 Cache 上保存结构化数据，值通过 Codec 序列化
+//
+//	users := caches.NewTyped[User](cache, caches.MsgpackCodec)
+//	users.Set("user:1", User{Name: "foo"}, time.Hour)
+//	u, err := users.Get("user:1") // errors.Is(err, caches.ErrNotFound)
+type Typed[T any] struct {
+	cache Cache
+	codec Codec
+}
+
+// NewTyped codec 为 nil 时使用 JSONCodec
+func NewTyped[T any](cache Cache, codec Codec) *Typed[T] {
+	if codec == nil {
+		codec = JSONCodec
+	}
+	return &Typed[T]{
+		cache: cache,
+		codec: codec,
+	}
+}
+
+// Cache 底层缓存
+func (t *Typed[T]) Cache() Cache {
+	return t.cache
+}
+
+// Get 不存在返回 ErrNotFound，解码失败返回 *DecodeError
+func (t *Typed[T]) Get(key string) (T, error) {
+	var v T
+	raw, err := t.cache.Get(key)
+	if err != nil {
+		return v, err
+	}
+	if len(raw) == 0 {
+		return v, ErrNotFound
+	}
+	return t.decode(key, raw)
+}
+
+// GetMulti 只返回存在且解码成功的数据，解码失败的 key 合并到 error 中
+func (t *Typed[T]) GetMulti(keys []string) (map[string]T, error) {
+	result := make(map[string]T, len(keys))
+	var errs []error
+	for key, raw := range t.cache.GetMulti(keys) {
+		if len(raw) == 0 {
+			continue
+		}
+		v, err := t.decode(key, raw)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result[key] = v
+	}
+	return result, errors.Join(errs...)
+}
+
+func (t *Typed[T]) Set(key string, v T, ttl time.Duration) error {
+	raw, err := t.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.cache.Set(key, string(raw), ttl)
+}
+
+func (t *Typed[T]) SetWithBucket(bucket string, key string, v T, ttl time.Duration) error {
+	raw, err := t.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.cache.SetWithBucket(bucket, key, string(raw), ttl)
+}
+
+// GetOrLoad 缓存不存在或无法解码时调用 load，并写回缓存
+func (t *Typed[T]) GetOrLoad(key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	v, err := t.Get(key)
+	if err == nil {
+		return v, nil
+	}
+	var decodeErr *DecodeError
+	if !errors.Is(err, ErrNotFound) && !errors.As(err, &decodeErr) {
+		return v, err
+	}
+
+	v, err = load()
+	if err != nil {
+		return v, err
+	}
+	return v, t.Set(key, v, ttl)
+}
+
+func (t *Typed[T]) Delete(key string) error {
+	return t.cache.Delete(key)
+}
+
+func (t *Typed[T]) decode(key string, raw string) (T, error) {
+	var v T
+	if err := t.codec.Unmarshal([]byte(raw), &v); err != nil {
+		return v, &DecodeError{Key: key, Err: err}
+	}
+	return v, nil
+}
@@ -0,0 +1,80 @@
+package caches_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	Id   int
+	Name string
+	Tags []string
+}
+
+func TestTypedCodecs(t *testing.T) {
+	codecs := map[string]caches.Codec{
+		"json":     caches.JSONCodec,
+		"sonic":    caches.SonicCodec,
+		"gob":      caches.GobCodec,
+		"msgpack":  caches.MsgpackCodec,
+		"compress": caches.Compress(caches.JSONCodec, 16),
+	}
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			users := caches.NewTyped[user](mem.NewLRU(10, 0), codec)
+			u := user{Id: 1, Name: strings.Repeat("foo", 20), Tags: []string{"a", "b"}}
+
+			assert.Nil(t, users.Set("u1", u, time.Minute))
+			got, err := users.Get("u1")
+			assert.Nil(t, err)
+			assert.Equal(t, u, got)
+		})
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	cache := mem.NewLRU(10, 0)
+	users := caches.NewTyped[user](cache, nil)
+
+	_, err := users.Get("nope")
+	assert.True(t, errors.Is(err, caches.ErrNotFound))
+
+	cache.Set("bad", "not json", time.Minute)
+	_, err = users.Get("bad")
+	var decodeErr *caches.DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "bad", decodeErr.Key)
+
+	users.Set("u1", user{Id: 1}, time.Minute)
+	got, err := users.GetMulti([]string{"u1", "bad", "nope"})
+	assert.Equal(t, map[string]user{"u1": {Id: 1}}, got)
+	assert.True(t, errors.As(err, &decodeErr))
+}
+
+func TestTypedGetOrLoad(t *testing.T) {
+	users := caches.NewTyped[user](mem.NewLRU(10, 0), caches.MsgpackCodec)
+
+	calls := 0
+	load := func() (user, error) {
+		calls++
+		return user{Id: 2}, nil
+	}
+	for i := 0; i < 3; i++ {
+		u, err := users.GetOrLoad("u2", time.Minute, load)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, u.Id)
+	}
+	assert.Equal(t, 1, calls)
+
+	loadErr := errors.New("db down")
+	_, err := users.GetOrLoad("u3", time.Minute, func() (user, error) {
+		return user{}, loadErr
+	})
+	assert.Equal(t, loadErr, err)
+}
@@ -41,7 +41,7 @@ func (c *Component) generateText(ext []string) (tempText string, err error) {
 		// 生成一个文件用于合并： 格式
 		// file ./name.mov
 		// log.Println(c.getTempText())
-		log.Println(ijson.Pretty(files))
+		ijson.LogPretty(files)
 
 		if itempText, err := ifile.CreateFile(text); err != nil {
 			return "", err
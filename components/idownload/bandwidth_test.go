@@ -0,0 +1,39 @@
+package idownload
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/syntax/irate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadRateLimit(t *testing.T) {
+	s := newRangeServer(t, 200*1024)
+	dir := t.TempDir()
+
+	// 组件限速，分片共享：突发 100KB 后剩余 100KB 约 1 秒
+	d := New(WithConcurrency(2), WithRetryAttempt(0), WithRateLimit(100*1024))
+	start := time.Now()
+	_, err := d.Download(s.URL, filepath.Join(dir, "a"))
+	assert.Nil(t, err)
+	assert.InDelta(t, time.Second, time.Since(start), float64(400*time.Millisecond))
+
+	// 运行中取消组件限速，单个下载限速
+	d.SetRateLimit(0)
+	start = time.Now()
+	_, err = d.DownloadContext(context.Background(), s.URL, filepath.Join(dir, "b"), WithBandwidth(irate.NewBandwidth(100*1024)))
+	assert.Nil(t, err)
+	assert.InDelta(t, time.Second, time.Since(start), float64(400*time.Millisecond))
+
+	start = time.Now()
+	_, err = d.Download(s.URL, filepath.Join(dir, "c"))
+	assert.Nil(t, err)
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+
+	data, _ := os.ReadFile(filepath.Join(dir, "b"))
+	assert.Equal(t, s.content, data)
+}
@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cute-angelia/go-xutils/syntax/irate"
 	humanize "github.com/dustin/go-humanize"
 	"github.com/guonaihong/gout"
 	"github.com/guonaihong/gout/dataflow"
@@ -23,13 +24,6 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-// 全局 iclient
-var iHttpClient *gout.Client
-
-func init() {
-	iHttpClient = gout.NewWithOpt(gout.WithInsecureSkipVerify())
-}
-
 var (
 	ErrorNotFound = errors.New("404 file not found")
 	ErrorHead     = errors.New("error head")
@@ -39,6 +33,14 @@ var (
 type FileInfo struct {
 	SourceUrl string
 	Path      string
+	Size      int64
+
+	// 配置了 ValidatorCache 且服务端返回 304，保留本地文件，没有重新下载
+	NotModified bool
+
+	// 开启 Verify、设置了期望校验值（WithChecksum）或服务端返回了校验值时计算
+	Md5    string
+	Sha256 string
 }
 
 type Component struct {
@@ -46,15 +48,34 @@ type Component struct {
 	// 进度条 — 加锁保护并发赋值
 	mu  sync.Mutex
 	bar *progressbar.ProgressBar
+	// 组件限速，分片下载的所有分片共享
+	bandwidth *irate.Bandwidth
+	// 每个组件独立的 client，SetSOCKS5 / SetProxy 会修改 Transport，不能影响其他组件
+	client *gout.Client
 }
 
 // newComponent ...
 func newComponent(config *config) *Component {
 	comp := &Component{}
 	comp.config = config
+	comp.bandwidth = irate.NewBandwidth(config.RateLimit)
+	comp.client = gout.NewWithOpt(gout.WithInsecureSkipVerify())
 	return comp
 }
 
+// SetRateLimit 运行中修改组件限速，单位 字节/秒，0 不限速
+func (d *Component) SetRateLimit(bytesPerSecond int64) {
+	d.bandwidth.SetLimit(bytesPerSecond)
+}
+
+// limitReader 依次受全局 irate.GlobalBandwidth、组件 RateLimit 和单个下载 transfer（WithBandwidth）的限速
+func (d *Component) limitReader(ctx context.Context, r io.Reader, transfer *irate.Bandwidth) io.Reader {
+	if !irate.Limited(irate.GlobalBandwidth, d.bandwidth, transfer) {
+		return r
+	}
+	return irate.NewReader(ctx, r, irate.GlobalBandwidth, d.bandwidth, transfer)
+}
+
 func (d *Component) getHttpHeader() gout.H {
 	gh := gout.H{}
 	if len(d.config.UserAgent) > 0 {
@@ -80,19 +101,19 @@ func (d *Component) getGoHttpClient(uri string, method string) *dataflow.DataFlo
 	var igout *dataflow.DataFlow
 	switch method {
 	case "GET":
-		igout = iHttpClient.GET(uri)
+		igout = d.client.GET(uri)
 	case "POST":
-		igout = iHttpClient.POST(uri)
+		igout = d.client.POST(uri)
 	case "PUT":
-		igout = iHttpClient.PUT(uri)
+		igout = d.client.PUT(uri)
 	case "DELETE":
-		igout = iHttpClient.DELETE(uri)
+		igout = d.client.DELETE(uri)
 	case "HEAD":
-		igout = iHttpClient.HEAD(uri)
+		igout = d.client.HEAD(uri)
 	case "OPTIONS":
-		igout = iHttpClient.OPTIONS(uri)
+		igout = d.client.OPTIONS(uri)
 	default:
-		igout = iHttpClient.GET(uri)
+		igout = d.client.GET(uri)
 	}
 	if d.config.Timeout > 0 {
 		igout = igout.SetTimeout(d.config.Timeout)
@@ -135,7 +156,27 @@ func (d *Component) GetContentLength(strURL string) int {
 
 // Download 下载文件
 func (d *Component) Download(strURL, filename string) (fileInfo FileInfo, errResp error) {
+	return d.DownloadContext(context.Background(), strURL, filename)
+}
+
+// downloadContext 整体下载的 ctx
+// 修复：使用 DownloadTimeout（整体下载超时），而非单次请求的 Timeout
+func (d *Component) downloadContext(parent context.Context) (context.Context, context.CancelFunc) {
+	downloadTimeout := d.config.DownloadTimeout
+	if downloadTimeout <= 0 {
+		downloadTimeout = d.config.Timeout * time.Duration(d.config.Concurrency+1)
+	}
+	if downloadTimeout > 0 {
+		return context.WithTimeout(parent, downloadTimeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// DownloadContext 下载文件，ctx 取消时停止下载（包括重试），分片模式下已下载的分片保留，下次继续
+// opts 为单个下载的参数，如 WithBandwidth
+func (d *Component) DownloadContext(parent context.Context, strURL, filename string, opts ...DownloadOption) (fileInfo FileInfo, errResp error) {
 	strURL = strings.TrimSpace(strURL)
+	o := newDownloadOptions(opts)
 
 	if !strings.Contains(strURL, "http") {
 		return fileInfo, errors.New("Url 不合法：" + strURL)
@@ -156,14 +197,19 @@ func (d *Component) Download(strURL, filename string) (fileInfo FileInfo, errRes
 	header := http.Header{}
 	var statusCode int
 
-	// 修复：使用 DownloadTimeout（整体下载超时），而非单次请求的 Timeout
-	downloadTimeout := d.config.DownloadTimeout
-	if downloadTimeout <= 0 {
-		downloadTimeout = d.config.Timeout * time.Duration(d.config.Concurrency+1)
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	ctx, cancel := d.downloadContext(parent)
 	defer cancel()
 
+	// 条件请求：本地文件是上次下载的且远程没有变化时直接返回
+	if info, ok := d.notModified(ctx, strURL, filename); ok {
+		return info, nil
+	}
+	defer func(strURL string) {
+		if errResp == nil {
+			d.saveValidator(strURL, newRemoteInfo(header), fileInfo)
+		}
+	}(strURL)
+
 	err := d.getGoHttpClient(strURL, "HEAD").BindHeader(&header).Code(&statusCode).Do()
 	if err != nil {
 		log.Println("Head", err.Error())
@@ -180,14 +226,22 @@ func (d *Component) Download(strURL, filename string) (fileInfo FileInfo, errRes
 
 	// 是否分片下载
 	if statusCode == http.StatusOK && header.Get("Accept-Ranges") == "bytes" && d.config.Concurrency > 0 {
-		contentLength, _ := strconv.Atoi(header.Get("Content-Length"))
+		remote := newRemoteInfo(header)
 		// 修复：ctx 传入 multiDownload，超时/取消真正生效
-		fileInfo, errResp = d.multiDownload(ctx, strURL, filename, contentLength)
+		fileInfo, errResp = d.multiDownload(ctx, strURL, filename, remote, o)
 		if errResp != nil && d.config.RetryAttempt > 0 {
 			log.Println("下载失败：错误：", strURL, errResp, "开始重试：", d.config.RetryAttempt)
 			NewRetry(d.config.RetryAttempt, d.config.RetryWaitTime).Func(func() error {
 				log.Println("NewRetry multiDownload", strURL, filename)
-				fileInfo, errResp = d.multiDownload(ctx, strURL, filename, contentLength)
+				// 远程文件已变化，重新获取长度和 ETag
+				if errors.Is(errResp, ErrRemoteChanged) {
+					header = http.Header{}
+					if err := d.getGoHttpClient(strURL, "HEAD").BindHeader(&header).Do(); err != nil {
+						return ErrRetry
+					}
+					remote = newRemoteInfo(header)
+				}
+				fileInfo, errResp = d.multiDownload(ctx, strURL, filename, remote, o)
 				if errResp != nil {
 					return ErrRetry
 				}
@@ -205,13 +259,13 @@ func (d *Component) Download(strURL, filename string) (fileInfo FileInfo, errRes
 	}
 
 	// 单例下载：修复：ctx 传入 singleDownload
-	fileInfo, errResp = d.singleDownload(ctx, strURL, filename)
+	fileInfo, errResp = d.singleDownload(ctx, strURL, filename, o)
 	if errResp != nil {
 		log.Println("下载失败：错误：", strURL, errResp, "开始重试：", d.config.RetryAttempt)
 		if d.config.RetryAttempt > 0 {
 			NewRetry(d.config.RetryAttempt, d.config.RetryWaitTime).Func(func() error {
 				log.Println("NewRetry singleDownload", strURL, filename)
-				fileInfo, errResp = d.singleDownload(ctx, strURL, filename)
+				fileInfo, errResp = d.singleDownload(ctx, strURL, filename, o)
 				if errResp != nil {
 					log.Println("singleDownload 下载失败：", errResp)
 					return ErrRetry
@@ -253,6 +307,7 @@ func (d *Component) DownloadToByteRetry(src string, retry int) ([]byte, error) {
 }
 
 // DownloadToByte 请求文件，流式读取返回字节，预分配容量避免大文件 OOM
+// 配置了 ValidatorCache 时发送条件请求，内容没有变化返回 ErrNotModified
 func (d *Component) DownloadToByte(strURL string) ([]byte, error) {
 	strURL = strings.TrimSpace(strURL)
 
@@ -270,6 +325,9 @@ func (d *Component) DownloadToByte(strURL string) ([]byte, error) {
 	for key, value := range headers {
 		req.Header.Add(key, fmt.Sprintf("%v", value))
 	}
+	if v, ok := d.loadValidator(strURL); ok && len(v.Path) == 0 {
+		v.setHeader(req.Header)
+	}
 
 	resp, err := iClient.Do(req)
 	if err != nil {
@@ -277,6 +335,16 @@ func (d *Component) DownloadToByte(strURL string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 
+	// 只有 2xx 才算成功并记录校验信息，错误页不能当成文件内容
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, ErrNotModified
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrorNotFound, strURL)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("%s error: %d", strURL, resp.StatusCode)
+	}
+
 	// 预分配，避免 bytes.Buffer 多次扩容
 	var buf bytes.Buffer
 	if resp.ContentLength > 0 {
@@ -284,17 +352,18 @@ func (d *Component) DownloadToByte(strURL string) ([]byte, error) {
 	}
 
 	bufCache := make([]byte, 32*1024)
+	body := d.limitReader(context.Background(), resp.Body, nil)
 
-	if d.config.Progressbar {
-		bar := d.newBar(int(resp.ContentLength), strURL)
-		_, err = io.CopyBuffer(io.MultiWriter(&buf, bar), resp.Body, bufCache)
-	} else {
-		_, err = io.CopyBuffer(&buf, resp.Body, bufCache)
-	}
+	progress := d.newProgress(nil, strURL, "", resp.ContentLength, []int64{resp.ContentLength}, nil)
+	progress.setState(0, PartRunning)
+	_, err = io.CopyBuffer(progress.writer(0, &buf), body, bufCache)
+	progress.setState(0, partState(err))
+	progress.finish(err)
 
 	if err != nil {
 		return nil, err
 	}
+	d.saveValidator(strURL, newRemoteInfo(resp.Header), FileInfo{Size: int64(buf.Len())})
 	return buf.Bytes(), nil
 }
 
@@ -303,16 +372,25 @@ func (d *Component) RemoveFile(filepath string) error {
 	return os.Remove(filepath)
 }
 
-// CleanPartFiles 清理指定文件的所有分片临时文件，方便手动清理下载中断后的残留文件。
+// CleanPartFiles 清理指定文件的所有分片临时文件和下载清单，方便手动清理下载中断后的残留文件。
+// 分片数以清单为准，没有清单时按 Concurrency。
 // 如果某个分片文件不存在则忽略，其余错误会聚合后返回。
 func (d *Component) CleanPartFiles(filename string) error {
+	parts := d.config.Concurrency
+	if m, err := ReadManifest(filename); err == nil && len(m.Parts) > parts {
+		parts = len(m.Parts)
+	}
+
 	var errs []string
-	for i := 0; i < d.config.Concurrency; i++ {
+	for i := 0; i < parts; i++ {
 		partFile := d.getPartFilename(filename, i)
 		if err := os.Remove(partFile); err != nil && !os.IsNotExist(err) {
 			errs = append(errs, fmt.Sprintf("删除分片 %s 失败: %v", partFile, err))
 		}
 	}
+	if err := os.Remove(getManifestFilename(filename)); err != nil && !os.IsNotExist(err) {
+		errs = append(errs, fmt.Sprintf("删除下载清单失败: %v", err))
+	}
 	if len(errs) > 0 {
 		return errors.New(strings.Join(errs, "; "))
 	}
@@ -322,74 +400,80 @@ func (d *Component) CleanPartFiles(filename string) error {
 // multiDownload 并发分片下载
 // 修复：
 //   - 接收外部 ctx，超时/取消真正生效
-//   - 使用 errgroup 收集 goroutine 错误；下载或合并失败时自动清理分片文件
-//   - Resume 模式下跳过已完整下载的分片
-func (d *Component) multiDownload(ctx context.Context, strURL, filename string, contentLen int) (FileInfo, error) {
-	var info FileInfo
-
-	bar := d.newBar(contentLen, strURL)
+//   - 使用 errgroup 收集 goroutine 错误；合并失败时自动清理分片文件
+//   - Resume 模式下分片进度记录在清单文件中，进程重启后跳过已下载的部分；下载失败保留分片供下次继续
+func (d *Component) multiDownload(ctx context.Context, strURL, filename string, remote remoteInfo, o *DownloadOptions) (FileInfo, error) {
+	return d.multiDownloadMirrors(ctx, newMirrorSet(&mirror{url: strURL, remote: remote, ranges: true}), filename, o)
+}
 
-	partSize := contentLen / d.config.Concurrency
+// multiDownloadMirrors 分片分配到不同镜像下载，清单和校验以最快的镜像为准
+func (d *Component) multiDownloadMirrors(ctx context.Context, mirrors *mirrorSet, filename string, o *DownloadOptions) (info FileInfo, err error) {
+	strURL, remote := mirrors.primary().url, mirrors.primary().remote
 	partDir := d.getPartDir(filename)
 	if err := os.MkdirAll(partDir, 0777); err != nil {
 		return info, fmt.Errorf("创建分片目录失败: %w", err)
 	}
 
-	eg, egCtx := errgroup.WithContext(ctx)
-	rangeStart := 0
+	m, err := d.loadManifest(strURL, filename, remote)
+	if err != nil {
+		return info, fmt.Errorf("读取下载清单失败: %w", err)
+	}
+
+	partTotals, partDone := make([]int64, len(m.Parts)), make([]int64, len(m.Parts))
+	for i, part := range m.Parts {
+		partTotals[i], partDone[i] = int64(part.Len()), int64(part.Done)
+	}
+	progress := d.newProgress(o.OnProgress, strURL, filename, int64(remote.ContentLength), partTotals, partDone)
+	defer func() { progress.finish(err) }()
 
-	for i := 0; i < d.config.Concurrency; i++ {
-		i, rangeStart := i, rangeStart
+	eg, egCtx := errgroup.WithContext(ctx)
+	for i, part := range m.Parts {
+		i, part := i, part
 
-		rangeEnd := rangeStart + partSize - 1
-		if i == d.config.Concurrency-1 {
-			rangeEnd = contentLen - 1
+		// 分片已完整，跳过请求
+		if part.Done >= part.Len() {
+			continue
 		}
 
 		eg.Go(func() error {
-			downloaded := 0
-			partFileName := d.getPartFilename(filename, i)
-
-			if d.config.Resume {
-				if content, err := os.ReadFile(partFileName); err == nil {
-					downloaded = len(content)
-				}
-				if d.config.Progressbar && bar != nil {
-					bar.Add(downloaded)
-				}
+			// 镜像失败时换下一个镜像继续；续传带上 If-Range，远程文件变化时不会拼接出错误的文件
+			progress.setState(i, PartRunning)
+			err := d.downloadPartMirrors(egCtx, mirrors, filename, part, progress, o)
+			progress.setState(i, partState(err))
+			if err != nil {
+				return err
 			}
-
-			partLen := rangeEnd - rangeStart + 1
-
-			// 分片已完整，跳过请求
-			if downloaded >= partLen {
-				return nil
+			if d.config.Resume {
+				return m.setDone(i, part.Len())
 			}
-
-			// isAppend：Resume 且本次是续传（有已下载内容）
-			isAppend := d.config.Resume && downloaded > 0
-			return d.downloadPartial(egCtx, strURL, filename, rangeStart+downloaded, rangeEnd, i, isAppend, bar)
+			return nil
 		})
-
-		rangeStart = rangeEnd + 1
 	}
 
 	if err := eg.Wait(); err != nil {
-		if cleanErr := d.CleanPartFiles(filename); cleanErr != nil {
-			log.Println("清理分片文件失败:", cleanErr)
+		if !d.config.Resume || errors.Is(err, ErrRemoteChanged) {
+			if cleanErr := d.CleanPartFiles(filename); cleanErr != nil {
+				log.Println("清理分片文件失败:", cleanErr)
+			}
+		} else if syncErr := m.sync(d); syncErr == nil {
+			m.save()
 		}
 		return info, fmt.Errorf("分片下载失败: %w", err)
 	}
 
-	if err := d.merge(filename); err != nil {
+	if err := d.merge(filename, m); err != nil {
 		if cleanErr := d.CleanPartFiles(filename); cleanErr != nil {
 			log.Println("清理分片文件失败:", cleanErr)
 		}
 		return info, fmt.Errorf("合并文件失败: %w", err)
 	}
+	os.Remove(getManifestFilename(filename))
 
 	info.SourceUrl = strURL
 	info.Path = filename
+	if err := d.verify(&info, int64(remote.ContentLength), o.Checksum, remote.Checksum); err != nil {
+		return FileInfo{}, err
+	}
 	return info, nil
 }
 
@@ -397,7 +481,7 @@ func (d *Component) multiDownload(ctx context.Context, strURL, filename string,
 // 修复：
 //   - 接收外部 ctx，超时/取消真正生效
 //   - 增加 O_TRUNC，防止重试时残留旧数据
-func (d *Component) singleDownload(ctx context.Context, strURL, filename string) (FileInfo, error) {
+func (d *Component) singleDownload(ctx context.Context, strURL, filename string, o *DownloadOptions) (FileInfo, error) {
 	var info FileInfo
 
 	iClient := d.getGoHttpClient(strURL, "GET").Client()
@@ -425,20 +509,32 @@ func (d *Component) singleDownload(ctx context.Context, strURL, filename string)
 	defer f.Close()
 
 	buf := make([]byte, 32*1024)
+	body := d.limitReader(ctx, resp.Body, o.Bandwidth)
 
-	if d.config.Progressbar {
-		bar := d.newBar(int(resp.ContentLength), strURL)
-		_, err = io.CopyBuffer(io.MultiWriter(f, bar), resp.Body, buf)
-	} else {
-		_, err = io.CopyBuffer(f, resp.Body, buf)
-	}
+	progress := d.newProgress(o.OnProgress, strURL, filename, resp.ContentLength, []int64{resp.ContentLength}, nil)
+	progress.setState(0, PartRunning)
+	_, err = io.CopyBuffer(progress.writer(0, f), body, buf)
+	progress.setState(0, partState(err))
+	progress.finish(err)
 
 	if err != nil {
 		return info, err
 	}
+	if err = f.Close(); err != nil {
+		return info, err
+	}
 
 	info.SourceUrl = strURL
 	info.Path = filename
+
+	// 自动解压时长度和校验值对应压缩后的内容，只能校验期望值
+	contentLength, header := resp.ContentLength, headerChecksum(resp.Header)
+	if resp.Uncompressed {
+		contentLength, header = -1, Checksum{}
+	}
+	if err = d.verify(&info, contentLength, o.Checksum, header); err != nil {
+		return FileInfo{}, err
+	}
 	return info, nil
 }
 
@@ -479,7 +575,9 @@ func (d *Component) newBar(length int, name string) *progressbar.ProgressBar {
 // 修复：
 //   - isAppend 由调用方显式传入，语义清晰，避免用 rangeStart>0 隐式判断
 //   - 非续传场景强制 O_TRUNC，防止残留脏数据产生"0字节"或错误文件
-func (d *Component) downloadPartial(ctx context.Context, strURL, filename string, rangeStart, rangeEnd, i int, isAppend bool, bar *progressbar.ProgressBar) error {
+//   - ifRange 不为空时发送 If-Range，服务端返回 200 说明远程文件已变化，返回 ErrRemoteChanged
+//   - 写入的数据同时写入 progress，统计进度
+func (d *Component) downloadPartial(ctx context.Context, strURL, filename string, rangeStart, rangeEnd, i int, isAppend bool, ifRange string, progress io.Writer, transfer *irate.Bandwidth) error {
 	if rangeStart > rangeEnd {
 		return nil
 	}
@@ -497,12 +595,18 @@ func (d *Component) downloadPartial(ctx context.Context, strURL, filename string
 	}
 
 	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", rangeStart, rangeEnd))
+	if len(ifRange) > 0 {
+		req.Header.Set("If-Range", ifRange)
+	}
 	resp, err := iClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("分片 %d 请求失败: %w", i, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusOK && len(ifRange) > 0 {
+		return fmt.Errorf("分片 %d: %w", i, ErrRemoteChanged)
+	}
 	if resp.StatusCode != http.StatusPartialContent {
 		return fmt.Errorf("分片 %d 服务端返回非 206: %d", i, resp.StatusCode)
 	}
@@ -522,21 +626,16 @@ func (d *Component) downloadPartial(ctx context.Context, strURL, filename string
 	}
 	defer partFile.Close()
 
-	var writer io.Writer = partFile
-	if d.config.Progressbar && bar != nil {
-		writer = io.MultiWriter(partFile, bar)
-	}
-
 	buf := make([]byte, 32*1024)
-	if _, err = io.CopyBuffer(writer, resp.Body, buf); err != nil && !errors.Is(err, io.EOF) {
+	if _, err = io.CopyBuffer(io.MultiWriter(partFile, progress), d.limitReader(ctx, resp.Body, transfer), buf); err != nil && !errors.Is(err, io.EOF) {
 		return fmt.Errorf("分片 %d 写入失败: %w", i, err)
 	}
 	return nil
 }
 
-// merge 将所有分片按序合并到目标文件
+// merge 将清单中的分片按序合并到目标文件
 // 修复：增加 O_TRUNC，重试时清空旧文件，防止末尾残留脏数据
-func (d *Component) merge(filename string) error {
+func (d *Component) merge(filename string, m *Manifest) error {
 	// 修复：O_TRUNC 确保重试时目标文件从头写入
 	destFile, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
 	if err != nil {
@@ -544,7 +643,8 @@ func (d *Component) merge(filename string) error {
 	}
 	defer destFile.Close()
 
-	for i := 0; i < d.config.Concurrency; i++ {
+	for _, part := range m.Parts {
+		i := part.Index
 		partFileName := d.getPartFilename(filename, i)
 		partFile, err := os.Open(partFileName)
 		if err != nil {
@@ -0,0 +1,115 @@
+package idownload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/cute-angelia/go-xutils/syntax/ijson"
+)
+
+// ErrNotModified 配置了 ValidatorCache 时，DownloadToByte 条件请求返回 304，内容与上次相同
+var ErrNotModified = errors.New("not modified")
+
+// validator 上次下载的 ETag / Last-Modified，保存在 ValidatorCache
+type validator struct {
+	ETag         string
+	LastModified string
+	Path         string // 文件下载的保存路径，DownloadToByte 为空
+	Size         int64
+}
+
+// setHeader 条件请求头，远程文件没有变化时返回 304
+func (v validator) setHeader(header http.Header) {
+	if len(v.ETag) > 0 {
+		header.Set("If-None-Match", v.ETag)
+	}
+	if len(v.LastModified) > 0 {
+		header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+func (d *Component) validatorKey(strURL string) string {
+	return d.config.ValidatorCache.GenerateCacheKey(d.config.ValidatorBucket, strURL)
+}
+
+// loadValidator 没有配置 ValidatorCache 或没有记录时返回 false
+func (d *Component) loadValidator(strURL string) (validator, bool) {
+	var v validator
+	if d.config.ValidatorCache == nil {
+		return v, false
+	}
+	data, err := d.config.ValidatorCache.Get(d.validatorKey(strURL))
+	if err != nil {
+		return v, false
+	}
+	if err := ijson.Decode([]byte(data), &v); err != nil {
+		return v, false
+	}
+	return v, true
+}
+
+// saveValidator 下载成功后保存响应头中的 ETag / Last-Modified，都没有时删除记录
+func (d *Component) saveValidator(strURL string, remote remoteInfo, info FileInfo) {
+	if d.config.ValidatorCache == nil {
+		return
+	}
+
+	key := d.validatorKey(strURL)
+	v := validator{
+		ETag:         remote.ETag,
+		LastModified: remote.LastModified,
+		Path:         info.Path,
+		Size:         info.Size,
+	}
+	if len(v.ETag) == 0 && len(v.LastModified) == 0 {
+		d.config.ValidatorCache.Delete(key)
+		return
+	}
+
+	data, err := ijson.Encode(v)
+	if err == nil {
+		err = d.config.ValidatorCache.SetWithBucket(d.config.ValidatorBucket, key, string(data), d.config.ValidatorTTL)
+	}
+	if err != nil {
+		log.Println(PackageName, "保存 ETag / Last-Modified 失败", strURL, err)
+	}
+}
+
+// notModified 文件下载前的条件 HEAD：本地文件还是上次下载的（路径、大小一致）且服务端返回 304 时不重新下载
+func (d *Component) notModified(ctx context.Context, strURL, filename string) (FileInfo, bool) {
+	v, ok := d.loadValidator(strURL)
+	if !ok || v.Path != filename {
+		return FileInfo{}, false
+	}
+	if stat, err := os.Stat(filename); err != nil || stat.Size() != v.Size {
+		return FileInfo{}, false
+	}
+
+	iClient := d.getGoHttpClient(strURL, "HEAD").Client()
+	req, err := http.NewRequestWithContext(ctx, "HEAD", strURL, nil)
+	if err != nil {
+		return FileInfo{}, false
+	}
+	for key, value := range d.getHttpHeader() {
+		req.Header.Add(key, fmt.Sprintf("%v", value))
+	}
+	v.setHeader(req.Header)
+
+	resp, err := iClient.Do(req)
+	if err != nil {
+		return FileInfo{}, false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		return FileInfo{}, false
+	}
+
+	if d.config.Debug {
+		log.Println("未修改，保留本地文件：", strURL, filename)
+	}
+	return FileInfo{SourceUrl: strURL, Path: filename, Size: v.Size, NotModified: true}, true
+}
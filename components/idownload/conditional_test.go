@@ -0,0 +1,119 @@
+package idownload
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+// etagServer ServeContent 处理 If-None-Match，gets 记录 GET 次数
+type etagServer struct {
+	*httptest.Server
+	version atomic.Int32
+	gets    atomic.Int32
+}
+
+func newEtagServer(t *testing.T) *etagServer {
+	s := &etagServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			s.gets.Add(1)
+		}
+		w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, s.version.Load()))
+		http.ServeContent(w, r, "feed.json", time.Time{}, bytes.NewReader(s.content()))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *etagServer) content() []byte {
+	return []byte(fmt.Sprintf(`{"version":%d}`, s.version.Load()))
+}
+
+func TestDownloadNotModified(t *testing.T) {
+	s := newEtagServer(t)
+	filename := filepath.Join(t.TempDir(), "feed.json")
+	d := New(WithRetryAttempt(0), WithValidatorCache(mem.NewLRU(100, 0)))
+
+	info, err := d.Download(s.URL, filename)
+	assert.Nil(t, err)
+	assert.False(t, info.NotModified)
+
+	info, err = d.Download(s.URL, filename)
+	assert.Nil(t, err)
+	assert.True(t, info.NotModified)
+	assert.Equal(t, filename, info.Path)
+	assert.Equal(t, int32(1), s.gets.Load())
+
+	// 远程变化后重新下载
+	s.version.Add(1)
+	info, err = d.Download(s.URL, filename)
+	assert.Nil(t, err)
+	assert.False(t, info.NotModified)
+	data, _ := os.ReadFile(filename)
+	assert.Equal(t, s.content(), data)
+
+	// 本地文件被修改，不发送条件请求
+	os.WriteFile(filename, []byte("{}"), 0666)
+	info, err = d.Download(s.URL, filename)
+	assert.Nil(t, err)
+	assert.False(t, info.NotModified)
+	assert.Equal(t, int32(3), s.gets.Load())
+}
+
+func TestDownloadToByteNotModified(t *testing.T) {
+	s := newEtagServer(t)
+	d := New(WithValidatorCache(mem.NewLRU(100, 0)))
+
+	data, err := d.DownloadToByte(s.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, s.content(), data)
+
+	_, err = d.DownloadToByte(s.URL)
+	assert.True(t, errors.Is(err, ErrNotModified))
+
+	s.version.Add(1)
+	data, err = d.DownloadToByte(s.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, s.content(), data)
+
+	// 没有配置 ValidatorCache 时不发送条件请求
+	data, err = New().DownloadToByte(s.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, s.content(), data)
+}
+
+func TestDownloadToByteErrorStatus(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if fail.Load() {
+			http.Error(w, "oops", http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(w, r, "feed.json", time.Time{}, bytes.NewReader([]byte("{}")))
+	}))
+	t.Cleanup(s.Close)
+	d := New(WithValidatorCache(mem.NewLRU(100, 0)))
+
+	// 错误页不算成功，也不记录校验信息
+	data, err := d.DownloadToByte(s.URL)
+	assert.NotNil(t, err)
+	assert.Nil(t, data)
+
+	fail.Store(false)
+	data, err = d.DownloadToByte(s.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("{}"), data)
+}
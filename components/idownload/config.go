@@ -2,6 +2,8 @@ package idownload
 
 import (
 	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
 )
 
 const PackageName = "component.idownload"
@@ -38,6 +40,17 @@ type config struct {
 	Debug           bool          //  debug 日志
 
 	Progressbar bool // 进度条开关
+	Verify      bool // 下载完成后计算 md5 / sha256 返回在 FileInfo
+
+	RateLimit int64 // 限速 字节/秒，0 不限速；全局限速见 irate.GlobalBandwidth，单个下载见 WithBandwidth
+
+	OnProgress       ProgressFunc  `json:"-"` // 进度回调，单个下载见 WithProgress；终端进度条也是一个回调
+	ProgressInterval time.Duration // 进度回调间隔，默认 500ms
+
+	// 条件请求：保存每个链接的 ETag / Last-Modified，下次下载时远程没有变化则不重新下载
+	ValidatorCache  caches.Cache  `json:"-"`
+	ValidatorBucket string        // 保存使用的 bucket
+	ValidatorTTL    time.Duration // 记录过期时间，0 不过期
 }
 
 // DefaultConfig 返回默认配置
@@ -57,6 +70,8 @@ func DefaultConfig() *config {
 		RetryAttempt:             3,
 		RetryWaitTime:            time.Second * 5,
 		Progressbar:              false,
+		ProgressInterval:         500 * time.Millisecond,
+		ValidatorBucket:          "idownload:validators",
 		FileMax:                  -1,
 	}
 }
@@ -1,6 +1,7 @@
 package idownload
 
 import (
+	"github.com/cute-angelia/go-xutils/components/caches"
 	"github.com/cute-angelia/go-xutils/syntax/ijson"
 	"github.com/spf13/viper"
 	"log"
@@ -116,6 +117,13 @@ func WithProgressbar(Progressbar bool) Option {
 	}
 }
 
+// WithVerify 下载完成后计算 md5 / sha256；长度、期望值和响应头中的校验值不需要开启也会校验
+func WithVerify(verify bool) Option {
+	return func(c *Container) {
+		c.config.Verify = verify
+	}
+}
+
 func WithResume(resume bool) Option {
 	return func(c *Container) {
 		c.config.Resume = resume
@@ -158,3 +166,46 @@ func WithRetryWaitTime(retryWaitTime time.Duration) Option {
 		c.config.RetryWaitTime = retryWaitTime
 	}
 }
+
+// WithRateLimit 组件限速 字节/秒，运行中可以 SetRateLimit 修改
+func WithRateLimit(bytesPerSecond int64) Option {
+	return func(c *Container) {
+		c.config.RateLimit = bytesPerSecond
+	}
+}
+
+// WithOnProgress 进度回调，所有下载共用；单个下载见 WithProgress
+func WithOnProgress(f ProgressFunc) Option {
+	return func(c *Container) {
+		c.config.OnProgress = f
+	}
+}
+
+// WithProgressInterval 进度回调间隔
+func WithProgressInterval(interval time.Duration) Option {
+	return func(c *Container) {
+		c.config.ProgressInterval = interval
+	}
+}
+
+// WithValidatorCache 保存 ETag / Last-Modified 发送条件请求，如 ibunt / iredis；
+// 文件未修改时返回 FileInfo.NotModified，DownloadToByte 返回 ErrNotModified
+func WithValidatorCache(cache caches.Cache) Option {
+	return func(c *Container) {
+		c.config.ValidatorCache = cache
+	}
+}
+
+// WithValidatorBucket ValidatorCache 使用的 bucket
+func WithValidatorBucket(bucket string) Option {
+	return func(c *Container) {
+		c.config.ValidatorBucket = bucket
+	}
+}
+
+// WithValidatorTTL ETag / Last-Modified 记录过期时间
+func WithValidatorTTL(ttl time.Duration) Option {
+	return func(c *Container) {
+		c.config.ValidatorTTL = ttl
+	}
+}
@@ -0,0 +1,37 @@
+package idownload
+
+import (
+	"github.com/cute-angelia/go-xutils/syntax/irate"
+)
+
+// DownloadOptions 单个下载的参数，配合 DownloadContext / DownloadMirrors / DownloadHls / OpenStream 使用
+type DownloadOptions struct {
+	Bandwidth  *irate.Bandwidth // 单个下载限速，与全局、组件限速同时生效
+	Checksum   Checksum         // 期望的校验值，为空的不校验
+	OnProgress ProgressFunc     // 进度回调，与组件配置的 OnProgress 同时生效
+}
+
+type DownloadOption func(*DownloadOptions)
+
+// WithBandwidth 单个下载限速，多个下载传入同一个 Bandwidth 时共享限速
+func WithBandwidth(b *irate.Bandwidth) DownloadOption {
+	return func(o *DownloadOptions) { o.Bandwidth = b }
+}
+
+// WithChecksum 期望的校验值，下载完成后与文件比较，不一致时返回 ErrChecksumMismatch
+func WithChecksum(sum Checksum) DownloadOption {
+	return func(o *DownloadOptions) { o.Checksum = sum }
+}
+
+// WithProgress 单个下载的进度回调
+func WithProgress(f ProgressFunc) DownloadOption {
+	return func(o *DownloadOptions) { o.OnProgress = f }
+}
+
+func newDownloadOptions(opts []DownloadOption) *DownloadOptions {
+	o := &DownloadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
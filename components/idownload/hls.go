@@ -0,0 +1,392 @@
+package idownload
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/cute-angelia/go-xutils/syntax/irate"
+	"github.com/cute-angelia/go-xutils/syntax/isingleflight"
+	"golang.org/x/sync/errgroup"
+)
+
+// Remuxer 转封装，*ffmpeg.Component 实现了该接口（-c copy，不重新编码）
+type Remuxer interface {
+	Convert(input string, savePath string) error
+}
+
+// HlsOptions m3u8 下载选项
+type HlsOptions struct {
+	MaxBandwidth int     // 选择不超过该码率的最高一路，0 不限制
+	MaxHeight    int     // 选择不超过该分辨率高度的最高一路，如 720，0 不限制
+	Concurrency  int     // 同时下载的分片数，0 时使用 Concurrency 配置，至少 1
+	Remux        Remuxer // 不为空时先合并为 filename.ts，再转封装为 filename（如 .mp4）
+}
+
+// DownloadHls 下载 m3u8：
+//   - master 播放列表按 HlsOptions 选择一路码率
+//   - 分片并发下载，失败按 RetryAttempt 重试；AES-128 加密的分片解密
+//   - 分片暂存在 filename.hls 目录，中断后再次下载跳过已完成的分片，合并后删除
+//
+// 直播播放列表只下载当前列出的分片
+func (d *Component) DownloadHls(ctx context.Context, playlistURL, filename string, opt HlsOptions, opts ...DownloadOption) (info FileInfo, err error) {
+	o := newDownloadOptions(opts)
+	playlist, err := d.fetchM3u8(ctx, playlistURL)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	if playlist.IsMaster() {
+		variant := selectM3u8Variant(playlist.Variants, opt)
+		if d.config.Debug {
+			log.Println("m3u8 选择码率", variant.Bandwidth, variant.Resolution, variant.Uri)
+		}
+		if playlist, err = d.fetchM3u8(ctx, variant.Uri); err != nil {
+			return FileInfo{}, err
+		}
+	}
+	if len(playlist.Segments) == 0 {
+		return FileInfo{}, fmt.Errorf("%w: 没有分片 %s", ErrM3u8, playlistURL)
+	}
+
+	segmentDir := filename + ".hls"
+	if err := os.MkdirAll(segmentDir, 0777); err != nil {
+		return FileInfo{}, fmt.Errorf("创建分片目录失败: %w", err)
+	}
+
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = max(d.config.Concurrency, 1)
+	}
+	keys := &hlsKeys{d: d, keys: map[string][]byte{}}
+
+	// 每个媒体分片一个进度分片，长度未知
+	segments, offset := playlist.Segments, 0
+	if len(playlist.Map) > 0 {
+		segments, offset = append([]M3u8Segment{{Uri: playlist.Map}}, segments...), 1
+	}
+	partTotals := make([]int64, len(segments))
+	for i := range partTotals {
+		partTotals[i] = -1
+	}
+	progress := d.newProgress(o.OnProgress, playlistURL, filename, -1, partTotals, nil)
+	defer func() { progress.finish(err) }()
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(concurrency)
+	files := make([]string, len(segments))
+	for i, segment := range segments {
+		segmentFile := filepath.Join(segmentDir, fmt.Sprintf("%06d.ts", i-offset))
+		if i < offset {
+			segmentFile = filepath.Join(segmentDir, "init")
+		}
+		files[i] = segmentFile
+		eg.Go(func() error {
+			progress.setState(i, PartRunning)
+			n, err := d.downloadHlsSegment(egCtx, keys, segment, segmentFile, o)
+			progress.add(i, n)
+			progress.setState(i, partState(err))
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return FileInfo{}, fmt.Errorf("m3u8 分片下载失败: %w", err)
+	}
+
+	output := filename
+	if opt.Remux != nil {
+		output = filename + ".ts"
+	}
+	if err := mergeHlsSegments(files, output); err != nil {
+		return FileInfo{}, fmt.Errorf("合并分片失败: %w", err)
+	}
+	os.RemoveAll(segmentDir)
+
+	if opt.Remux != nil {
+		err := opt.Remux.Convert(output, filename)
+		os.Remove(output)
+		if err != nil {
+			return FileInfo{}, fmt.Errorf("转封装失败: %w", err)
+		}
+	}
+
+	info = FileInfo{SourceUrl: playlistURL, Path: filename}
+	if err := d.verify(&info, -1, o.Checksum, Checksum{}); err != nil {
+		return FileInfo{}, err
+	}
+	return info, nil
+}
+
+// selectM3u8Variant 满足限制的最高码率，都不满足时选最低码率
+func selectM3u8Variant(variants []M3u8Variant, opt HlsOptions) M3u8Variant {
+	sorted := append([]M3u8Variant(nil), variants...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Bandwidth > sorted[j].Bandwidth })
+
+	for _, v := range sorted {
+		if opt.MaxBandwidth > 0 && v.Bandwidth > opt.MaxBandwidth {
+			continue
+		}
+		if opt.MaxHeight > 0 && v.Height > opt.MaxHeight {
+			continue
+		}
+		return v
+	}
+	return sorted[len(sorted)-1]
+}
+
+func (d *Component) fetchM3u8(ctx context.Context, strURL string) (*M3u8Playlist, error) {
+	data, err := d.fetchBytes(ctx, strURL)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(strURL)
+	if err != nil {
+		return nil, err
+	}
+	return ParseM3u8(bytes.NewReader(data), base)
+}
+
+// downloadHlsSegment 已存在的分片跳过；先写临时文件再重命名，中断不会留下不完整的分片
+// 返回分片文件的字节数，用于统计进度
+func (d *Component) downloadHlsSegment(ctx context.Context, keys *hlsKeys, segment M3u8Segment, segmentFile string, o *DownloadOptions) (int64, error) {
+	if stat, err := os.Stat(segmentFile); err == nil {
+		return stat.Size(), nil
+	}
+
+	var (
+		block cipher.Block
+		iv    []byte
+		err   error
+	)
+	if segment.Key != nil {
+		if block, iv, err = keys.cipher(ctx, segment); err != nil {
+			return 0, fmt.Errorf("分片 %d 解密失败: %w", segment.Sequence, err)
+		}
+	}
+
+	// 响应体直接写入临时文件，重试时重新创建
+	tmp := segmentFile + ".tmp"
+	var size int64
+	err = d.fetch(ctx, segment.Uri, o.Bandwidth, func(body io.Reader) error {
+		f, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if block == nil {
+			size, err = io.Copy(f, body)
+			if err != nil {
+				return err
+			}
+			return f.Close()
+		}
+		dec := &hlsDecrypter{w: f, mode: cipher.NewCBCDecrypter(block, iv)}
+		if _, err := io.Copy(dec, body); err != nil {
+			return err
+		}
+		if err := dec.Close(); err != nil {
+			return fmt.Errorf("分片 %d 解密失败: %w", segment.Sequence, err)
+		}
+		size = dec.written
+		return f.Close()
+	})
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return size, os.Rename(tmp, segmentFile)
+}
+
+// mergeHlsSegments 按播放列表顺序合并，init 分片在最前
+func mergeHlsSegments(files []string, output string) error {
+	dest, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dest, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return dest.Close()
+}
+
+// fetchBytes GET 整个响应，用于播放列表和密钥
+func (d *Component) fetchBytes(ctx context.Context, strURL string) ([]byte, error) {
+	var data []byte
+	err := d.fetch(ctx, strURL, nil, func(body io.Reader) (err error) {
+		data, err = io.ReadAll(body)
+		return err
+	})
+	return data, err
+}
+
+// fetch GET 并把响应体交给 read，失败按 RetryAttempt 重试，404 不重试；每次重试 read 都从头读取
+func (d *Component) fetch(ctx context.Context, strURL string, transfer *irate.Bandwidth, read func(body io.Reader) error) error {
+	err := d.fetchOnce(ctx, strURL, transfer, read)
+	if err != nil && !errors.Is(err, ErrorNotFound) && d.config.RetryAttempt > 0 {
+		NewRetry(d.config.RetryAttempt, d.config.RetryWaitTime).Func(func() error {
+			if err = d.fetchOnce(ctx, strURL, transfer, read); err != nil {
+				return ErrRetry
+			}
+			return nil
+		}).Do(ctx)
+	}
+	return err
+}
+
+func (d *Component) fetchOnce(ctx context.Context, strURL string, transfer *irate.Bandwidth, read func(body io.Reader) error) error {
+	iClient := d.getGoHttpClient(strURL, "GET").Client()
+	req, err := http.NewRequestWithContext(ctx, "GET", strURL, nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range d.getHttpHeader() {
+		req.Header.Add(key, fmt.Sprintf("%v", value))
+	}
+
+	resp, err := iClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrorNotFound, strURL)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("%s error: %d", strURL, resp.StatusCode)
+	}
+	return read(d.limitReader(ctx, resp.Body, transfer))
+}
+
+// hlsKeys 缓存 AES-128 密钥，同一个密钥只请求一次
+// 请求密钥时不持有锁，并发的相同 uri 由 group 合并
+type hlsKeys struct {
+	d     *Component
+	group isingleflight.Group[[]byte]
+	mu    sync.Mutex
+	keys  map[string][]byte
+}
+
+func (k *hlsKeys) get(ctx context.Context, uri string) ([]byte, error) {
+	k.mu.Lock()
+	key, ok := k.keys[uri]
+	k.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	key, err, _ := k.group.Do(uri, func() ([]byte, error) {
+		key, err := k.d.fetchBytes(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != aes.BlockSize {
+			return nil, fmt.Errorf("密钥长度 %d", len(key))
+		}
+
+		k.mu.Lock()
+		k.keys[uri] = key
+		k.mu.Unlock()
+		return key, nil
+	})
+	return key, err
+}
+
+// cipher AES-128-CBC 的密钥和 IV；没有 IV 时使用分片序号
+func (k *hlsKeys) cipher(ctx context.Context, segment M3u8Segment) (cipher.Block, []byte, error) {
+	if segment.Key.Method != "AES-128" {
+		return nil, nil, fmt.Errorf("不支持的加密方式 %s", segment.Key.Method)
+	}
+	key, err := k.get(ctx, segment.Key.Uri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	iv := segment.Key.IV
+	if len(iv) == 0 {
+		iv = make([]byte, aes.BlockSize)
+		binary.BigEndian.PutUint64(iv[8:], uint64(segment.Sequence))
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, nil, errors.New("IV 不合法")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, iv, nil
+}
+
+// hlsDecrypter AES-128-CBC 流式解密，最后一个块留到 Close 时去掉 PKCS7 填充再写入
+type hlsDecrypter struct {
+	w       io.Writer
+	mode    cipher.BlockMode
+	buf     []byte // 还没有解密的数据，至少保留一个块
+	written int64  // 写入 w 的明文字节数
+}
+
+func (c *hlsDecrypter) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+	if n := (len(c.buf) - 1) / aes.BlockSize * aes.BlockSize; n > 0 {
+		c.mode.CryptBlocks(c.buf[:n], c.buf[:n])
+		m, err := c.w.Write(c.buf[:n])
+		c.written += int64(m)
+		if err != nil {
+			return 0, err
+		}
+		c.buf = append(c.buf[:0], c.buf[n:]...)
+	}
+	return len(p), nil
+}
+
+// Close 解密最后一个块并去掉填充，不关闭 w
+func (c *hlsDecrypter) Close() error {
+	if len(c.buf) != aes.BlockSize {
+		return errors.New("分片长度不合法")
+	}
+	c.mode.CryptBlocks(c.buf, c.buf)
+	data, err := unpadPKCS7(c.buf)
+	if err != nil {
+		return err
+	}
+	m, err := c.w.Write(data)
+	c.written += int64(m)
+	return err
+}
+
+// unpadPKCS7 去掉 PKCS7 填充，每个填充字节都必须等于填充长度
+func unpadPKCS7(data []byte) ([]byte, error) {
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > aes.BlockSize || padding > len(data) {
+		return nil, errors.New("填充不合法")
+	}
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("填充不合法")
+		}
+	}
+	return data[:len(data)-padding], nil
+}
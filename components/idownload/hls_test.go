@@ -0,0 +1,273 @@
+package idownload
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseM3u8(t *testing.T) {
+	base, _ := url.Parse("https://cdn.example.com/video/master.m3u8")
+
+	master := `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d401e,mp4a.40.2"
+360p/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2500000,RESOLUTION=1280x720
+/abs/720p.m3u8
+`
+	p, err := ParseM3u8(strings.NewReader(master), base)
+	assert.Nil(t, err)
+	assert.True(t, p.IsMaster())
+	assert.Equal(t, []M3u8Variant{
+		{Uri: "https://cdn.example.com/video/360p/index.m3u8", Bandwidth: 800000, Resolution: "640x360", Width: 640, Height: 360, Codecs: "avc1.4d401e,mp4a.40.2"},
+		{Uri: "https://cdn.example.com/abs/720p.m3u8", Bandwidth: 2500000, Resolution: "1280x720", Width: 1280, Height: 720},
+	}, p.Variants)
+
+	assert.Equal(t, 2500000, selectM3u8Variant(p.Variants, HlsOptions{}).Bandwidth)
+	assert.Equal(t, 800000, selectM3u8Variant(p.Variants, HlsOptions{MaxHeight: 480}).Bandwidth)
+	assert.Equal(t, 800000, selectM3u8Variant(p.Variants, HlsOptions{MaxBandwidth: 1000}).Bandwidth)
+
+	media := `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:7
+#EXTINF:9.5,
+a.ts
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin",IV=0x000102030405060708090a0b0c0d0e0f
+#EXTINF:10.0,title
+b.ts?token=1
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:0.5,
+https://other.example.com/c.ts
+#EXT-X-ENDLIST
+`
+	p, err = ParseM3u8(strings.NewReader(media), base)
+	assert.Nil(t, err)
+	assert.False(t, p.IsMaster())
+	assert.True(t, p.End)
+	assert.Equal(t, 20.0, p.Duration)
+	assert.Len(t, p.Segments, 3)
+	assert.Equal(t, M3u8Segment{Uri: "https://cdn.example.com/video/a.ts", Duration: 9.5, Sequence: 7}, p.Segments[0])
+	assert.Equal(t, "https://cdn.example.com/video/b.ts?token=1", p.Segments[1].Uri)
+	assert.Equal(t, "https://cdn.example.com/video/key.bin", p.Segments[1].Key.Uri)
+	assert.Equal(t, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, p.Segments[1].Key.IV)
+	assert.Nil(t, p.Segments[2].Key)
+	assert.Equal(t, 9, p.Segments[2].Sequence)
+
+	_, err = ParseM3u8(strings.NewReader("<html>"), base)
+	assert.ErrorIs(t, err, ErrM3u8)
+}
+
+func encryptSegment(key, iv, data []byte) []byte {
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	data = append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	block, _ := aes.NewCipher(key)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return data
+}
+
+type fakeRemuxer struct {
+	input, output string
+}
+
+func (r *fakeRemuxer) Convert(input string, savePath string) error {
+	r.input, r.output = input, savePath
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(savePath, append([]byte("mp4:"), data...), 0666)
+}
+
+func TestDownloadHls(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	explicitIV := []byte("fedcba9876543210")
+	segments := []string{"segment-0;", "segment-1 encrypted;", "segment-2 encrypted with sequence iv;", "segment-3;"}
+
+	// 分片 2 没有 IV，使用序号 10+2
+	sequenceIV := make([]byte, 16)
+	binary.BigEndian.PutUint64(sequenceIV[8:], 12)
+	bodies := map[string][]byte{
+		"/hd/0.ts": []byte(segments[0]),
+		"/hd/1.ts": encryptSegment(key, explicitIV, []byte(segments[1])),
+		"/hd/2.ts": encryptSegment(key, sequenceIV, []byte(segments[2])),
+		"/hd/3.ts": []byte(segments[3]),
+		"/key":     key,
+		"/master.m3u8": []byte(`#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=500000,RESOLUTION=640x360
+sd.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=3000000,RESOLUTION=1920x1080
+hd/index.m3u8
+`),
+		"/hd/index.m3u8": []byte(fmt.Sprintf(`#EXTM3U
+#EXT-X-MEDIA-SEQUENCE:10
+#EXTINF:4,
+0.ts
+#EXT-X-KEY:METHOD=AES-128,URI="/key",IV=0x%x
+#EXTINF:4,
+1.ts
+#EXT-X-KEY:METHOD=AES-128,URI="/key"
+#EXTINF:4,
+2.ts
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:4,
+3.ts
+#EXT-X-ENDLIST
+`, explicitIV)),
+	}
+
+	// 每个分片第一次请求失败
+	var keyRequests int32
+	failed := map[string]*int32{}
+	for name := range bodies {
+		failed[name] = new(int32)
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/key" {
+			atomic.AddInt32(&keyRequests, 1)
+		}
+		if strings.HasSuffix(r.URL.Path, ".ts") && atomic.AddInt32(failed[r.URL.Path], 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(body)
+	}))
+	defer s.Close()
+
+	dir := t.TempDir()
+	d := New(WithConcurrency(3), WithRetryAttempt(2), WithRetryWaitTime(time.Millisecond))
+
+	filename := filepath.Join(dir, "video.ts")
+	info, err := d.DownloadHls(context.Background(), s.URL+"/master.m3u8", filename, HlsOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(strings.Join(segments, ""))), info.Size)
+
+	data, _ := os.ReadFile(filename)
+	assert.Equal(t, strings.Join(segments, ""), string(data))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&keyRequests))
+	_, err = os.Stat(filename + ".hls")
+	assert.True(t, os.IsNotExist(err))
+
+	// 转封装
+	remuxer := &fakeRemuxer{}
+	filename = filepath.Join(dir, "video.mp4")
+	_, err = d.DownloadHls(context.Background(), s.URL+"/hd/index.m3u8", filename, HlsOptions{Remux: remuxer})
+	assert.Nil(t, err)
+	assert.Equal(t, filename+".ts", remuxer.input)
+	data, _ = os.ReadFile(filename)
+	assert.Equal(t, "mp4:"+strings.Join(segments, ""), string(data))
+	_, err = os.Stat(filename + ".ts")
+	assert.True(t, os.IsNotExist(err))
+
+	// 选择的码率不存在
+	_, err = d.DownloadHls(context.Background(), s.URL+"/master.m3u8", filepath.Join(dir, "sd.ts"), HlsOptions{MaxHeight: 720})
+	assert.ErrorIs(t, err, ErrorNotFound)
+}
+
+func TestHlsDecrypter(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+	block, _ := aes.NewCipher(key)
+
+	decrypt := func(data []byte, chunk int) ([]byte, error) {
+		var out bytes.Buffer
+		dec := &hlsDecrypter{w: &out, mode: cipher.NewCBCDecrypter(block, iv)}
+		for len(data) > 0 {
+			n := min(chunk, len(data))
+			dec.Write(data[:n])
+			data = data[n:]
+		}
+		if err := dec.Close(); err != nil {
+			return nil, err
+		}
+		assert.Equal(t, int64(out.Len()), dec.written)
+		return out.Bytes(), nil
+	}
+
+	// 写入的块大小与 AES 块不对齐
+	for _, size := range []int{0, 15, 16, 33, 1000} {
+		plain := bytes.Repeat([]byte{'a'}, size)
+		for _, chunk := range []int{1, 7, 16, 4096} {
+			data, err := decrypt(encryptSegment(key, iv, plain), chunk)
+			assert.Nil(t, err)
+			assert.Equal(t, string(plain), string(data))
+		}
+	}
+
+	// 最后一个字节合法，其余填充字节不一致
+	bad := append(bytes.Repeat([]byte{'a'}, 13), 1, 3, 3)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(bad, bad)
+	_, err := decrypt(bad, 16)
+	assert.NotNil(t, err)
+
+	// 长度不是块的整数倍
+	_, err = decrypt(encryptSegment(key, iv, []byte("segment"))[:15], 16)
+	assert.NotNil(t, err)
+}
+
+func TestHlsKeys(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.key" {
+			fetches.Add(1)
+			<-release
+		}
+		w.Write(bytes.Repeat([]byte{1}, aes.BlockSize))
+	}))
+	t.Cleanup(s.Close)
+
+	keys := &hlsKeys{d: New(WithRetryAttempt(0)), keys: map[string][]byte{}}
+	ctx := context.Background()
+
+	// 相同 uri 并发只请求一次
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := keys.get(ctx, s.URL+"/slow.key")
+			errs <- err
+		}()
+	}
+
+	// 慢密钥请求中，其他密钥不被阻塞
+	done := make(chan error, 1)
+	go func() {
+		_, err := keys.get(ctx, s.URL+"/fast.key")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("fast key blocked by slow key")
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			assert.Nil(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("slow key timeout")
+		}
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+}
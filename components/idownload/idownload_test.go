@@ -37,7 +37,7 @@ func TestRosemm(t *testing.T) {
 	)
 	// findo, err := idown.DownloadToByte(uri)
 	findo, err := idown.Download(uri, "/tmp/1.jpg")
-	log.Println(ijson.Pretty(findo))
+	ijson.LogPretty(findo)
 	log.Println(err)
 }
 
@@ -50,7 +50,7 @@ func TestToptoon(t *testing.T) {
 	)
 	// findo, err := idown.DownloadToByte(uri)
 	findo, err := idown.Download(uri, "/tmp/1.jpg")
-	log.Println(ijson.Pretty(findo))
+	ijson.LogPretty(findo)
 	log.Println(err)
 }
 
@@ -65,7 +65,7 @@ func TestV2(t *testing.T) {
 		WithCookie("frontend=54300d6621fe8bf51aa0bd41bac06da6; frontend-rmu=SIKqjQhmcTsvgdVhaeg4rdj7EC8%3D; frontend-rmt=iWCNVa30N8MEds0vju3LY9QKfnIPrNIgvZ45wtWJnCtsPtvgQCodEKj3RAqyd5cy; _gid=GA1.2.1309613440.1651131130; fpestid=UflGBDjSIrZXUuUkg9YCWQigFBXg9H5TVbykLToxcXpsc5zMwRXfjUSlegirc6fyzbK6rw; __cf_bm=YcWcL8MOMBKJYiDAXWLk6U0f4MbJU0x0twkWApJeQaI-1651138216-0-AUUO2wc3zy5DVtt8H4y8QdyCUha8yGwpS3g87QpXspOiPNv+Xm/ZgNEK1yisl9yhKSS3UCV7WR8Vgv8xze0zU4AO3xsw8CZ6skvQQe9PtInuA9vX50Bs9zGLseAcNPnuhA==; _ga_170M3FX3HZ=GS1.1.1651137021.3.1.1651138218.47; _ga=GA1.2.1638894544.1651131130"),
 	)
 	findo, err := idown.Download(uri, "/tmp/1.jpg")
-	log.Println(ijson.Pretty(findo))
+	ijson.LogPretty(findo)
 	log.Println(err)
 }
 
@@ -75,7 +75,7 @@ func TestV3(t *testing.T) {
 		WithUserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.4896.127 Safari/537.36"),
 	)
 	findo, err := idown.Download(uri, "/tmp/2.jpg")
-	log.Println(ijson.Pretty(findo))
+	ijson.LogPretty(findo)
 	log.Println(err)
 }
 
@@ -85,7 +85,7 @@ func TestHuaBan(t *testing.T) {
 		WithUserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.4896.127 Safari/537.36"),
 	)
 	findo, err := idown.Download(uri, "/tmp/2.jpg")
-	log.Println(ijson.Pretty(findo))
+	ijson.LogPretty(findo)
 	log.Println(err)
 }
 
@@ -93,7 +93,7 @@ func TestDownloadDouyin(t *testing.T) {
 	uri := "https://aweme.snssdk.com/aweme/v1/play/?video_id=v0200fg10000c6b2tlrc77ub0qkvip1g&line=0&ratio=1080p&media_type=4&vr_type=0&improve_bitrate=0&is_play_url=1&is_support_h265=0&source=PackSourceEnum_PUBLISH"
 	idown := New()
 	findo, err := idown.Download(uri, "/tmp/1.mp4")
-	log.Println(ijson.Pretty(findo))
+	ijson.LogPretty(findo)
 	log.Println(err)
 }
 
@@ -123,7 +123,7 @@ func TestDownloads(t *testing.T) {
 		// 下载文件
 		//newName := ifile.NewFileName(datum.Uri).GetNameOrigin()
 		//if fileinfo, err := idown.Download(datum.Uri, "/tmp/"+newName); err == nil {
-		//	log.Println(ijson.Pretty(fileinfo))
+		//	ijson.LogPretty(fileinfo)
 		//} else {
 		//	log.Println("获取图片失败：❌", err)
 		//}
@@ -172,7 +172,7 @@ func TestDownLargeFile(t *testing.T) {
 	)
 	newname := ifile.NewFileName(fileuri).GetNameSnowFlow()
 	if filebyte, err := idown.Download(fileuri, "/tmp/"+newname); err == nil {
-		log.Println(ijson.Pretty(filebyte))
+		ijson.LogPretty(filebyte)
 	} else {
 		log.Println(err)
 	}
@@ -216,6 +216,6 @@ func TestTwitter(t *testing.T) {
 	// info, err := idownloader.Download("https://video.twimg.com/ext_tz_video/1671201090497576960/pu/vid/1280x720/LAtCzKi_8NkCMZ_0.mp4?tag=12", "/tmp/1.mp4")
 	info, err := idownloader.Download("https://video.twimg.com/amplixy_video/1669825038521110528/vid/1920x1080/4Z3t98204cgh06Qo.mp4?tag=16", "/tmp/1.mp4")
 
-	log.Println(ijson.Pretty(info))
+	ijson.LogPretty(info)
 	log.Println(err)
 }
@@ -0,0 +1,195 @@
+package idownload
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrM3u8 不是合法的 m3u8 播放列表
+var ErrM3u8 = errors.New("invalid m3u8 playlist")
+
+// M3u8Variant master 播放列表中的一路码率
+type M3u8Variant struct {
+	Uri        string
+	Bandwidth  int
+	Resolution string // 如 1280x720
+	Width      int
+	Height     int
+	Codecs     string
+}
+
+// M3u8Key EXT-X-KEY，Method 为 NONE 时不加密
+type M3u8Key struct {
+	Method string
+	Uri    string
+	IV     []byte // 为空时使用分片序号
+}
+
+// M3u8Segment 媒体分片
+type M3u8Segment struct {
+	Uri      string
+	Duration float64
+	Sequence int
+	Key      *M3u8Key
+}
+
+// M3u8Playlist master 播放列表只有 Variants，媒体播放列表只有 Segments
+type M3u8Playlist struct {
+	Variants []M3u8Variant
+	Segments []M3u8Segment
+	Map      string // EXT-X-MAP 初始化分片（fMP4）
+	Duration float64
+	End      bool // EXT-X-ENDLIST，点播
+}
+
+// IsMaster 是否为 master 播放列表
+func (p *M3u8Playlist) IsMaster() bool {
+	return len(p.Variants) > 0
+}
+
+// ParseM3u8 解析播放列表，相对地址按 base 转为绝对地址
+func ParseM3u8(r io.Reader, base *url.URL) (*M3u8Playlist, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	p := &M3u8Playlist{}
+	var (
+		header   bool
+		sequence int
+		duration float64
+		key      *M3u8Key
+		variant  *M3u8Variant
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		if !header {
+			if !strings.HasPrefix(line, "#EXTM3U") {
+				return nil, ErrM3u8
+			}
+			header = true
+			continue
+		}
+
+		tag, value, _ := strings.Cut(line, ":")
+		switch {
+		case tag == "#EXT-X-MEDIA-SEQUENCE":
+			sequence, _ = strconv.Atoi(value)
+		case tag == "#EXTINF":
+			duration, _ = strconv.ParseFloat(strings.Split(value, ",")[0], 64)
+		case tag == "#EXT-X-ENDLIST":
+			p.End = true
+		case tag == "#EXT-X-STREAM-INF":
+			attrs := parseM3u8Attributes(value)
+			variant = &M3u8Variant{
+				Resolution: attrs["RESOLUTION"],
+				Codecs:     attrs["CODECS"],
+			}
+			variant.Bandwidth, _ = strconv.Atoi(attrs["BANDWIDTH"])
+			if w, h, ok := strings.Cut(variant.Resolution, "x"); ok {
+				variant.Width, _ = strconv.Atoi(w)
+				variant.Height, _ = strconv.Atoi(h)
+			}
+		case tag == "#EXT-X-KEY":
+			attrs := parseM3u8Attributes(value)
+			key = nil
+			if method := attrs["METHOD"]; method != "NONE" {
+				key = &M3u8Key{Method: method}
+				if raw := attrs["URI"]; len(raw) > 0 {
+					uri, err := resolveM3u8Uri(base, raw)
+					if err != nil {
+						return nil, err
+					}
+					key.Uri = uri
+				}
+				if iv := attrs["IV"]; len(iv) > 2 {
+					b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(iv, "0x"), "0X"))
+					if err != nil {
+						return nil, fmt.Errorf("%w: IV %s", ErrM3u8, iv)
+					}
+					key.IV = b
+				}
+			}
+		case tag == "#EXT-X-MAP":
+			uri, err := resolveM3u8Uri(base, parseM3u8Attributes(value)["URI"])
+			if err != nil {
+				return nil, err
+			}
+			p.Map = uri
+		case strings.HasPrefix(line, "#"):
+			// 其他标签忽略
+		default:
+			uri, err := resolveM3u8Uri(base, line)
+			if err != nil {
+				return nil, err
+			}
+			if variant != nil {
+				variant.Uri = uri
+				p.Variants = append(p.Variants, *variant)
+				variant = nil
+				continue
+			}
+			p.Segments = append(p.Segments, M3u8Segment{
+				Uri:      uri,
+				Duration: duration,
+				Sequence: sequence,
+				Key:      key,
+			})
+			p.Duration += duration
+			sequence++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, ErrM3u8
+	}
+	return p, nil
+}
+
+// parseM3u8Attributes 解析属性列表 KEY=VALUE,KEY="VALUE"，引号内可以有逗号
+func parseM3u8Attributes(s string) map[string]string {
+	attrs := map[string]string{}
+	for len(s) > 0 {
+		name, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		attrs[strings.TrimSpace(name)] = value
+		s = rest
+	}
+	return attrs
+}
+
+func resolveM3u8Uri(base *url.URL, uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrM3u8, uri)
+	}
+	if base == nil {
+		return u.String(), nil
+	}
+	return base.ResolveReference(u).String(), nil
+}
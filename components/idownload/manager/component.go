@@ -0,0 +1,445 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/idownload"
+	"github.com/cute-angelia/go-xutils/syntax/ijson"
+)
+
+const PackageName = "component.idownload.manager"
+
+var (
+	ErrNotFound     = errors.New("job not found")
+	ErrInvalidState = errors.New("invalid job state")
+	ErrClosed       = errors.New("manager closed")
+
+	// 取消下载的原因，决定任务的下一个状态
+	errPaused   = errors.New("paused")
+	errCanceled = errors.New("canceled")
+	errClosed   = errors.New("closed")
+)
+
+// Downloader *idownload.Component 实现了该接口
+type Downloader interface {
+	DownloadContext(ctx context.Context, strURL, filename string, opts ...idownload.DownloadOption) (idownload.FileInfo, error)
+	CleanPartFiles(filename string) error
+}
+
+// Component 下载队列
+//   - 按优先级调度，限制全局和每个 host 的同时下载数
+//   - 任务可以暂停、继续、取消；暂停的分片下载保留分片，继续时断点续传
+//   - 配置 Store 时任务持久化，重启后未完成的任务继续下载
+type Component struct {
+	config     *config
+	downloader Downloader
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	running int
+	hosts   map[string]int
+	seq     int64
+	closed  bool
+	changed chan struct{} // 任务状态变化时关闭并替换，用于 Wait
+	wg      sync.WaitGroup
+}
+
+// newComponent 加载持久化的任务并开始下载
+func newComponent(config *config, downloader Downloader) *Component {
+	c := &Component{
+		config:     config,
+		downloader: downloader,
+		jobs:       map[string]*Job{},
+		hosts:      map[string]int{},
+		changed:    make(chan struct{}),
+	}
+	if err := c.load(); err != nil {
+		panic(fmt.Sprintf("[%s] 加载任务失败: %s", PackageName, err))
+	}
+	c.schedule()
+	return c
+}
+
+// Enqueue 添加任务，priority 越大越先下载
+func (c *Component) Enqueue(strURL, filename string, priority int) (Job, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return Job{}, ErrClosed
+	}
+
+	c.seq++
+	now := time.Now()
+	job := &Job{
+		ID:        strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.FormatInt(c.seq, 36),
+		Url:       strURL,
+		Filename:  filename,
+		Priority:  priority,
+		Seq:       c.seq,
+		CreatedAt: now,
+	}
+	c.jobs[job.ID] = job
+	event, err := c.setStateWithMutexHold(job, StateQueued)
+	if err != nil {
+		delete(c.jobs, job.ID)
+		c.mu.Unlock()
+		return Job{}, err
+	}
+	c.mu.Unlock()
+
+	c.emit(event)
+	c.schedule()
+	return *job, nil
+}
+
+// Get 查询任务
+func (c *Component) Get(id string) (Job, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	job, ok := c.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return *job, nil
+}
+
+// Jobs 所有任务，按调度顺序
+func (c *Component) Jobs() []Job {
+	c.mu.Lock()
+	jobs := make([]*Job, 0, len(c.jobs))
+	for _, job := range c.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].before(jobs[j]) })
+
+	result := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		result = append(result, *job)
+	}
+	c.mu.Unlock()
+	return result
+}
+
+// Pause 暂停任务，下载中的任务停止下载
+func (c *Component) Pause(id string) error {
+	return c.update(id, func(job *Job) (State, error) {
+		switch job.State {
+		case StateQueued:
+			return StatePaused, nil
+		case StateRunning:
+			job.resume = false
+			job.cancel(errPaused)
+			return StateRunning, nil
+		case StatePaused:
+			return StatePaused, nil
+		}
+		return job.State, ErrInvalidState
+	})
+}
+
+// Resume 继续暂停、失败或取消的任务
+func (c *Component) Resume(id string) error {
+	err := c.update(id, func(job *Job) (State, error) {
+		switch job.State {
+		case StatePaused, StateFailed, StateCanceled:
+			job.Error = ""
+			return StateQueued, nil
+		case StateRunning:
+			// 可能已暂停但下载还未结束
+			job.resume = true
+			return StateRunning, nil
+		case StateQueued:
+			return StateQueued, nil
+		}
+		return job.State, ErrInvalidState
+	})
+	if err == nil {
+		c.schedule()
+	}
+	return err
+}
+
+// Cancel 取消任务并清理分片文件
+func (c *Component) Cancel(id string) error {
+	return c.update(id, func(job *Job) (State, error) {
+		switch job.State {
+		case StateQueued, StatePaused, StateFailed:
+			c.clean(job)
+			return StateCanceled, nil
+		case StateRunning:
+			job.cancel(errCanceled)
+			return StateRunning, nil
+		case StateCanceled:
+			return StateCanceled, nil
+		}
+		return job.State, ErrInvalidState
+	})
+}
+
+// Remove 删除未在下载的任务记录，不删除已下载的文件
+func (c *Component) Remove(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	job, ok := c.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if job.State == StateRunning {
+		return ErrInvalidState
+	}
+	delete(c.jobs, id)
+	if c.config.Store != nil {
+		return c.config.Store.Delete(c.storeKey(id))
+	}
+	return nil
+}
+
+// Wait 等待所有排队和下载中的任务结束
+func (c *Component) Wait(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		busy := c.running > 0
+		for _, job := range c.jobs {
+			busy = busy || job.State == StateQueued
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		if !busy {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Close 停止下载，下载中的任务回到排队状态，配置 Store 时下次启动继续
+func (c *Component) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	for _, job := range c.jobs {
+		if job.State == StateRunning {
+			job.cancel(errClosed)
+		}
+	}
+	c.mu.Unlock()
+
+	c.wg.Wait()
+	return nil
+}
+
+// update 修改任务状态并通知
+func (c *Component) update(id string, f func(job *Job) (State, error)) error {
+	c.mu.Lock()
+	job, ok := c.jobs[id]
+	if !ok {
+		c.mu.Unlock()
+		return ErrNotFound
+	}
+
+	state, err := f(job)
+	var event Event
+	if err == nil && state != job.State {
+		event, err = c.setStateWithMutexHold(job, state)
+	}
+	c.mu.Unlock()
+
+	if event.Job.ID != "" {
+		c.emit(event)
+	}
+	return err
+}
+
+// schedule 按优先级启动任务，直到达到全局或 host 的并发限制
+func (c *Component) schedule() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+
+	queued := make([]*Job, 0)
+	for _, job := range c.jobs {
+		if job.State == StateQueued {
+			queued = append(queued, job)
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool { return queued[i].before(queued[j]) })
+
+	var (
+		started []*Job
+		ctxs    []context.Context
+		events  []Event
+	)
+	for _, job := range queued {
+		if c.config.Concurrency > 0 && c.running >= c.config.Concurrency {
+			break
+		}
+		host := job.host()
+		if limit := c.hostLimit(host); limit > 0 && c.hosts[host] >= limit {
+			continue
+		}
+
+		ctx, cancel := context.WithCancelCause(context.Background())
+		job.cancel = cancel
+		c.running++
+		c.hosts[host]++
+		event, err := c.setStateWithMutexHold(job, StateRunning)
+		if err != nil {
+			log.Println(PackageName, "保存任务失败", job.ID, err)
+		}
+
+		started = append(started, job)
+		ctxs = append(ctxs, ctx)
+		events = append(events, event)
+	}
+	c.wg.Add(len(started))
+	c.mu.Unlock()
+
+	// 先通知 running，再开始下载，保证同一个任务的事件有序
+	c.emit(events...)
+	for i, job := range started {
+		go c.run(ctxs[i], job)
+	}
+}
+
+// run 下载一个任务，根据取消原因决定下一个状态
+func (c *Component) run(ctx context.Context, job *Job) {
+	defer c.wg.Done()
+
+	info, err := c.downloader.DownloadContext(ctx, job.Url, job.Filename, idownload.WithProgress(func(p idownload.Progress) { c.progress(job, p) }))
+
+	c.mu.Lock()
+	cause := context.Cause(ctx)
+	job.cancel(nil)
+	job.cancel = nil
+	resume := job.resume
+	job.resume = false
+	c.running--
+	c.hosts[job.host()]--
+
+	var state State
+	switch {
+	case err == nil:
+		job.Path = info.Path
+		state = StateDone
+	case errors.Is(cause, errPaused) && resume:
+		state = StateQueued
+	case errors.Is(cause, errPaused):
+		state = StatePaused
+	case errors.Is(cause, errCanceled):
+		c.clean(job)
+		state = StateCanceled
+	case errors.Is(cause, errClosed):
+		state = StateQueued
+	default:
+		job.Error = err.Error()
+		state = StateFailed
+	}
+	event, err := c.setStateWithMutexHold(job, state)
+	if err != nil {
+		log.Println(PackageName, "保存任务失败", job.ID, err)
+	}
+	c.mu.Unlock()
+
+	c.emit(event)
+	c.schedule()
+}
+
+// progress 更新任务进度并通知，不保存到存储
+func (c *Component) progress(job *Job, p idownload.Progress) {
+	c.mu.Lock()
+	job.Done, job.Total = p.Done, p.Total
+	event := Event{Job: *job, From: job.State, Progress: &p}
+	c.mu.Unlock()
+
+	c.emit(event)
+}
+
+// setStateWithMutexHold 修改状态并持久化
+func (c *Component) setStateWithMutexHold(job *Job, state State) (Event, error) {
+	event := Event{From: job.State}
+	job.State = state
+	job.UpdatedAt = time.Now()
+	event.Job = *job
+
+	close(c.changed)
+	c.changed = make(chan struct{})
+
+	return event, c.save(job)
+}
+
+func (c *Component) emit(events ...Event) {
+	if c.config.OnEvent == nil {
+		return
+	}
+	for _, event := range events {
+		c.config.OnEvent(event)
+	}
+}
+
+func (c *Component) clean(job *Job) {
+	if err := c.downloader.CleanPartFiles(job.Filename); err != nil {
+		log.Println(PackageName, "清理分片文件失败", job.ID, err)
+	}
+}
+
+func (c *Component) hostLimit(host string) int {
+	if limit, ok := c.config.Hosts[host]; ok {
+		return limit
+	}
+	return c.config.HostConcurrency
+}
+
+func (c *Component) storeKey(id string) string {
+	return c.config.Store.GenerateCacheKey(c.config.Bucket, id)
+}
+
+func (c *Component) save(job *Job) error {
+	if c.config.Store == nil {
+		return nil
+	}
+	data, err := ijson.Encode(job)
+	if err != nil {
+		return err
+	}
+	return c.config.Store.SetWithBucket(c.config.Bucket, c.storeKey(job.ID), string(data), 0)
+}
+
+// load 读取持久化的任务，上次退出时下载中的任务重新排队
+func (c *Component) load() error {
+	if c.config.Store == nil {
+		return nil
+	}
+	return c.config.Store.Scan(c.config.Bucket, func(key string) error {
+		data, err := c.config.Store.Get(key)
+		if err != nil {
+			return nil
+		}
+		job := &Job{}
+		if err := ijson.Decode([]byte(data), job); err != nil {
+			log.Println(PackageName, "任务数据损坏", key, err)
+			return nil
+		}
+		if job.State == StateRunning {
+			job.State = StateQueued
+		}
+		if job.Seq > c.seq {
+			c.seq = job.Seq
+		}
+		c.jobs[job.ID] = job
+		return nil
+	})
+}
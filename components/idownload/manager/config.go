@@ -0,0 +1,25 @@
+package manager
+
+import "github.com/cute-angelia/go-xutils/components/caches"
+
+// config options
+type config struct {
+	Concurrency     int            // 全局同时下载数
+	HostConcurrency int            // 每个 host 同时下载数，0 不限制
+	Hosts           map[string]int // 单独设置某个 host 的同时下载数
+
+	Store  caches.Cache // 持久化队列，如 ibunt / ibitcask；为空时只在内存
+	Bucket string       // 持久化使用的 bucket
+
+	OnEvent func(event Event) // 任务状态变化
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *config {
+	return &config{
+		Concurrency:     4,
+		HostConcurrency: 2,
+		Hosts:           map[string]int{},
+		Bucket:          "idownload:jobs",
+	}
+}
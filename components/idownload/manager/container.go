@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
+
+type Option func(c *Container)
+
+type Container struct {
+	config *config
+}
+
+// New options 模式，downloader 一般为 idownload.New(...)，重试、代理等沿用其配置
+func New(downloader Downloader, options ...Option) *Component {
+	c := &Container{
+		config: DefaultConfig(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if downloader == nil {
+		panic(PackageName + " need downloader")
+	}
+	return newComponent(c.config, downloader)
+}
+
+func WithConcurrency(concurrency int) Option {
+	return func(c *Container) {
+		c.config.Concurrency = concurrency
+	}
+}
+
+func WithHostConcurrency(concurrency int) Option {
+	return func(c *Container) {
+		c.config.HostConcurrency = concurrency
+	}
+}
+
+// WithHostLimit 单独设置某个 host 的同时下载数，如 WithHostLimit("cdn.example.com", 8)
+func WithHostLimit(host string, concurrency int) Option {
+	return func(c *Container) {
+		c.config.Hosts[host] = concurrency
+	}
+}
+
+// WithStore 持久化队列，重启后未完成的任务继续下载
+func WithStore(store caches.Cache) Option {
+	return func(c *Container) {
+		c.config.Store = store
+	}
+}
+
+func WithBucket(bucket string) Option {
+	return func(c *Container) {
+		c.config.Bucket = bucket
+	}
+}
+
+func WithOnEvent(f func(event Event)) Option {
+	return func(c *Container) {
+		c.config.OnEvent = f
+	}
+}
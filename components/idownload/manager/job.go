@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/idownload"
+)
+
+// State 任务状态
+type State string
+
+const (
+	StateQueued   State = "queued"   // 等待下载
+	StateRunning  State = "running"  // 下载中
+	StatePaused   State = "paused"   // 已暂停，Resume 后继续
+	StateDone     State = "done"     // 下载完成
+	StateFailed   State = "failed"   // 重试后仍然失败，Resume 后重新排队
+	StateCanceled State = "canceled" // 已取消，分片文件已清理
+)
+
+// Finished 不会再变化，除非 Resume
+func (s State) Finished() bool {
+	return s == StateDone || s == StateFailed || s == StateCanceled
+}
+
+// Job 下载任务
+type Job struct {
+	ID       string
+	Url      string
+	Filename string
+	Priority int // 越大越先下载，相同时先进先出
+	State    State
+	Path     string // 下载完成后的文件
+	Error    string // 失败原因
+	Done     int64  // 已下载字节，随进度事件更新
+	Total    int64  // 总字节，-1 未知
+
+	Seq       int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	cancel context.CancelCauseFunc
+	resume bool // 暂停后、下载结束前又调用了 Resume，结束后重新排队
+}
+
+// Event 任务状态变化或下载进度
+type Event struct {
+	Job      Job                 // 变化后的任务
+	From     State               // 变化前的状态，新增任务时为空
+	Progress *idownload.Progress // 不为空时为进度事件，状态没有变化
+}
+
+// host 按 host 限制并发
+func (j *Job) host() string {
+	u, err := url.Parse(j.Url)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// before 调度顺序
+func (j *Job) before(other *Job) bool {
+	if j.Priority != other.Priority {
+		return j.Priority > other.Priority
+	}
+	return j.Seq < other.Seq
+}
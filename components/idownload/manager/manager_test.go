@@ -0,0 +1,329 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/cute-angelia/go-xutils/components/idownload"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDownloader 下载直到 release 或 ctx 取消，记录开始顺序和每个 host 的最大并发
+type fakeDownloader struct {
+	mu       sync.Mutex
+	started  []string
+	running  map[string]int
+	maxHost  map[string]int
+	release  chan struct{}
+	fail     map[string]bool
+	cleaned  []string
+	startedC chan string
+	hold     chan struct{} // 不为空时，ctx 取消后等待 hold 关闭再返回
+}
+
+func newFakeDownloader() *fakeDownloader {
+	return &fakeDownloader{
+		running:  map[string]int{},
+		maxHost:  map[string]int{},
+		release:  make(chan struct{}),
+		fail:     map[string]bool{},
+		startedC: make(chan string, 100),
+	}
+}
+
+func (f *fakeDownloader) DownloadContext(ctx context.Context, strURL, filename string, opts ...idownload.DownloadOption) (idownload.FileInfo, error) {
+	host := strings.Split(strings.TrimPrefix(strURL, "http://"), "/")[0]
+
+	f.mu.Lock()
+	f.started = append(f.started, strURL)
+	f.running[host]++
+	if f.running[host] > f.maxHost[host] {
+		f.maxHost[host] = f.running[host]
+	}
+	fail := f.fail[strURL]
+	f.mu.Unlock()
+	f.startedC <- strURL
+
+	defer func() {
+		f.mu.Lock()
+		f.running[host]--
+		f.mu.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		if f.hold != nil {
+			<-f.hold
+		}
+		return idownload.FileInfo{}, ctx.Err()
+	case <-f.release:
+	}
+	if fail {
+		return idownload.FileInfo{}, errors.New("boom")
+	}
+	return idownload.FileInfo{SourceUrl: strURL, Path: filename}, nil
+}
+
+func (f *fakeDownloader) CleanPartFiles(filename string) error {
+	f.mu.Lock()
+	f.cleaned = append(f.cleaned, filename)
+	f.mu.Unlock()
+	return nil
+}
+
+func waitState(t *testing.T, c *Component, id string, state State) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ := c.Get(id); job.State == state {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	job, _ := c.Get(id)
+	t.Fatalf("job %s state %s, want %s", id, job.State, state)
+	return job
+}
+
+func TestPriority(t *testing.T) {
+	d := newFakeDownloader()
+	c := New(d, WithConcurrency(1))
+	defer c.Close()
+
+	first, _ := c.Enqueue("http://a/first", "first", 0)
+	<-d.startedC
+	c.Enqueue("http://a/low", "low", 1)
+	c.Enqueue("http://a/high", "high", 10)
+	c.Enqueue("http://a/low2", "low2", 1)
+	assert.Equal(t, StateRunning, waitState(t, c, first.ID, StateRunning).State)
+
+	close(d.release)
+	assert.Nil(t, c.Wait(context.Background()))
+	assert.Equal(t, []string{"http://a/first", "http://a/high", "http://a/low", "http://a/low2"}, d.started)
+
+	for _, job := range c.Jobs() {
+		assert.Equal(t, StateDone, job.State)
+		assert.Equal(t, job.Filename, job.Path)
+	}
+}
+
+func TestHostConcurrency(t *testing.T) {
+	d := newFakeDownloader()
+	c := New(d, WithConcurrency(10), WithHostConcurrency(1), WithHostLimit("b", 3))
+	defer c.Close()
+
+	for i := 0; i < 5; i++ {
+		c.Enqueue("http://a/file", "a", 0)
+		c.Enqueue("http://b/file", "b", 0)
+	}
+	for i := 0; i < 4; i++ {
+		<-d.startedC
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	d.mu.Lock()
+	assert.Len(t, d.started, 4)
+	d.mu.Unlock()
+
+	close(d.release)
+	assert.Nil(t, c.Wait(context.Background()))
+	assert.Equal(t, 1, d.maxHost["a"])
+	assert.Equal(t, 3, d.maxHost["b"])
+}
+
+func TestPauseResumeCancel(t *testing.T) {
+	d := newFakeDownloader()
+
+	var mu sync.Mutex
+	var events []Event
+	c := New(d, WithConcurrency(1), WithOnEvent(func(event Event) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer c.Close()
+
+	running, _ := c.Enqueue("http://a/1", "1", 0)
+	queued, _ := c.Enqueue("http://a/2", "2", 0)
+	<-d.startedC
+
+	// 排队中的直接暂停，下载中的停止下载
+	assert.Nil(t, c.Pause(queued.ID))
+	assert.Nil(t, c.Pause(running.ID))
+	waitState(t, c, running.ID, StatePaused)
+	waitState(t, c, queued.ID, StatePaused)
+
+	assert.Nil(t, c.Resume(running.ID))
+	<-d.startedC
+	assert.Nil(t, c.Cancel(running.ID))
+	waitState(t, c, running.ID, StateCanceled)
+	assert.Equal(t, []string{"1"}, d.cleaned)
+
+	assert.Nil(t, c.Cancel(queued.ID))
+	assert.Equal(t, StateCanceled, waitState(t, c, queued.ID, StateCanceled).State)
+	assert.ErrorIs(t, c.Pause("missing"), ErrNotFound)
+
+	mu.Lock()
+	var states []State
+	for _, event := range events {
+		if event.Job.ID == running.ID {
+			states = append(states, event.Job.State)
+		}
+	}
+	mu.Unlock()
+	assert.Equal(t, []State{StateQueued, StateRunning, StatePaused, StateQueued, StateRunning, StateCanceled}, states)
+}
+
+// TestResumeWhilePausing 暂停后下载还未停止时继续，停止后重新排队
+func TestResumeWhilePausing(t *testing.T) {
+	d := newFakeDownloader()
+	d.hold = make(chan struct{})
+	c := New(d, WithConcurrency(1))
+	defer c.Close()
+
+	job, _ := c.Enqueue("http://a/1", "1", 0)
+	<-d.startedC
+
+	assert.Nil(t, c.Pause(job.ID))
+	assert.Nil(t, c.Resume(job.ID))
+	close(d.hold)
+
+	select {
+	case <-d.startedC:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("job %s not restarted, state %s", job.ID, waitState(t, c, job.ID, StatePaused).State)
+	}
+	waitState(t, c, job.ID, StateRunning)
+}
+
+func TestFailedResume(t *testing.T) {
+	d := newFakeDownloader()
+	d.fail["http://a/1"] = true
+	close(d.release)
+
+	c := New(d)
+	defer c.Close()
+
+	job, _ := c.Enqueue("http://a/1", "1", 0)
+	assert.Equal(t, "boom", waitState(t, c, job.ID, StateFailed).Error)
+
+	d.mu.Lock()
+	d.fail["http://a/1"] = false
+	d.mu.Unlock()
+	assert.Nil(t, c.Resume(job.ID))
+	waitState(t, c, job.ID, StateDone)
+
+	assert.Nil(t, c.Remove(job.ID))
+	assert.Empty(t, c.Jobs())
+}
+
+func TestPersist(t *testing.T) {
+	store := mem.NewLRU(100, 0)
+	d := newFakeDownloader()
+
+	c := New(d, WithConcurrency(1), WithStore(store))
+	running, _ := c.Enqueue("http://a/1", "1", 0)
+	queued, _ := c.Enqueue("http://a/2", "2", 5)
+	<-d.startedC
+	paused, _ := c.Enqueue("http://a/3", "3", 0)
+	assert.Nil(t, c.Pause(paused.ID))
+	assert.Nil(t, c.Close())
+
+	// 重启：下载中的重新排队，暂停的保持暂停
+	c = New(newFakeDownloader(), WithConcurrency(1), WithStore(store))
+	defer c.Close()
+
+	jobs := c.Jobs()
+	assert.Len(t, jobs, 3)
+	assert.Equal(t, queued.ID, jobs[0].ID)
+	job, _ := c.Get(paused.ID)
+	assert.Equal(t, StatePaused, job.State)
+	job, _ = c.Get(running.ID)
+	assert.Contains(t, []State{StateQueued, StateRunning}, job.State)
+
+	next, _ := c.Enqueue("http://a/4", "4", 0)
+	assert.Greater(t, next.Seq, paused.Seq)
+}
+
+func TestDownload(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(r.URL.Path))
+	}))
+	defer s.Close()
+
+	dir := t.TempDir()
+	c := New(idownload.New(idownload.WithRetryAttempt(0)))
+	defer c.Close()
+
+	var ids []string
+	for _, name := range []string{"a", "b", "c"} {
+		job, err := c.Enqueue(s.URL+"/"+name, filepath.Join(dir, name), 0)
+		assert.Nil(t, err)
+		ids = append(ids, job.ID)
+	}
+	assert.Nil(t, c.Wait(context.Background()))
+
+	for i, name := range []string{"a", "b", "c"} {
+		job, _ := c.Get(ids[i])
+		assert.Equal(t, StateDone, job.State)
+		data, _ := os.ReadFile(job.Path)
+		assert.Equal(t, "/"+name, string(data))
+	}
+}
+
+func TestProgressEvent(t *testing.T) {
+	content := strings.Repeat("x", 64*1024)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+	}))
+	defer s.Close()
+
+	var (
+		mu     sync.Mutex
+		events []Event
+	)
+	c := New(idownload.New(idownload.WithRetryAttempt(0), idownload.WithConcurrency(4)), WithOnEvent(func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}))
+	defer c.Close()
+
+	job, err := c.Enqueue(s.URL+"/file", filepath.Join(t.TempDir(), "file"), 0)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Wait(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	var finished *idownload.Progress
+	for _, event := range events {
+		if event.Progress != nil {
+			assert.Equal(t, StateRunning, event.Job.State)
+			assert.Equal(t, event.From, event.Job.State)
+			if event.Progress.Finished {
+				finished = event.Progress
+			}
+		}
+	}
+	if assert.NotNil(t, finished) {
+		assert.Nil(t, finished.Err)
+		assert.Equal(t, int64(len(content)), finished.Done)
+		assert.Len(t, finished.Parts, 4)
+	}
+	// 最后一个事件是状态变化
+	assert.Nil(t, events[len(events)-1].Progress)
+	assert.Equal(t, StateDone, events[len(events)-1].Job.State)
+
+	job, _ = c.Get(job.ID)
+	assert.Equal(t, int64(len(content)), job.Done)
+	assert.Equal(t, int64(len(content)), job.Total)
+}
@@ -0,0 +1,222 @@
+package idownload
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/syntax/ijson"
+)
+
+// ErrRemoteChanged 续传过程中远程文件发生变化（ETag / Last-Modified / 长度不一致），已下载的分片作废
+var ErrRemoteChanged = errors.New("remote file changed")
+
+// manifestSuffix 分片下载清单文件后缀，与目标文件同目录
+const manifestSuffix = ".idownload.json"
+
+// remoteInfo HEAD 得到的远程文件信息
+type remoteInfo struct {
+	ContentLength int
+	ETag          string
+	LastModified  string
+	Checksum      Checksum // Content-MD5 / Digest
+}
+
+func newRemoteInfo(header http.Header) remoteInfo {
+	contentLength, _ := strconv.Atoi(header.Get("Content-Length"))
+	return remoteInfo{
+		ContentLength: contentLength,
+		ETag:          header.Get("ETag"),
+		LastModified:  header.Get("Last-Modified"),
+		Checksum:      headerChecksum(header),
+	}
+}
+
+// ifRange 续传请求的 If-Range，远程文件变化时服务端返回 200 整个文件而不是 206
+// 弱 ETag 不能用于 If-Range
+func (r remoteInfo) ifRange() string {
+	if len(r.ETag) > 0 && !strings.HasPrefix(r.ETag, "W/") {
+		return r.ETag
+	}
+	return r.LastModified
+}
+
+// ManifestPart 一个分片，对应文件 getPartFilename(filename, Index)
+type ManifestPart struct {
+	Index int
+	Start int // 包含
+	End   int // 包含
+	Done  int // 已下载字节数
+}
+
+func (p ManifestPart) Len() int {
+	return p.End - p.Start + 1
+}
+
+// Manifest 分片下载清单，进程重启后据此继续下载
+type Manifest struct {
+	Url           string
+	ETag          string
+	LastModified  string
+	ContentLength int
+	Parts         []ManifestPart
+	UpdatedAt     time.Time
+
+	mu       sync.Mutex
+	filename string
+}
+
+// ReadManifest 读取 filename 的下载清单，不存在时返回 os.ErrNotExist
+func ReadManifest(filename string) (*Manifest, error) {
+	m := &Manifest{filename: filename}
+	if err := ijson.ReadFile(getManifestFilename(filename), m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// newManifest 按 concurrency 切分
+func newManifest(strURL, filename string, remote remoteInfo, concurrency int) *Manifest {
+	m := &Manifest{
+		Url:           strURL,
+		ETag:          remote.ETag,
+		LastModified:  remote.LastModified,
+		ContentLength: remote.ContentLength,
+		filename:      filename,
+	}
+
+	partSize := remote.ContentLength / concurrency
+	rangeStart := 0
+	for i := 0; i < concurrency; i++ {
+		rangeEnd := rangeStart + partSize - 1
+		if i == concurrency-1 {
+			rangeEnd = remote.ContentLength - 1
+		}
+		m.Parts = append(m.Parts, ManifestPart{Index: i, Start: rangeStart, End: rangeEnd})
+		rangeStart = rangeEnd + 1
+	}
+	return m
+}
+
+// Completed 已下载字节数
+func (m *Manifest) Completed() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	completed := 0
+	for _, part := range m.Parts {
+		completed += part.Done
+	}
+	return completed
+}
+
+// match 远程文件是否还是清单记录的那个；没有 ETag / Last-Modified 时只能比较长度
+func (m *Manifest) match(remote remoteInfo) bool {
+	if m.ContentLength != remote.ContentLength || len(m.Parts) == 0 {
+		return false
+	}
+	if len(m.ETag) > 0 || len(remote.ETag) > 0 {
+		return m.ETag == remote.ETag
+	}
+	return m.LastModified == remote.LastModified
+}
+
+// sync 以分片文件实际大小为准，清单中的 Done 可能落后于磁盘
+func (m *Manifest) sync(d *Component) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, part := range m.Parts {
+		partFilename := d.getPartFilename(m.filename, part.Index)
+		stat, err := os.Stat(partFilename)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+			m.Parts[i].Done = 0
+			continue
+		}
+
+		done := int(stat.Size())
+		if done > part.Len() {
+			// 超出分片长度说明文件已损坏，从头下载该分片
+			if err := os.Truncate(partFilename, 0); err != nil {
+				return err
+			}
+			done = 0
+		}
+		m.Parts[i].Done = done
+	}
+	return nil
+}
+
+// setDone 更新分片进度并保存
+func (m *Manifest) setDone(i int, done int) error {
+	m.mu.Lock()
+	m.Parts[i].Done = done
+	m.mu.Unlock()
+	return m.save()
+}
+
+// save 先写临时文件再重命名，进程中途退出不会留下损坏的清单
+func (m *Manifest) save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.UpdatedAt = time.Now()
+	data, err := ijson.Encode(m)
+	if err != nil {
+		return err
+	}
+
+	manifestFilename := getManifestFilename(m.filename)
+	tmp := manifestFilename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0664); err != nil {
+		return err
+	}
+	return os.Rename(tmp, manifestFilename)
+}
+
+// loadManifest 读取并校验清单：
+//   - 清单不存在：新建，残留的分片文件从头覆盖
+//   - 远程文件已变化：删除旧分片，新建
+//   - 其他：按分片文件大小继续
+func (d *Component) loadManifest(strURL, filename string, remote remoteInfo) (*Manifest, error) {
+	if d.config.Resume {
+		m, err := ReadManifest(filename)
+		switch {
+		case err == nil && m.match(remote):
+			if err := m.sync(d); err != nil {
+				return nil, err
+			}
+			m.Url = strURL
+			if d.config.Debug {
+				log.Println("继续下载", strURL, "已完成：", m.Completed(), "/", m.ContentLength)
+			}
+			return m, m.save()
+		case err == nil:
+			log.Println("远程文件已变化，重新下载", strURL)
+			if err := d.CleanPartFiles(filename); err != nil {
+				return nil, err
+			}
+		case !os.IsNotExist(err):
+			log.Println("清单文件损坏，重新下载", strURL, err)
+		}
+	}
+
+	m := newManifest(strURL, filename, remote, d.config.Concurrency)
+	if !d.config.Resume {
+		return m, nil
+	}
+	return m, m.save()
+}
+
+// getManifestFilename 清单文件名
+func getManifestFilename(filename string) string {
+	return filename + manifestSuffix
+}
@@ -0,0 +1,167 @@
+package idownload
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeServer 支持 Range / If-Range，cut > 0 时每个请求只返回 cut 字节后断开，模拟下载中断
+type rangeServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	content []byte
+	etag    string
+	cut     int64
+	round   int64
+	ranges  []string // 本轮请求的 Range
+}
+
+func newRangeServer(t *testing.T, size int) *rangeServer {
+	s := &rangeServer{}
+	s.setContent(size)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		content, etag := s.content, s.etag
+		if r.Method == http.MethodGet {
+			s.ranges = append(s.ranges, r.Header.Get("Range"))
+		}
+		s.mu.Unlock()
+
+		w.Header().Set("ETag", etag)
+		http.ServeContent(&cutWriter{ResponseWriter: w, left: atomic.LoadInt64(&s.cut)}, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *rangeServer) setContent(size int) {
+	content := make([]byte, size)
+	rand.Read(content)
+
+	s.mu.Lock()
+	s.content = content
+	s.etag = fmt.Sprintf(`"%x"`, content[:8])
+	s.mu.Unlock()
+}
+
+// nextRound 重新记录 Range
+func (s *rangeServer) nextRound(cut int64) {
+	atomic.StoreInt64(&s.cut, cut)
+	s.mu.Lock()
+	s.ranges = nil
+	s.mu.Unlock()
+}
+
+type cutWriter struct {
+	http.ResponseWriter
+	left int64
+}
+
+func (w *cutWriter) Write(p []byte) (int, error) {
+	if w.left > 0 {
+		if int64(len(p)) > w.left {
+			p = p[:w.left]
+		}
+		w.left -= int64(len(p))
+		if w.left == 0 {
+			n, _ := w.ResponseWriter.Write(p)
+			return n, errors.New("cut")
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func newResumeDownloader() *Component {
+	return New(WithConcurrency(4), WithRetryAttempt(0), WithTimeout(10*time.Second))
+}
+
+func TestDownloadResumeAfterRestart(t *testing.T) {
+	s := newRangeServer(t, 1<<20)
+	filename := filepath.Join(t.TempDir(), "file.bin")
+
+	// 第一次运行：分片最多下载 100KB，其中一个失败后其余分片被取消
+	s.nextRound(100 << 10)
+	_, err := newResumeDownloader().Download(s.URL, filename)
+	require.Error(t, err)
+
+	m, err := ReadManifest(filename)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, s.etag, m.ETag)
+	assert.Len(t, m.Parts, 4)
+	assert.Greater(t, m.Completed(), 0)
+
+	// 重启后继续，只下载剩余部分
+	s.nextRound(0)
+	info, err := newResumeDownloader().Download(s.URL, filename)
+	assert.Nil(t, err)
+	assert.Equal(t, filename, info.Path)
+
+	// 已下载的分片从断点继续
+	for _, part := range m.Parts {
+		if part.Done > 0 {
+			assert.Contains(t, s.ranges, fmt.Sprintf("bytes=%d-%d", part.Start+part.Done, part.End))
+			assert.NotContains(t, s.ranges, fmt.Sprintf("bytes=%d-%d", part.Start, part.End))
+		}
+	}
+
+	data, _ := os.ReadFile(filename)
+	assert.True(t, bytes.Equal(s.content, data))
+
+	_, err = os.Stat(getManifestFilename(filename))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(filepath.Dir(filename), "file.bin-0"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadResumeRemoteChanged(t *testing.T) {
+	s := newRangeServer(t, 1<<20)
+	filename := filepath.Join(t.TempDir(), "file.bin")
+
+	s.nextRound(100 << 10)
+	_, err := newResumeDownloader().Download(s.URL, filename)
+	assert.NotNil(t, err)
+
+	// 远程文件变化，已下载的分片不能拼接到新文件上
+	s.setContent(1 << 20)
+	s.nextRound(0)
+	_, err = newResumeDownloader().Download(s.URL, filename)
+	assert.Nil(t, err)
+
+	data, _ := os.ReadFile(filename)
+	assert.True(t, bytes.Equal(s.content, data))
+}
+
+func TestDownloadResumeIfRange(t *testing.T) {
+	s := newRangeServer(t, 1<<20)
+	filename := filepath.Join(t.TempDir(), "file.bin")
+
+	s.nextRound(100 << 10)
+	_, err := newResumeDownloader().Download(s.URL, filename)
+	assert.NotNil(t, err)
+
+	// HEAD 之后远程文件才变化，续传请求的 If-Range 不匹配
+	d := newResumeDownloader()
+	remote := remoteInfo{ContentLength: len(s.content), ETag: s.etag}
+	s.setContent(1 << 20)
+	s.nextRound(0)
+
+	_, err = d.multiDownload(t.Context(), s.URL, filename, remote, &DownloadOptions{})
+	assert.ErrorIs(t, err, ErrRemoteChanged)
+	_, err = os.Stat(getManifestFilename(filename))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,291 @@
+package idownload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DownloadMirrors 从多个镜像下载同一个文件：
+//   - HEAD 探测所有地址，按延迟排序，长度、ETag、校验值与最快的不一致的镜像不使用
+//   - 分片模式下不同分片从不同镜像下载，某个镜像失败后分片从已下载的位置换到其他镜像继续
+//   - 不支持分片时按延迟依次尝试
+//
+// ctx 与超时同 DownloadContext，opts 为单个下载的参数
+func (d *Component) DownloadMirrors(parent context.Context, urls []string, filename string, opts ...DownloadOption) (FileInfo, error) {
+	if len(urls) == 0 {
+		return FileInfo{}, ErrorUrl
+	}
+	mirrorURLs := make([]string, 0, len(urls))
+	for _, strURL := range urls {
+		strURL = strings.TrimSpace(strURL)
+		if !strings.Contains(strURL, "http") {
+			return FileInfo{}, errors.New("Url 不合法：" + strURL)
+		}
+		mirrorURLs = append(mirrorURLs, strURL)
+	}
+
+	if d.config.Debug {
+		log.Println("下载地址：", mirrorURLs, "保存地址：", filename)
+	}
+	if err := d.validFileContentLength(mirrorURLs[0]); err != nil {
+		return FileInfo{}, err
+	}
+	if filename == "" {
+		filename = path.Base(mirrorURLs[0])
+	}
+
+	ctx, cancel := d.downloadContext(parent)
+	defer cancel()
+	return d.downloadMirrors(ctx, mirrorURLs, filename, newDownloadOptions(opts))
+}
+
+// mirror 一个下载地址及探测结果
+type mirror struct {
+	url     string // 跳转后的地址
+	origin  string // 传入的地址，ValidatorCache 以它为 key
+	latency time.Duration
+	remote  remoteInfo
+	ranges  bool // 支持 Range
+
+	failed atomic.Bool
+}
+
+// mirrorSet 按延迟排序的镜像，第一个最快；失败的镜像本次下载不再使用
+type mirrorSet struct {
+	mirrors []*mirror
+}
+
+func newMirrorSet(mirrors ...*mirror) *mirrorSet {
+	return &mirrorSet{mirrors: mirrors}
+}
+
+// primary 最快的镜像，清单和校验以它为准
+func (s *mirrorSet) primary() *mirror {
+	return s.mirrors[0]
+}
+
+// ranged 支持 Range 的镜像
+func (s *mirrorSet) ranged() *mirrorSet {
+	ranged := &mirrorSet{}
+	for _, m := range s.mirrors {
+		if m.ranges {
+			ranged.mirrors = append(ranged.mirrors, m)
+		}
+	}
+	return ranged
+}
+
+// pick 分片 i 轮流分配到各个镜像，跳过失败的镜像，都失败时返回 nil
+func (s *mirrorSet) pick(i int) *mirror {
+	for k := range s.mirrors {
+		m := s.mirrors[(i+k)%len(s.mirrors)]
+		if !m.failed.Load() {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *mirrorSet) fail(m *mirror, err error) {
+	if len(s.mirrors) > 1 {
+		log.Println("镜像下载失败，切换镜像", m.url, err)
+	}
+	m.failed.Store(true)
+}
+
+// downloadMirrors 探测镜像后下载，失败按 RetryAttempt 重试，重试前重新探测
+//   - 条件请求只发给第一个有记录的地址，本地文件是上次下载的且它返回 304 时直接返回
+//   - 下载成功后保存所有一致镜像的 ETag / Last-Modified
+func (d *Component) downloadMirrors(ctx context.Context, urls []string, filename string, o *DownloadOptions) (FileInfo, error) {
+	for _, strURL := range urls {
+		if _, ok := d.loadValidator(strURL); ok {
+			if info, ok := d.notModified(ctx, strURL, filename); ok {
+				return info, nil
+			}
+			break
+		}
+	}
+
+	var used *mirrorSet
+	download := func() (FileInfo, error) {
+		mirrors, err := d.probeMirrors(ctx, urls)
+		if err != nil {
+			return FileInfo{}, err
+		}
+		used = mirrors
+		if ranged := mirrors.ranged(); d.config.Concurrency > 0 && len(ranged.mirrors) > 0 {
+			return d.multiDownloadMirrors(ctx, ranged, filename, o)
+		}
+		return d.singleDownloadMirrors(ctx, mirrors, filename, o)
+	}
+
+	fileInfo, errResp := download()
+	if errResp != nil && !errors.Is(errResp, ErrorNotFound) && d.config.RetryAttempt > 0 {
+		log.Println("下载失败：错误：", urls, errResp, "开始重试：", d.config.RetryAttempt)
+		NewRetry(d.config.RetryAttempt, d.config.RetryWaitTime).Func(func() error {
+			if fileInfo, errResp = download(); errResp != nil {
+				return ErrRetry
+			}
+			return nil
+		}).Do(ctx)
+	}
+
+	if errResp != nil {
+		log.Println("下载失败：错误：", urls, errResp)
+		return fileInfo, errResp
+	}
+	log.Println("下载成功", fileInfo.SourceUrl, fileInfo.Path)
+	for _, m := range used.mirrors {
+		d.saveValidator(m.origin, m.remote, fileInfo)
+	}
+	return fileInfo, nil
+}
+
+// probeMirrors 并发 HEAD 所有地址，按延迟排序；与最快的镜像内容不一致的丢弃
+func (d *Component) probeMirrors(ctx context.Context, urls []string) (*mirrorSet, error) {
+	var (
+		wg      sync.WaitGroup
+		mirrors = make([]*mirror, len(urls))
+		errs    = make([]error, len(urls))
+	)
+	for i, strURL := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mirrors[i], errs[i] = d.probeMirror(ctx, strURL)
+		}()
+	}
+	wg.Wait()
+
+	var ok []*mirror
+	for _, m := range mirrors {
+		if m != nil {
+			ok = append(ok, m)
+		}
+	}
+	if len(ok) == 0 {
+		return nil, fmt.Errorf("没有可用的镜像: %w", errors.Join(errs...))
+	}
+	sort.SliceStable(ok, func(i, j int) bool { return ok[i].latency < ok[j].latency })
+
+	set := newMirrorSet(ok[0])
+	for _, m := range ok[1:] {
+		if !consistentRemote(ok[0].remote, m.remote) {
+			log.Println("镜像内容不一致，不使用", m.url, m.remote.ContentLength, m.remote.ETag, "最快的镜像", ok[0].url, ok[0].remote.ContentLength, ok[0].remote.ETag)
+			continue
+		}
+		set.mirrors = append(set.mirrors, m)
+	}
+	if d.config.Debug {
+		for _, m := range set.mirrors {
+			log.Println("镜像", m.url, "延迟", m.latency, "分片", m.ranges)
+		}
+	}
+	return set, nil
+}
+
+// probeMirror HEAD 一个地址，跟随跳转，记录延迟和远程文件信息
+func (d *Component) probeMirror(ctx context.Context, strURL string) (*mirror, error) {
+	iClient := d.getGoHttpClient(strURL, "HEAD").Client()
+	req, err := http.NewRequestWithContext(ctx, "HEAD", strURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range d.getHttpHeader() {
+		req.Header.Add(key, fmt.Sprintf("%v", value))
+	}
+
+	start := time.Now()
+	resp, err := iClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrorNotFound, strURL)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: %s %d", ErrorHead, strURL, resp.StatusCode)
+	}
+
+	remote := newRemoteInfo(resp.Header)
+	return &mirror{
+		url:     resp.Request.URL.String(),
+		origin:  strURL,
+		latency: time.Since(start),
+		remote:  remote,
+		ranges:  resp.Header.Get("Accept-Ranges") == "bytes" && remote.ContentLength > 0,
+	}, nil
+}
+
+// consistentRemote 长度相同，双方都有 ETag / 校验值时也要相同
+func consistentRemote(a, b remoteInfo) bool {
+	if a.ContentLength != b.ContentLength {
+		return false
+	}
+	for _, pair := range [][2]string{
+		{a.ETag, b.ETag},
+		{a.Checksum.Md5, b.Checksum.Md5},
+		{a.Checksum.Sha256, b.Checksum.Sha256},
+	} {
+		if len(pair[0]) > 0 && len(pair[1]) > 0 && pair[0] != pair[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// downloadPartMirrors 从分配给分片的镜像下载，失败后换下一个可用的镜像，从分片文件已有的位置继续
+// 续传的 If-Range 使用各自镜像的 ETag / Last-Modified
+func (d *Component) downloadPartMirrors(ctx context.Context, mirrors *mirrorSet, filename string, part ManifestPart, progress *progressTracker, o *DownloadOptions) error {
+	done := part.Done
+	var err error
+	for m := mirrors.pick(part.Index); m != nil; m = mirrors.pick(part.Index) {
+		isAppend := done > 0
+		ifRange := ""
+		if isAppend {
+			ifRange = m.remote.ifRange()
+		}
+		err = d.downloadPartial(ctx, m.url, filename, part.Start+done, part.End, part.Index, isAppend, ifRange, progress.writer(part.Index, io.Discard), o.Bandwidth)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		mirrors.fail(m, err)
+
+		stat, statErr := os.Stat(d.getPartFilename(filename, part.Index))
+		switch {
+		case statErr == nil:
+			done = int(stat.Size())
+		case os.IsNotExist(statErr):
+			done = 0
+		default:
+			return err
+		}
+	}
+	return err
+}
+
+// singleDownloadMirrors 按延迟依次尝试
+func (d *Component) singleDownloadMirrors(ctx context.Context, mirrors *mirrorSet, filename string, o *DownloadOptions) (FileInfo, error) {
+	var err error
+	for m := mirrors.pick(0); m != nil; m = mirrors.pick(0) {
+		var info FileInfo
+		if info, err = d.singleDownload(ctx, m.url, filename, o); err == nil || ctx.Err() != nil {
+			return info, err
+		}
+		mirrors.fail(m, err)
+	}
+	return FileInfo{}, err
+}
@@ -0,0 +1,152 @@
+package idownload
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+// mirrorServer 同一份内容的镜像，delay 模拟延迟，cut > 0 时每个 GET 只返回 cut 字节后断开
+type mirrorServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func newMirrorServer(t *testing.T, content []byte, etag string, delay time.Duration, cut int64) *mirrorServer {
+	s := &mirrorServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			time.Sleep(delay)
+		} else {
+			s.mu.Lock()
+			s.ranges = append(s.ranges, r.Header.Get("Range"))
+			s.mu.Unlock()
+		}
+		w.Header().Set("ETag", etag)
+		http.ServeContent(&cutWriter{ResponseWriter: w, left: cut}, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *mirrorServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ranges...)
+}
+
+func newMirrorContent(size int) []byte {
+	content := make([]byte, size)
+	rand.Read(content)
+	return content
+}
+
+func TestDownloadMirrorsFallback(t *testing.T) {
+	content := newMirrorContent(1 << 20)
+	fast := newMirrorServer(t, content, `"v1"`, 0, 0)
+	// 延迟稍高，分到的分片下载 100KB 后失败
+	flaky := newMirrorServer(t, content, `"v1"`, 50*time.Millisecond, 100<<10)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	filename := filepath.Join(t.TempDir(), "file.bin")
+	d := New(WithConcurrency(4), WithRetryAttempt(0), WithTimeout(10*time.Second))
+	info, err := d.DownloadMirrors(context.Background(), []string{down.URL, flaky.URL, fast.URL}, filename)
+	assert.Nil(t, err)
+	assert.Equal(t, fast.URL, info.SourceUrl)
+
+	data, _ := os.ReadFile(filename)
+	assert.True(t, bytes.Equal(content, data))
+
+	// 奇数分片分到 flaky，失败后从已下载的位置换到 fast
+	assert.NotEmpty(t, flaky.requests())
+	m := newManifest(fast.URL, filename, remoteInfo{ContentLength: len(content)}, 4)
+	for _, part := range m.Parts {
+		if part.Index%2 == 1 {
+			assert.Contains(t, fast.requests(), fmt.Sprintf("bytes=%d-%d", part.Start+100<<10, part.End))
+		}
+	}
+}
+
+func TestDownloadMirrorsInconsistent(t *testing.T) {
+	content := newMirrorContent(256 << 10)
+	fast := newMirrorServer(t, content, `"v1"`, 0, 0)
+	other := newMirrorServer(t, content, `"v2"`, 50*time.Millisecond, 0)
+	shorter := newMirrorServer(t, content[:1000], `"v1"`, 50*time.Millisecond, 0)
+
+	filename := filepath.Join(t.TempDir(), "file.bin")
+	d := New(WithConcurrency(4), WithRetryAttempt(0))
+	_, err := d.DownloadMirrors(context.Background(), []string{fast.URL, other.URL, shorter.URL}, filename)
+	assert.Nil(t, err)
+
+	// ETag、长度不一致的镜像不下载
+	assert.Empty(t, other.requests())
+	assert.Empty(t, shorter.requests())
+	assert.Len(t, fast.requests(), 4)
+}
+
+func TestDownloadMirrorsUnavailable(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	d := New(WithConcurrency(4), WithRetryAttempt(0))
+	_, err := d.DownloadMirrors(context.Background(), []string{notFound.URL, notFound.URL + "/b"}, filepath.Join(t.TempDir(), "file.bin"))
+	assert.True(t, errors.Is(err, ErrorNotFound))
+}
+
+func TestSingleDownloadMirrors(t *testing.T) {
+	content := newMirrorContent(64 << 10)
+	broken := newMirrorServer(t, content, "", 0, 1000)
+	ok := newMirrorServer(t, content, "", 50*time.Millisecond, 0)
+
+	filename := filepath.Join(t.TempDir(), "file.bin")
+	d := New(WithConcurrency(0), WithRetryAttempt(0))
+	info, err := d.DownloadMirrors(context.Background(), []string{broken.URL, ok.URL}, filename)
+	assert.Nil(t, err)
+	assert.Equal(t, ok.URL, info.SourceUrl)
+
+	data, _ := os.ReadFile(filename)
+	assert.True(t, bytes.Equal(content, data))
+}
+
+func TestDownloadMirrorsNotModified(t *testing.T) {
+	content := newMirrorContent(64 << 10)
+	fast := newMirrorServer(t, content, `"v1"`, 0, 0)
+	slow := newMirrorServer(t, content, `"v1"`, 50*time.Millisecond, 0)
+
+	filename := filepath.Join(t.TempDir(), "file.bin")
+	d := New(WithConcurrency(4), WithRetryAttempt(0), WithValidatorCache(mem.NewLRU(100, 0)))
+	info, err := d.DownloadMirrors(context.Background(), []string{slow.URL, fast.URL}, filename)
+	assert.Nil(t, err)
+	assert.False(t, info.NotModified)
+	gets := len(fast.requests()) + len(slow.requests())
+
+	// 本地文件是上次下载的，远程没有变化时不重新下载
+	info, err = d.DownloadMirrors(context.Background(), []string{slow.URL, fast.URL}, filename)
+	assert.Nil(t, err)
+	assert.True(t, info.NotModified)
+	assert.Equal(t, filename, info.Path)
+	assert.Equal(t, gets, len(fast.requests())+len(slow.requests()))
+
+	// 本地文件被修改，重新下载
+	os.WriteFile(filename, []byte("changed"), 0666)
+	info, err = d.DownloadMirrors(context.Background(), []string{slow.URL, fast.URL}, filename)
+	assert.Nil(t, err)
+	assert.False(t, info.NotModified)
+	data, _ := os.ReadFile(filename)
+	assert.True(t, bytes.Equal(content, data))
+}
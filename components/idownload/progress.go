@@ -0,0 +1,217 @@
+package idownload
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/syntax/irate"
+)
+
+// PartState 分片状态
+type PartState string
+
+const (
+	PartPending PartState = "pending"
+	PartRunning PartState = "running"
+	PartDone    PartState = "done"
+	PartFailed  PartState = "failed"
+)
+
+// PartProgress 分片进度，单线程下载只有一个分片，m3u8 每个媒体分片一个
+type PartProgress struct {
+	Index int
+	Done  int64
+	Total int64 // -1 未知
+	State PartState
+}
+
+// Progress 下载进度
+type Progress struct {
+	Url      string
+	Filename string
+	Done     int64
+	Total    int64         // -1 未知
+	Speed    int64         // 字节/秒，平滑后的速度
+	Eta      time.Duration // 预计剩余时间，未知时为 -1
+	Parts    []PartProgress
+	Finished bool  // 最后一次事件
+	Err      error // Finished 时下载失败的原因
+}
+
+// ProgressFunc 进度回调，同一个下载的回调不会并发调用
+type ProgressFunc func(p Progress)
+
+// progressTracker 统计一次下载的进度，每 ProgressInterval 通知一次
+// 没有任何回调时为 nil，方法可以在 nil 上调用
+type progressTracker struct {
+	consumers []ProgressFunc
+	url       string
+	filename  string
+	total     int64
+	parts     []*partTracker
+
+	interval time.Duration
+	stop     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+
+	speed *irate.Speed
+}
+
+type partTracker struct {
+	index int
+	total int64
+	done  atomic.Int64
+	state atomic.Value
+}
+
+// Write 统计写入的字节数
+func (p *partTracker) Write(b []byte) (int, error) {
+	p.done.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// newProgress 回调来自组件配置 OnProgress、单个下载的 onProgress 和终端进度条；partTotals 为每个分片的长度，done 为已完成的字节
+func (d *Component) newProgress(onProgress ProgressFunc, strURL, filename string, total int64, partTotals []int64, done []int64) *progressTracker {
+	var consumers []ProgressFunc
+	if d.config.OnProgress != nil {
+		consumers = append(consumers, d.config.OnProgress)
+	}
+	if onProgress != nil {
+		consumers = append(consumers, onProgress)
+	}
+	if d.config.Progressbar {
+		bar := d.newBar(int(total), strURL)
+		consumers = append(consumers, func(p Progress) {
+			bar.Set64(p.Done)
+			if p.Finished && p.Err == nil {
+				bar.Finish()
+			}
+		})
+	}
+	if len(consumers) == 0 {
+		return nil
+	}
+
+	interval := d.config.ProgressInterval
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	t := &progressTracker{
+		consumers: consumers,
+		url:       strURL,
+		filename:  filename,
+		total:     total,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	var resumed int64
+	for i, partTotal := range partTotals {
+		part := &partTracker{index: i, total: partTotal}
+		part.state.Store(PartPending)
+		if i < len(done) {
+			part.done.Store(done[i])
+			resumed += done[i]
+			if partTotal >= 0 && done[i] >= partTotal {
+				part.state.Store(PartDone)
+			}
+		}
+		t.parts = append(t.parts, part)
+	}
+	t.speed = irate.NewSpeed(resumed)
+
+	go t.run()
+	return t
+}
+
+func (t *progressTracker) run() {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.emit(t.snapshot(false, nil))
+		}
+	}
+}
+
+// writer 分片 i 写入时同时统计进度
+func (t *progressTracker) writer(i int, w io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return io.MultiWriter(w, t.parts[i])
+}
+
+// setState 修改分片 i 的状态
+func (t *progressTracker) setState(i int, state PartState) {
+	if t == nil {
+		return
+	}
+	t.parts[i].state.Store(state)
+}
+
+// add 分片 i 增加 n 字节，用于不经过 writer 的下载，如 m3u8 分片
+func (t *progressTracker) add(i int, n int64) {
+	if t == nil {
+		return
+	}
+	t.parts[i].done.Add(n)
+}
+
+// finish 停止定时通知，发送最后一次事件
+func (t *progressTracker) finish(err error) {
+	if t == nil {
+		return
+	}
+	t.once.Do(func() {
+		close(t.stop)
+		<-t.stopped
+		t.emit(t.snapshot(true, err))
+	})
+}
+
+func partState(err error) PartState {
+	if err != nil {
+		return PartFailed
+	}
+	return PartDone
+}
+
+func (t *progressTracker) snapshot(finished bool, err error) Progress {
+	p := Progress{
+		Url:      t.url,
+		Filename: t.filename,
+		Total:    t.total,
+		Eta:      -1,
+		Finished: finished,
+		Err:      err,
+	}
+	for _, part := range t.parts {
+		state := part.state.Load().(PartState)
+		pp := PartProgress{Index: part.index, Done: part.done.Load(), Total: part.total, State: state}
+		p.Done += pp.Done
+		p.Parts = append(p.Parts, pp)
+	}
+
+	p.Speed = t.speed.Update(p.Done)
+	if finished && err == nil {
+		p.Eta = 0
+	} else if t.total > 0 {
+		p.Eta = t.speed.Eta(t.total - p.Done)
+	}
+	return p
+}
+
+func (t *progressTracker) emit(p Progress) {
+	for _, consumer := range t.consumers {
+		consumer(p)
+	}
+}
@@ -0,0 +1,99 @@
+package idownload
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// progressRecorder 记录进度事件
+type progressRecorder struct {
+	mu     sync.Mutex
+	events []Progress
+}
+
+func (r *progressRecorder) record(p Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, p)
+}
+
+func (r *progressRecorder) last() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[len(r.events)-1]
+}
+
+func TestProgress(t *testing.T) {
+	s := newRangeServer(t, 1<<20)
+	filename := filepath.Join(t.TempDir(), "file.bin")
+
+	var global, single progressRecorder
+	d := New(WithConcurrency(4), WithRetryAttempt(0), WithOnProgress(global.record), WithProgressInterval(10*time.Millisecond))
+	_, err := d.DownloadContext(context.Background(), s.URL, filename, WithProgress(single.record))
+	assert.Nil(t, err)
+
+	for _, r := range []*progressRecorder{&global, &single} {
+		p := r.last()
+		assert.True(t, p.Finished)
+		assert.Nil(t, p.Err)
+		assert.Equal(t, filename, p.Filename)
+		assert.Equal(t, int64(1<<20), p.Total)
+		assert.Equal(t, int64(1<<20), p.Done)
+		assert.Equal(t, time.Duration(0), p.Eta)
+		assert.Len(t, p.Parts, 4)
+		for _, part := range p.Parts {
+			assert.Equal(t, PartDone, part.State)
+			assert.Equal(t, part.Total, part.Done)
+		}
+	}
+
+	// 只有最后一个事件 Finished
+	for _, p := range global.events[:len(global.events)-1] {
+		assert.False(t, p.Finished)
+	}
+}
+
+func TestProgressFailed(t *testing.T) {
+	s := newRangeServer(t, 1<<20)
+	filename := filepath.Join(t.TempDir(), "file.bin")
+
+	s.nextRound(100 << 10)
+	var r progressRecorder
+	_, err := New(WithConcurrency(4), WithRetryAttempt(0), WithOnProgress(r.record)).Download(s.URL, filename)
+	assert.NotNil(t, err)
+
+	p := r.last()
+	assert.True(t, p.Finished)
+	assert.NotNil(t, p.Err)
+	assert.Less(t, p.Done, p.Total)
+
+	var failed int
+	for _, part := range p.Parts {
+		if part.State == PartFailed {
+			failed++
+		}
+	}
+	assert.Greater(t, failed, 0)
+
+	// 继续下载时已完成的字节计入进度
+	s.nextRound(0)
+	var resumed progressRecorder
+	_, err = New(WithConcurrency(4), WithRetryAttempt(0), WithOnProgress(resumed.record)).Download(s.URL, filename)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1<<20), resumed.last().Done)
+}
+
+func TestProgressDisabled(t *testing.T) {
+	d := New()
+	assert.Nil(t, d.newProgress(nil, "", "", 0, []int64{0}, nil))
+
+	var tracker *progressTracker
+	tracker.setState(0, PartRunning)
+	tracker.add(0, 1)
+	tracker.finish(nil)
+}
@@ -0,0 +1,250 @@
+package idownload
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Stream 流式下载的响应体，不落盘也不整体读入内存：
+//   - 读取中断时按 RetryAttempt 重试，带 Range 从已读取的位置继续，If-Range 保证远程文件没有变化
+//   - 服务端不支持 Range 时跳过已读取的部分
+//   - 读取到 Size 字节时校验期望值（WithChecksum）和响应头中的校验值，不一致时返回 ErrChecksumMismatch
+type Stream struct {
+	Size   int64       // Content-Length，-1 未知
+	Header http.Header // 第一次响应的响应头
+
+	d        *Component
+	ctx      context.Context
+	opts     *DownloadOptions
+	url      string
+	ifRange  string
+	body     io.ReadCloser
+	reader   io.Reader
+	offset   int64
+	retries  int
+	err      error // 结束后 Read 返回的错误，正常结束为 io.EOF
+	progress *progressTracker
+
+	expected Checksum
+	header   Checksum
+	md5      hash.Hash
+	sha256   hash.Hash
+}
+
+// OpenStream 请求 strURL，返回响应体，调用方负责 Close；opts 为单个下载的参数
+func (d *Component) OpenStream(ctx context.Context, strURL string, opts ...DownloadOption) (*Stream, error) {
+	strURL = strings.TrimSpace(strURL)
+	if !strings.Contains(strURL, "http") {
+		return nil, errors.New("Url 不合法：" + strURL)
+	}
+
+	s := &Stream{d: d, ctx: ctx, opts: newDownloadOptions(opts), url: strURL, Size: -1}
+	if err := s.connect(nil); err != nil {
+		return nil, err
+	}
+	if d.config.FileMax != -1 && s.Size > int64(d.config.FileMax) {
+		s.body.Close()
+		return nil, fmt.Errorf("链接：%s 未下载，大小：%s, 超过设置大小: %s",
+			strURL,
+			humanize.Bytes(uint64(s.Size)),
+			humanize.Bytes(uint64(d.config.FileMax)),
+		)
+	}
+
+	s.expected, s.header = s.opts.Checksum, headerChecksum(s.Header)
+	if !s.expected.empty() || !s.header.empty() {
+		s.md5, s.sha256 = md5.New(), sha256.New()
+	}
+	s.progress = d.newProgress(s.opts.OnProgress, strURL, "", s.Size, []int64{s.Size}, nil)
+	s.progress.setState(0, PartRunning)
+	return s, nil
+}
+
+// DownloadToWriter 流式下载写入 w，返回写入的字节数
+func (d *Component) DownloadToWriter(ctx context.Context, strURL string, w io.Writer, opts ...DownloadOption) (int64, error) {
+	s, err := d.OpenStream(ctx, strURL, opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+	return io.CopyBuffer(w, s, make([]byte, 32*1024))
+}
+
+// Read 读取中断时从已读取的位置重新请求，对调用方透明
+func (s *Stream) Read(p []byte) (int, error) {
+	for {
+		if s.err != nil {
+			return 0, s.err
+		}
+
+		n, err := s.reader.Read(p)
+		s.offset += int64(n)
+		s.progress.add(0, int64(n))
+		if s.md5 != nil {
+			s.md5.Write(p[:n])
+			s.sha256.Write(p[:n])
+		}
+
+		switch {
+		case s.Size >= 0 && s.offset >= s.Size, s.Size < 0 && errors.Is(err, io.EOF):
+			s.finish(s.check())
+			return n, s.err
+		case err == nil:
+			return n, nil
+		}
+
+		// 长度已知时提前 EOF 也是中断
+		s.body.Close()
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err = s.connect(err); err != nil {
+			s.finish(err)
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// Close 关闭响应体，没有读完时进度事件以 context.Canceled 结束
+func (s *Stream) Close() error {
+	if s.err == nil {
+		s.finish(context.Canceled)
+	}
+	return s.body.Close()
+}
+
+// finish 记录结束原因，发送最后一次进度事件
+func (s *Stream) finish(err error) {
+	s.err = err
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	s.progress.setState(0, partState(err))
+	s.progress.finish(err)
+}
+
+// check 读取结束时校验长度和校验值，通过时返回 io.EOF
+func (s *Stream) check() error {
+	if s.Size >= 0 && s.offset != s.Size {
+		return fmt.Errorf("%w: 读取 %d 字节，Content-Length %d", ErrChecksumMismatch, s.offset, s.Size)
+	}
+	if s.md5 == nil {
+		return io.EOF
+	}
+	md5Sum, sha256Sum := hex.EncodeToString(s.md5.Sum(nil)), hex.EncodeToString(s.sha256.Sum(nil))
+	for _, sum := range []Checksum{s.expected, s.header} {
+		if len(sum.Md5) > 0 && !strings.EqualFold(sum.Md5, md5Sum) {
+			return fmt.Errorf("%w: md5 %s，期望 %s", ErrChecksumMismatch, md5Sum, sum.Md5)
+		}
+		if len(sum.Sha256) > 0 && !strings.EqualFold(sum.Sha256, sha256Sum) {
+			return fmt.Errorf("%w: sha256 %s，期望 %s", ErrChecksumMismatch, sha256Sum, sum.Sha256)
+		}
+	}
+	return io.EOF
+}
+
+// connect 从 offset 开始请求，cause 不为空时表示上一次请求或读取失败，按 RetryAttempt 等待后重试
+func (s *Stream) connect(cause error) error {
+	for {
+		if cause != nil {
+			if err := s.ctx.Err(); err != nil {
+				return err
+			}
+			if errors.Is(cause, ErrorNotFound) || errors.Is(cause, ErrRemoteChanged) || s.retries >= s.d.config.RetryAttempt {
+				return cause
+			}
+			s.retries++
+			log.Println(PackageName, "读取中断，从", s.offset, "继续：", s.url, cause)
+
+			timer := time.NewTimer(s.d.config.RetryWaitTime)
+			select {
+			case <-s.ctx.Done():
+				timer.Stop()
+				return s.ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if cause = s.get(); cause == nil {
+			return nil
+		}
+	}
+}
+
+// get 请求 offset 之后的内容；返回 200 时跳过已读取的部分，带 If-Range 时返回 200 说明远程文件已变化
+func (s *Stream) get() error {
+	iClient := s.d.getGoHttpClient(s.url, "GET").Client()
+	req, err := http.NewRequestWithContext(s.ctx, "GET", s.url, nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range s.d.getHttpHeader() {
+		req.Header.Add(key, fmt.Sprintf("%v", value))
+	}
+	// 不压缩，偏移量才是原始字节
+	req.Header.Set("Accept-Encoding", "identity")
+	if s.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", s.offset))
+		if len(s.ifRange) > 0 {
+			req.Header.Set("If-Range", s.ifRange)
+		}
+	}
+
+	resp, err := iClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return fmt.Errorf("%w: %s", ErrorNotFound, s.url)
+	case resp.StatusCode == http.StatusPartialContent && s.offset > 0:
+		if start := contentRangeStart(resp.Header.Get("Content-Range")); start != s.offset {
+			resp.Body.Close()
+			return fmt.Errorf("%s Content-Range %s，期望从 %d 开始", s.url, resp.Header.Get("Content-Range"), s.offset)
+		}
+	case resp.StatusCode == http.StatusOK && s.offset > 0:
+		if len(s.ifRange) > 0 {
+			resp.Body.Close()
+			return ErrRemoteChanged
+		}
+		if _, err := io.CopyN(io.Discard, resp.Body, s.offset); err != nil {
+			resp.Body.Close()
+			return err
+		}
+	case resp.StatusCode == http.StatusOK:
+		s.Size, s.Header = resp.ContentLength, resp.Header
+		s.ifRange = newRemoteInfo(resp.Header).ifRange()
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("%s error: %d", s.url, resp.StatusCode)
+	}
+
+	s.body = resp.Body
+	s.reader = s.d.limitReader(s.ctx, resp.Body, s.opts.Bandwidth)
+	return nil
+}
+
+// contentRangeStart 解析 Content-Range: bytes 100-199/200 的起始位置，不合法时返回 -1
+func contentRangeStart(contentRange string) int64 {
+	var start, end int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d", &start, &end); err != nil {
+		return -1
+	}
+	return start
+}
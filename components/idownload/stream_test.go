@@ -0,0 +1,88 @@
+package idownload
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStreamDownloader() *Component {
+	return New(WithRetryAttempt(20), WithRetryWaitTime(time.Millisecond))
+}
+
+func TestDownloadToWriterResume(t *testing.T) {
+	s := newRangeServer(t, 1<<20)
+	s.nextRound(100 << 10)
+
+	var buf bytes.Buffer
+	n, err := newStreamDownloader().DownloadToWriter(context.Background(), s.URL, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1<<20), n)
+	assert.True(t, bytes.Equal(s.content, buf.Bytes()))
+
+	// 中断后从已读取的位置继续
+	assert.Equal(t, "", s.ranges[0])
+	assert.Contains(t, s.ranges, "bytes=102400-")
+}
+
+func TestDownloadToWriterWithoutRange(t *testing.T) {
+	content := newMirrorContent(256 << 10)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 不支持 Range，每次返回整个文件，第一次只写出 100KB
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		if requests.Add(1) == 1 {
+			(&cutWriter{ResponseWriter: w, left: 100 << 10}).Write(content)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	_, err := newStreamDownloader().DownloadToWriter(context.Background(), server.URL, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+	// 跳过已读取的部分，写入的数据是连续的
+	assert.True(t, bytes.Equal(content, buf.Bytes()))
+}
+
+func TestStreamRemoteChanged(t *testing.T) {
+	s := newRangeServer(t, 1<<20)
+	s.nextRound(100 << 10)
+
+	stream, err := newStreamDownloader().OpenStream(context.Background(), s.URL)
+	assert.Nil(t, err)
+	defer stream.Close()
+	assert.Equal(t, int64(1<<20), stream.Size)
+
+	_, err = io.ReadFull(stream, make([]byte, 100<<10))
+	assert.Nil(t, err)
+
+	s.setContent(1 << 20)
+	_, err = io.ReadAll(stream)
+	assert.True(t, errors.Is(err, ErrRemoteChanged))
+}
+
+func TestStreamChecksum(t *testing.T) {
+	s := newRangeServer(t, 64<<10)
+	sum := md5.Sum(s.content)
+
+	d := newStreamDownloader()
+	_, err := d.DownloadToWriter(context.Background(), s.URL, io.Discard, WithChecksum(Checksum{Md5: hex.EncodeToString(sum[:])}))
+	assert.Nil(t, err)
+
+	_, err = d.DownloadToWriter(context.Background(), s.URL, io.Discard, WithChecksum(Checksum{Md5: "00"}))
+	assert.True(t, errors.Is(err, ErrChecksumMismatch))
+}
@@ -0,0 +1,112 @@
+package idownload
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cute-angelia/go-xutils/syntax/ifile"
+)
+
+// ErrChecksumMismatch 下载的文件与期望的长度或校验值不一致，文件已删除，按失败重试
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Checksum 文件校验值，十六进制，为空的不校验
+type Checksum struct {
+	Md5    string
+	Sha256 string
+}
+
+func (c Checksum) empty() bool {
+	return len(c.Md5) == 0 && len(c.Sha256) == 0
+}
+
+// headerChecksum 响应头中的校验值：Content-MD5、Digest（RFC 3230）、Content-Digest / Repr-Digest（RFC 9530）
+func headerChecksum(header http.Header) Checksum {
+	var sum Checksum
+	if v := header.Get("Content-MD5"); len(v) > 0 {
+		sum.Md5 = base64ToHex(v)
+	}
+	for _, name := range []string{"Digest", "Repr-Digest", "Content-Digest"} {
+		for _, item := range strings.Split(header.Get(name), ",") {
+			algorithm, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if !ok {
+				continue
+			}
+			value = base64ToHex(strings.Trim(value, ":"))
+			switch strings.ToLower(algorithm) {
+			case "md5":
+				sum.Md5 = value
+			case "sha-256":
+				sum.Sha256 = value
+			}
+		}
+	}
+	return sum
+}
+
+func base64ToHex(v string) string {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// verify 校验下载完成的文件：
+//   - contentLength >= 0 时校验文件长度
+//   - 期望值 expected（WithChecksum）或响应头中有校验值，或开启 Verify 时计算 md5 / sha256 并比较
+//
+// 不一致时删除文件，返回 ErrChecksumMismatch
+func (d *Component) verify(info *FileInfo, contentLength int64, expected, header Checksum) error {
+	err := d.checksum(info, contentLength, expected, header)
+	if errors.Is(err, ErrChecksumMismatch) {
+		os.Remove(info.Path)
+	}
+	return err
+}
+
+func (d *Component) checksum(info *FileInfo, contentLength int64, expected, header Checksum) error {
+	stat, err := os.Stat(info.Path)
+	if err != nil {
+		return err
+	}
+	info.Size = stat.Size()
+	if contentLength >= 0 && info.Size != contentLength {
+		return fmt.Errorf("%w: 文件长度 %d，Content-Length %d", ErrChecksumMismatch, info.Size, contentLength)
+	}
+
+	if !d.config.Verify && expected.empty() && header.empty() {
+		return nil
+	}
+
+	f, err := os.Open(info.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var md5Hash hash.Hash = md5.New()
+	info.Sha256, err = ifile.FileHashSha256(io.TeeReader(f, md5Hash))
+	if err != nil {
+		return err
+	}
+	info.Md5 = hex.EncodeToString(md5Hash.Sum(nil))
+
+	for _, sum := range []Checksum{expected, header} {
+		if len(sum.Md5) > 0 && !strings.EqualFold(sum.Md5, info.Md5) {
+			return fmt.Errorf("%w: md5 %s，期望 %s", ErrChecksumMismatch, info.Md5, sum.Md5)
+		}
+		if len(sum.Sha256) > 0 && !strings.EqualFold(sum.Sha256, info.Sha256) {
+			return fmt.Errorf("%w: sha256 %s，期望 %s", ErrChecksumMismatch, info.Sha256, sum.Sha256)
+		}
+	}
+	return nil
+}
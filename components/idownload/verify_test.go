@@ -0,0 +1,95 @@
+package idownload
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderChecksum(t *testing.T) {
+	md5sum := md5.Sum([]byte("hello"))
+	shasum := sha256.Sum256([]byte("hello"))
+
+	header := http.Header{}
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5sum[:]))
+	header.Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(shasum[:])+":")
+	assert.Equal(t, Checksum{Md5: hex.EncodeToString(md5sum[:]), Sha256: hex.EncodeToString(shasum[:])}, headerChecksum(header))
+
+	header = http.Header{}
+	header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(shasum[:])+", unixsum=30637")
+	assert.Equal(t, Checksum{Sha256: hex.EncodeToString(shasum[:])}, headerChecksum(header))
+	assert.True(t, headerChecksum(http.Header{}).empty())
+}
+
+func TestDownloadVerify(t *testing.T) {
+	content := strings.Repeat("idownload", 10000)
+	md5sum := md5.Sum([]byte(content))
+	shasum := sha256.Sum256([]byte(content))
+
+	// 第一次请求返回损坏的内容
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(md5sum[:]))
+		body := content
+		if r.Method == http.MethodGet && atomic.AddInt32(&requests, 1) == 1 {
+			body = strings.ToUpper(content)
+		}
+		w.Write([]byte(body))
+	}))
+	defer s.Close()
+
+	dir := t.TempDir()
+
+	d := New(WithRetryAttempt(0))
+	_, err := d.Download(s.URL, filepath.Join(dir, "a"))
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	_, err = os.Stat(filepath.Join(dir, "a"))
+	assert.True(t, os.IsNotExist(err))
+
+	// 校验失败按失败重试
+	atomic.StoreInt32(&requests, 0)
+	d = New(WithRetryAttempt(2), WithRetryWaitTime(time.Millisecond))
+	info, err := d.Download(s.URL, filepath.Join(dir, "b"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), info.Size)
+	assert.Equal(t, hex.EncodeToString(md5sum[:]), info.Md5)
+	assert.Equal(t, hex.EncodeToString(shasum[:]), info.Sha256)
+
+	// 期望值
+	expected := WithChecksum(Checksum{Sha256: strings.ToUpper(hex.EncodeToString(shasum[:]))})
+	_, err = New(WithRetryAttempt(0)).DownloadContext(context.Background(), s.URL, filepath.Join(dir, "c"), expected)
+	assert.Nil(t, err)
+
+	_, err = New(WithRetryAttempt(0)).DownloadContext(context.Background(), s.URL, filepath.Join(dir, "d"), WithChecksum(Checksum{Sha256: "00"}))
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestMultiDownloadVerify(t *testing.T) {
+	s := newRangeServer(t, 256*1024)
+	dir := t.TempDir()
+
+	d := New(WithConcurrency(4), WithRetryAttempt(0), WithVerify(true))
+	info, err := d.Download(s.URL, filepath.Join(dir, "a"))
+	assert.Nil(t, err)
+
+	shasum := sha256.Sum256(s.content)
+	assert.Equal(t, hex.EncodeToString(shasum[:]), info.Sha256)
+	assert.Equal(t, int64(len(s.content)), info.Size)
+
+	_, err = d.DownloadContext(context.Background(), s.URL, filepath.Join(dir, "b"), WithChecksum(Checksum{Md5: "00"}))
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	_, err = os.Stat(filepath.Join(dir, "b"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -2,6 +2,7 @@ package ifileutil
 
 import (
 	"github.com/cute-angelia/go-xutils/syntax/istrings"
+	"io/fs"
 	"io/ioutil"
 	"path"
 	"path/filepath"
@@ -84,6 +85,53 @@ func (self *Component) GetFileList() (dirPaths []string, filePaths []string, err
 	return
 }
 
+// Dir 根目录
+func (self *Component) Dir() string {
+	return self.config.Dir
+}
+
+// Match 相对 Dir 的路径是否通过过滤，每一级目录都需要通过目录过滤，与遍历时一致
+func (self *Component) Match(relPath string) bool {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if self.isBlackDir(dir) {
+			return false
+		}
+	}
+	return !self.isBlackExt(path.Ext(relPath))
+}
+
+// Walk 递归遍历 Dir 下通过过滤的普通文件，relPath 为 / 分隔的相对路径；被排除的文件夹不会进入
+func (self *Component) Walk(f func(relPath string, info fs.FileInfo) error) error {
+	root := filepath.Clean(self.config.Dir)
+	return filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == root {
+			return nil
+		}
+		if entry.IsDir() {
+			if self.isBlackDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || self.isBlackExt(path.Ext(entry.Name())) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		return f(filepath.ToSlash(relPath), info)
+	})
+}
+
 // IsEmptyDir 检查是否为空文件夹
 func (self *Component) IsEmptyDir() bool {
 	s, _ := ioutil.ReadDir(self.config.Dir)
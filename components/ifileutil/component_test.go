@@ -0,0 +1,33 @@
+package ifileutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.jpg", "b.txt", "img/c.JPG", "img/deep/d.jpg", "tmp/e.jpg"} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.Nil(t, os.WriteFile(filename, []byte(name), 0644))
+	}
+
+	c := New(WithDir(dir), WithDirDeclude([]string{"tmp"}), WithExtInclude([]string{".jpg"}))
+	var files []string
+	err := c.Walk(func(relPath string, info fs.FileInfo) error {
+		files = append(files, relPath)
+		assert.Equal(t, int64(len(relPath)), info.Size())
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a.jpg", "img/c.JPG", "img/deep/d.jpg"}, files)
+
+	assert.True(t, c.Match("img/deep/d.jpg"))
+	assert.False(t, c.Match("tmp/e.jpg"))
+	assert.False(t, c.Match("b.txt"))
+}
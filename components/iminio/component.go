@@ -16,11 +16,12 @@ import (
 	"time"
 
 	"github.com/cute-angelia/go-xutils/components/idownload"
-	"github.com/cute-angelia/go-xutils/syntax/ifile"
+	"github.com/cute-angelia/go-xutils/syntax/irate"
 	"github.com/cute-angelia/go-xutils/utils/generator/hash"
 	progress "github.com/markity/minio-progress"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"golang.org/x/sync/singleflight"
 )
 
 type Component struct {
@@ -28,6 +29,10 @@ type Component struct {
 	config *config
 	locker sync.Mutex
 	Client *minio.Client
+	// 组件限速，所有上传共享
+	bandwidth *irate.Bandwidth
+	// 同一列表索引只遍历一次
+	listGroup singleflight.Group
 }
 
 // newComponent ...
@@ -40,12 +45,27 @@ func newComponent(config *config) *Component {
 		log.Println("发生错误" + err.Error())
 	}
 	return &Component{
-		name:   PackageName,
-		config: config,
-		Client: minioClient,
+		name:      PackageName,
+		config:    config,
+		Client:    minioClient,
+		bandwidth: irate.NewBandwidth(config.RateLimit),
 	}
 }
 
+// SetRateLimit 运行中修改上传限速，单位 字节/秒，0 不限速
+func (e *Component) SetRateLimit(bytesPerSecond int64) {
+	e.bandwidth.SetLimit(bytesPerSecond)
+}
+
+// limitReader 依次受全局 irate.GlobalBandwidth、组件 RateLimit 和单个上传 transfer（WithBandwidth）的限速
+// 不限速时原样返回，保留 *os.File 的 io.ReaderAt，minio 可以并发上传分片
+func (e *Component) limitReader(ctx context.Context, r io.Reader, transfer *irate.Bandwidth) io.Reader {
+	if !irate.Limited(irate.GlobalBandwidth, e.bandwidth, transfer) {
+		return r
+	}
+	return irate.NewReader(ctx, r, irate.GlobalBandwidth, e.bandwidth, transfer)
+}
+
 func (e *Component) GetUrl(bucket, key string, opts ...UrlOption) string {
 	if key == "" {
 		//log.Println("errors.New( key is empty )")
@@ -134,6 +154,8 @@ func (e *Component) GenerateHashKey(bucketType int32, bucket string, prefix stri
 // 1.分页
 // 2.可以指定文件后缀获取
 // 建議在文件上傳到 MinIO 時，文件名數字部分補零（例如：第009話），這樣 MinIO 默認的字典序就會是正確的自然排序，你原本的流式分頁代碼就能直接運行。
+//
+// Deprecated: 每页都从头列举，页数越大越慢；使用 ListObjects（游标）或 ListObjectsPage（页码 + 总数）
 func (e *Component) GetObjectsByPage(bucket string, prefix string, page int32, perpage int32, fileExt []string) (objs []string, notall bool) {
 	count := int32(0)
 	offset := (page - 1) * perpage
@@ -212,12 +234,20 @@ func (e *Component) CheckMode(objectName string) (newObjectName string, canuploa
 // CopyObject 复制对象
 func (e *Component) CopyObject(dst minio.CopyDestOptions, src minio.CopySrcOptions) (uploadInfo minio.UploadInfo, err error) {
 	uploadInfo, err = e.Client.CopyObject(context.Background(), dst, src)
+	if err == nil {
+		e.invalidateList(dst.Bucket, dst.Object)
+	}
 	return
 }
 
 // PutObject 上传-按读取文件数据
 // PutObject：流式上传时用 pipe，避免整体读入内存
 func (e *Component) PutObject(bucket string, objectNameIn string, reader io.Reader, objectSize int64, objopt minio.PutObjectOptions) (minio.UploadInfo, error) {
+	return e.PutObjectContext(context.Background(), bucket, objectNameIn, reader, objectSize, objopt)
+}
+
+// PutObjectContext 同 PutObject，ctx 可以取消上传，opts 为单个上传的参数，如 WithBandwidth
+func (e *Component) PutObjectContext(parent context.Context, bucket string, objectNameIn string, reader io.Reader, objectSize int64, objopt minio.PutObjectOptions, opts ...UploadOption) (minio.UploadInfo, error) {
 	objectName, ok := e.CheckMode(objectNameIn)
 	if !ok {
 		return minio.UploadInfo{}, fmt.Errorf("模式未设置 %s", objectNameIn)
@@ -244,14 +274,15 @@ func (e *Component) PutObject(bucket string, objectNameIn string, reader io.Read
 	}
 
 	// ✅ 统一用带超时的 context
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(parent, 10*time.Minute)
 	defer cancel()
 
-	uploadInfo, err := e.Client.PutObject(ctx, bucket, objectName, reader, objectSize, objopt)
+	uploadInfo, err := e.Client.PutObject(ctx, bucket, objectName, e.limitReader(ctx, reader, newUploadOptions(opts).Bandwidth), objectSize, objopt)
 	if err != nil {
 		log.Println("Upload Failed:", bucket, objectNameIn, err)
 		return uploadInfo, err
 	}
+	e.invalidateList(bucket, objectName)
 	if e.config.Debug {
 		log.Printf("Successfully uploaded: %s/%s, Size: %d\n", bucket, objectName, uploadInfo.Size)
 	}
@@ -260,6 +291,11 @@ func (e *Component) PutObject(bucket string, objectNameIn string, reader io.Read
 
 // FPutObject：加上超时 context
 func (e *Component) FPutObject(bucket string, objectNameIn string, filePath string, objopt minio.PutObjectOptions) (minio.UploadInfo, error) {
+	return e.FPutObjectContext(context.Background(), bucket, objectNameIn, filePath, objopt)
+}
+
+// FPutObjectContext 同 FPutObject，ctx 可以取消上传，opts 为单个上传的参数，如 WithBandwidth
+func (e *Component) FPutObjectContext(parent context.Context, bucket string, objectNameIn string, filePath string, objopt minio.PutObjectOptions, opts ...UploadOption) (minio.UploadInfo, error) {
 	objectName, ok := e.CheckMode(objectNameIn)
 	if !ok {
 		return minio.UploadInfo{}, fmt.Errorf("模式未设置 %s", objectNameIn)
@@ -279,13 +315,14 @@ func (e *Component) FPutObject(bucket string, objectNameIn string, filePath stri
 	objopt.Progress = progress.NewUploadProgress(fileInfo.Size())
 
 	// ✅ 加超时，避免大文件永久阻塞
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(parent, 10*time.Minute)
 	defer cancel()
 
-	uploadInfo, err := e.Client.PutObject(ctx, bucket, objectName, file, fileInfo.Size(), objopt)
+	uploadInfo, err := e.Client.PutObject(ctx, bucket, objectName, e.limitReader(ctx, file, newUploadOptions(opts).Bandwidth), fileInfo.Size(), objopt)
 	if err != nil {
 		return uploadInfo, fmt.Errorf("上传失败: %w", err)
 	}
+	e.invalidateList(bucket, objectName)
 	if e.config.Debug {
 		log.Println("Successfully uploaded bytes: ", uploadInfo)
 	}
@@ -313,10 +350,11 @@ func (e *Component) PutObjectBase64(bucket string, objectNameIn string, base64Fi
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	uploadInfo, err := e.Client.PutObject(ctx, bucket, objectName, decodedReader, -1, objopt)
+	uploadInfo, err := e.Client.PutObject(ctx, bucket, objectName, e.limitReader(ctx, decodedReader, nil), -1, objopt)
 	if err != nil {
 		return minio.UploadInfo{}, fmt.Errorf("base64 上传失败: %w", err)
 	}
+	e.invalidateList(bucket, objectName)
 	if e.config.Debug {
 		log.Println("Successfully uploaded bytes: ", uploadInfo)
 	}
@@ -324,6 +362,8 @@ func (e *Component) PutObjectBase64(bucket string, objectNameIn string, base64Fi
 }
 
 // PutObjectWithSrc 提供链接，上传到 minio
+// 响应体直接流式上传，不经过临时文件；读取中断时按 dnComponent 的 RetryAttempt 用 Range 继续
+// 整体超时 10 分钟，需要自定义超时或取消时使用 PutObjectFromUrl
 // return key & hash sha1 & error
 func (e *Component) PutObjectWithSrc(dnComponent *idownload.Component, uri string, bucket string, objectName string, objopt minio.PutObjectOptions) (string, error) {
 	if !strings.Contains(uri, "http") {
@@ -332,38 +372,50 @@ func (e *Component) PutObjectWithSrc(dnComponent *idownload.Component, uri strin
 
 	objectName = strings.ReplaceAll(objectName, "//", "/")
 
-	tempname := ifile.NewFileName(uri).GetNameSnowFlow()
-	if _, err := dnComponent.Download(uri, tempname); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	stream, err := dnComponent.OpenStream(ctx, uri)
+	if err != nil {
 		if e.config.Debug {
 			log.Println(PackageName, "获取文件失败：❌", uri, err)
 		}
 		return "", fmt.Errorf("获取文件失败：❌ %s  %w", uri, err)
 	}
-	defer os.Remove(tempname) // 下载成功后，函数退出时清理
+	defer stream.Close()
 
-	file, err := os.OpenFile(tempname, os.O_RDONLY, 0444)
-	if err != nil {
-		return "", fmt.Errorf("打开文件失败: %w", err)
+	if stream.Size > 0 {
+		objopt.Progress = progress.NewUploadProgress(stream.Size)
+	} else if objopt.PartSize == 0 {
+		objopt.PartSize = 32 * 1024 * 1024
 	}
-	defer file.Close()
 
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return "", fmt.Errorf("获取文件信息失败: %w", err)
-	}
-
-	objopt.Progress = progress.NewUploadProgress(fileInfo.Size())
-
-	info, err := e.Client.PutObject(context.TODO(), bucket, objectName, file, fileInfo.Size(), objopt)
+	info, err := e.Client.PutObject(ctx, bucket, objectName, e.limitReader(ctx, stream, nil), stream.Size, objopt)
 	if err != nil {
 		log.Println(PackageName, "上传失败：❌", err, bucket, objectName, uri)
 		return "", fmt.Errorf("上传失败：❌ %w, %s %s %s", err, bucket, objectName, uri)
 	}
+	e.invalidateList(bucket, info.Key)
 
 	log.Println(PackageName, "上传成功：✅", uri, bucket+"/"+info.Key)
 	return bucket + "/" + info.Key, nil
 }
 
+// PutObjectFromUrl 同 PutObjectContext，内容来自链接，流式上传；
+// 没有设置 ContentType 时使用响应的 Content-Type
+func (e *Component) PutObjectFromUrl(ctx context.Context, dnComponent *idownload.Component, uri string, bucket string, objectNameIn string, objopt minio.PutObjectOptions, opts ...UploadOption) (minio.UploadInfo, error) {
+	stream, err := dnComponent.OpenStream(ctx, uri)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("获取文件失败：❌ %s  %w", uri, err)
+	}
+	defer stream.Close()
+
+	if len(objopt.ContentType) == 0 {
+		objopt.ContentType = stream.Header.Get("Content-Type")
+	}
+	return e.PutObjectContext(ctx, bucket, objectNameIn, stream, stream.Size, objopt, opts...)
+}
+
 // DeleteObject ✅ 统一使用 log，移除 fmt.Printf
 func (e *Component) DeleteObject(objectNameWithBucket string) error {
 	decodedPath, err := url.PathUnescape(objectNameWithBucket)
@@ -382,6 +434,7 @@ func (e *Component) DeleteObject(objectNameWithBucket string) error {
 		log.Printf("%s 删除对象失败：❌ Bucket:%s; Object:%s; 原因：%v\n", PackageName, bucket, objectName, err)
 		return err
 	}
+	e.invalidateList(bucket, objectName)
 
 	log.Printf("%s 删除对象成功：✅ Bucket:%s; Object:%s\n", PackageName, bucket, objectName)
 	return nil
@@ -429,6 +482,7 @@ func (e *Component) DeleteFolder(objectNameWithBucket string) error {
 		log.Printf("%s 批量删除中发生错误: Object:%s, Error:%v\n", PackageName, err.ObjectName, err.Err)
 		hasError = true
 	}
+	e.invalidateList(bucket, folderPrefix)
 
 	if hasError {
 		return fmt.Errorf("partially failed to delete folder: %s", folderPrefix)
@@ -450,6 +504,7 @@ func (e *Component) DeleteObjectWithBucketAndKey(bucket, key string) error {
 		log.Println(PackageName, "删除对象失败：❌", fmt.Sprintf("Bucket:%s; Object:%s; 失败原因：", bucket, key), err)
 		return err
 	}
+	e.invalidateList(bucket, key)
 
 	log.Println(PackageName, "删除对象成功：✅", fmt.Sprintf("Bucket:%s; Object:%s", bucket, key))
 	return nil
@@ -1,6 +1,12 @@
 package iminio
 
-import "time"
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+)
 
 // config options
 type config struct {
@@ -17,6 +23,13 @@ type config struct {
 	ReplaceMode int // 替换模式， 1跳过， 2覆盖  3保留两者
 
 	Referer string // Referer
+
+	RateLimit int64 // 上传限速 字节/秒，0 不限速；全局限速见 irate.GlobalBandwidth，单个上传见 WithBandwidth
+
+	ListCache    caches.Cache  `json:"-"` // ListObjectsPage 的索引缓存，为空时每次完整遍历
+	ListCacheTTL time.Duration // 索引有效期
+
+	UploadStateDir string // FPutObjectMultipart 保存续传状态的目录
 }
 
 const (
@@ -28,8 +41,10 @@ const (
 // DefaultConfig 返回默认配置
 func DefaultConfig() *config {
 	return &config{
-		UseSSL:      false,
-		Debug:       false,
-		ReplaceMode: 2,
+		UseSSL:         false,
+		Debug:          false,
+		ReplaceMode:    2,
+		ListCacheTTL:   10 * time.Minute,
+		UploadStateDir: filepath.Join(os.TempDir(), "iminio-multipart"),
 	}
 }
@@ -4,6 +4,8 @@ import (
 	"log"
 	"strings"
 	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
 )
 
 type Option func(c *Container)
@@ -68,6 +70,34 @@ func WithReferer(Referer string) Option {
 	}
 }
 
+// WithRateLimit 上传限速 字节/秒，运行中可以 SetRateLimit 修改
+func WithRateLimit(bytesPerSecond int64) Option {
+	return func(c *Container) {
+		c.config.RateLimit = bytesPerSecond
+	}
+}
+
+// WithListCache ListObjectsPage 使用的索引缓存
+func WithListCache(cache caches.Cache) Option {
+	return func(c *Container) {
+		c.config.ListCache = cache
+	}
+}
+
+// WithListCacheTTL 索引有效期，默认 10 分钟
+func WithListCacheTTL(ttl time.Duration) Option {
+	return func(c *Container) {
+		c.config.ListCacheTTL = ttl
+	}
+}
+
+// WithUploadStateDir 分片上传续传状态的保存目录，默认在系统临时目录下
+func WithUploadStateDir(dir string) Option {
+	return func(c *Container) {
+		c.config.UploadStateDir = dir
+	}
+}
+
 // New options 模式
 func New(options ...Option) *Component {
 	c := &Container{
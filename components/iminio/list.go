@@ -0,0 +1,374 @@
+package iminio
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/utils/generator/hash"
+	"github.com/minio/minio-go/v7"
+)
+
+// ListIndexBucket 列表索引在 caches.Cache 中的 bucket 前缀，索引头按 minio bucket 分开保存，见 listIndexBucket
+const ListIndexBucket = "iminio:list"
+
+const (
+	defaultListLimit = 100
+	// indexChunkSize 索引按块保存，翻页只读取需要的块
+	indexChunkSize = 1000
+)
+
+// ErrInvalidCursor cursor 无法解析，或与本次的 bucket / prefix / 过滤条件不一致
+var ErrInvalidCursor = errors.New("iminio: invalid cursor")
+
+// ListFilter 过滤条件，零值不过滤；在服务端遍历时过滤，不返回给调用方
+type ListFilter struct {
+	Exts           []string  // 扩展名，如 .jpg，不区分大小写
+	Keyword        string    // 文件名包含，不区分大小写
+	MinSize        int64     // 最小字节数
+	MaxSize        int64     // 最大字节数，0 不限制
+	ModifiedAfter  time.Time // 修改时间不早于
+	ModifiedBefore time.Time // 修改时间早于
+}
+
+// ListOptions 列表参数
+type ListOptions struct {
+	Prefix string
+	Cursor string // 上一页的 NextCursor，为空从头开始；ListObjectsPage 忽略
+	Limit  int    // 每页数量，默认 100
+	Filter ListFilter
+}
+
+// ListPage 一页结果
+type ListPage struct {
+	Objects    []minio.ObjectInfo
+	NextCursor string // 下一页的 cursor，为空表示没有更多
+	Total      int    // 符合条件的总数，ListObjects 不统计，为 -1
+}
+
+// cursor 编码后对调用方不透明
+type cursor struct {
+	After       string `json:"a"`
+	Fingerprint string `json:"f"`
+}
+
+// listIndex 索引头，块单独保存
+type listIndex struct {
+	Fingerprint string `json:"fp"`
+	Bucket      string `json:"bucket"`
+	Prefix      string `json:"prefix"`
+	Total       int    `json:"total"`
+	Chunks      int    `json:"chunks"`
+	Build       int64  `json:"build"` // 块 key 带上 Build，重建时不会读到旧块
+}
+
+// indexEntry 索引中保存的对象信息
+type indexEntry struct {
+	Key          string `json:"k"`
+	Size         int64  `json:"s"`
+	ETag         string `json:"e,omitempty"`
+	ContentType  string `json:"c,omitempty"`
+	LastModified int64  `json:"m"`
+}
+
+func (f ListFilter) match(obj minio.ObjectInfo) bool {
+	name := path.Base(obj.Key)
+	if len(f.Exts) > 0 {
+		ext := path.Ext(name)
+		matched := false
+		for _, e := range f.Exts {
+			if strings.EqualFold(e, ext) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.Keyword) > 0 && !strings.Contains(strings.ToLower(name), strings.ToLower(f.Keyword)) {
+		return false
+	}
+	if obj.Size < f.MinSize || (f.MaxSize > 0 && obj.Size > f.MaxSize) {
+		return false
+	}
+	if !f.ModifiedAfter.IsZero() && obj.LastModified.Before(f.ModifiedAfter) {
+		return false
+	}
+	if !f.ModifiedBefore.IsZero() && !obj.LastModified.Before(f.ModifiedBefore) {
+		return false
+	}
+	return true
+}
+
+// fingerprint 同一 bucket / prefix / 过滤条件得到相同的值
+func fingerprint(bucket string, opts ListOptions) string {
+	filter, _ := json.Marshal(opts.Filter)
+	return hash.NewEncodeMD5(fmt.Sprintf("%s:%s:%s", bucket, opts.Prefix, filter))
+}
+
+func encodeCursor(after, fp string) string {
+	data, _ := json.Marshal(cursor{After: after, Fingerprint: fp})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s, fp string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Fingerprint != fp {
+		return "", ErrInvalidCursor
+	}
+	return c.After, nil
+}
+
+// ListObjects 基于 StartAfter 的游标分页，每页只从上一页最后一个 key 之后开始列举
+// 多读一个符合条件的对象判断是否还有下一页，NextCursor 为空即最后一页；目录占位对象（以 / 结尾）不返回
+func (e *Component) ListObjects(ctx context.Context, bucket string, opts ListOptions) (ListPage, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	fp := fingerprint(bucket, opts)
+
+	after := ""
+	if len(opts.Cursor) > 0 {
+		var err error
+		if after, err = decodeCursor(opts.Cursor, fp); err != nil {
+			return ListPage{}, err
+		}
+	}
+
+	page := ListPage{Total: -1}
+	err := e.walkObjects(ctx, bucket, opts.Prefix, after, opts.Filter, func(obj minio.ObjectInfo) bool {
+		if len(page.Objects) == limit {
+			page.NextCursor = encodeCursor(page.Objects[limit-1].Key, fp)
+			return false
+		}
+		page.Objects = append(page.Objects, obj)
+		return true
+	})
+	return page, err
+}
+
+// ListObjectsPage 按页码读取，返回符合条件的总数，适合后台文件浏览
+// 配置了 WithListCache 时，首次访问遍历一次并保存索引，之后翻页直接读取索引，索引在 ListCacheTTL 后过期；
+// 通过本组件上传、复制、删除会清除相关索引，其他途径修改后可以调用 InvalidateListIndex
+// 没有配置缓存时每次都完整遍历
+func (e *Component) ListObjectsPage(ctx context.Context, bucket string, opts ListOptions, page int) (ListPage, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if page < 1 {
+		page = 1
+	}
+	fp := fingerprint(bucket, opts)
+
+	if e.config.ListCache == nil {
+		entries, err := e.buildListIndex(ctx, bucket, opts)
+		if err != nil {
+			return ListPage{}, err
+		}
+		return pageOf(entries, 0, len(entries), page, limit, fp), nil
+	}
+
+	index, err := e.listIndex(ctx, bucket, opts, fp)
+	if err != nil {
+		return ListPage{}, err
+	}
+
+	// 只读取当前页涉及的块
+	start := (page - 1) * limit
+	end := min(start+limit+1, index.Total)
+	var entries []indexEntry
+	first := start / indexChunkSize
+	for i := first; i*indexChunkSize < end; i++ {
+		chunk, err := e.indexChunk(index, i)
+		if err != nil {
+			// 块已被淘汰，本次完整遍历，下次访问重建索引
+			e.dropListIndex(index)
+			entries, err := e.buildListIndex(ctx, bucket, opts)
+			if err != nil {
+				return ListPage{}, err
+			}
+			return pageOf(entries, 0, len(entries), page, limit, fp), nil
+		}
+		entries = append(entries, chunk...)
+	}
+	return pageOf(entries, first*indexChunkSize, index.Total, page, limit, fp), nil
+}
+
+// InvalidateListIndex 清除 bucket 中 prefix 与 key 有包含关系的索引，key 为对象名或目录前缀
+func (e *Component) InvalidateListIndex(bucket, key string) error {
+	cache := e.config.ListCache
+	if cache == nil {
+		return nil
+	}
+	return cache.Scan(listIndexBucket(bucket), func(cacheKey string) error {
+		value, err := cache.Get(cacheKey)
+		if err != nil {
+			return nil
+		}
+		var index listIndex
+		if json.Unmarshal([]byte(value), &index) != nil {
+			return cache.Delete(cacheKey)
+		}
+		if strings.HasPrefix(key, index.Prefix) || strings.HasPrefix(index.Prefix, key) {
+			e.dropListIndex(index)
+		}
+		return nil
+	})
+}
+
+// invalidateList 修改对象后调用，失败只记录日志
+func (e *Component) invalidateList(bucket, key string) {
+	if err := e.InvalidateListIndex(bucket, key); err != nil {
+		log.Println(PackageName, "清除列表索引失败", bucket, key, err)
+	}
+}
+
+// walkObjects 按 key 顺序遍历符合条件的对象，f 返回 false 时停止
+func (e *Component) walkObjects(ctx context.Context, bucket, prefix, after string, filter ListFilter, f func(obj minio.ObjectInfo) bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for obj := range e.Client.ListObjects(ctx, bucket, minio.ListObjectsOptions{
+		Prefix:     prefix,
+		Recursive:  true,
+		StartAfter: after,
+	}) {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if strings.HasSuffix(obj.Key, "/") || !filter.match(obj) {
+			continue
+		}
+		if !f(obj) {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (e *Component) buildListIndex(ctx context.Context, bucket string, opts ListOptions) ([]indexEntry, error) {
+	var entries []indexEntry
+	err := e.walkObjects(ctx, bucket, opts.Prefix, "", opts.Filter, func(obj minio.ObjectInfo) bool {
+		entries = append(entries, indexEntry{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			ETag:         obj.ETag,
+			ContentType:  obj.ContentType,
+			LastModified: obj.LastModified.UnixNano(),
+		})
+		return true
+	})
+	return entries, err
+}
+
+// listIndex 读取索引头，不存在时遍历并保存；并发请求同一索引只遍历一次
+func (e *Component) listIndex(ctx context.Context, bucket string, opts ListOptions, fp string) (listIndex, error) {
+	cache := e.config.ListCache
+	headerKey := cache.GenerateCacheKey(listIndexBucket(bucket), fp)
+
+	var index listIndex
+	if value, err := cache.Get(headerKey); err == nil && json.Unmarshal([]byte(value), &index) == nil {
+		return index, nil
+	}
+
+	// 遍历结果由所有等待的请求共享，不跟随第一个请求取消
+	buildCtx := context.WithoutCancel(ctx)
+	v, err, _ := e.listGroup.Do(fp, func() (interface{}, error) {
+		entries, err := e.buildListIndex(buildCtx, bucket, opts)
+		if err != nil {
+			return listIndex{}, err
+		}
+
+		index := listIndex{
+			Fingerprint: fp,
+			Bucket:      bucket,
+			Prefix:      opts.Prefix,
+			Total:       len(entries),
+			Chunks:      (len(entries) + indexChunkSize - 1) / indexChunkSize,
+			Build:       time.Now().UnixNano(),
+		}
+		ttl := e.config.ListCacheTTL
+		for i := 0; i < index.Chunks; i++ {
+			data, _ := json.Marshal(entries[i*indexChunkSize : min((i+1)*indexChunkSize, len(entries))])
+			if err := cache.Set(e.chunkKey(index, i), string(data), ttl); err != nil {
+				return listIndex{}, err
+			}
+		}
+		// 块都写入后再写索引头
+		data, _ := json.Marshal(index)
+		if err := cache.SetWithBucket(listIndexBucket(bucket), headerKey, string(data), ttl); err != nil {
+			return listIndex{}, err
+		}
+		return index, nil
+	})
+	if err != nil {
+		return listIndex{}, err
+	}
+	return v.(listIndex), nil
+}
+
+func (e *Component) indexChunk(index listIndex, i int) ([]indexEntry, error) {
+	value, err := e.config.ListCache.Get(e.chunkKey(index, i))
+	if err != nil {
+		return nil, err
+	}
+	var entries []indexEntry
+	if err := json.Unmarshal([]byte(value), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (e *Component) dropListIndex(index listIndex) {
+	cache := e.config.ListCache
+	cache.Delete(cache.GenerateCacheKey(listIndexBucket(index.Bucket), index.Fingerprint))
+	for i := 0; i < index.Chunks; i++ {
+		cache.Delete(e.chunkKey(index, i))
+	}
+}
+
+// listIndexBucket 索引头按 minio bucket 分开保存，修改对象时只遍历该 bucket 的索引头
+func listIndexBucket(bucket string) string {
+	return ListIndexBucket + ":" + bucket
+}
+
+// chunkKey 块不放入索引头的 bucket，Scan 只遍历索引头
+func (e *Component) chunkKey(index listIndex, i int) string {
+	return e.config.ListCache.GenerateCacheKey(ListIndexBucket+":chunk", fmt.Sprintf("%s:%d:%d", index.Fingerprint, index.Build, i))
+}
+
+// pageOf entries 从第 offset 个开始，取第 page 页
+func pageOf(entries []indexEntry, offset, total, page, limit int, fp string) ListPage {
+	result := ListPage{Total: total}
+	start := (page-1)*limit - offset
+	if start < 0 || start >= len(entries) {
+		return result
+	}
+	end := min(start+limit, len(entries))
+	for _, entry := range entries[start:end] {
+		result.Objects = append(result.Objects, minio.ObjectInfo{
+			Key:          entry.Key,
+			Size:         entry.Size,
+			ETag:         entry.ETag,
+			ContentType:  entry.ContentType,
+			LastModified: time.Unix(0, entry.LastModified),
+		})
+	}
+	if offset+end < total {
+		result.NextCursor = encodeCursor(entries[end-1].Key, fp)
+	}
+	return result
+}
@@ -0,0 +1,270 @@
+package iminio
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 只实现 ListObjectsV2 / PutObject / RemoveObject，每次最多返回 pageSize 个对象
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string]int64
+	modified time.Time
+	pageSize int
+	lists    atomic.Int32
+}
+
+type fakeListResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Name                  string
+	Prefix                string
+	KeyCount              int
+	MaxKeys               int
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []fakeObject
+}
+
+type fakeObject struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int64
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	switch {
+	case query.Has("location"):
+		w.Write([]byte(`<LocationConstraint>us-east-1</LocationConstraint>`))
+	case r.Method == http.MethodPut:
+		n, _ := io.Copy(io.Discard, r.Body)
+		s.objects[key] = n
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case query.Get("list-type") == "2":
+		s.lists.Add(1)
+		after := query.Get("start-after")
+		if token := query.Get("continuation-token"); len(token) > 0 {
+			after = token
+		}
+
+		var keys []string
+		for k := range s.objects {
+			if strings.HasPrefix(k, query.Get("prefix")) && k > after {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		result := fakeListResult{Name: "bucket", Prefix: query.Get("prefix"), MaxKeys: s.pageSize}
+		if len(keys) > s.pageSize {
+			keys = keys[:s.pageSize]
+			result.IsTruncated = true
+			result.NextContinuationToken = keys[len(keys)-1]
+		}
+		for _, k := range keys {
+			result.Contents = append(result.Contents, fakeObject{
+				Key:          k,
+				LastModified: s.modified.Format("2006-01-02T15:04:05.000Z"),
+				ETag:         `"etag"`,
+				Size:         s.objects[k],
+			})
+		}
+		result.KeyCount = len(result.Contents)
+		w.Header().Set("Content-Type", "application/xml")
+		xml.NewEncoder(w).Encode(result)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func newListComponent(t *testing.T, options ...Option) (*Component, *fakeS3) {
+	s3 := &fakeS3{objects: map[string]int64{}, modified: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), pageSize: 2}
+	for i := 0; i < 10; i++ {
+		s3.objects[fmt.Sprintf("img/%02d.jpg", i)] = int64(i * 100)
+	}
+	s3.objects["img/readme.txt"] = 10
+	s3.objects["img/dir/"] = 0
+	s3.objects["other/a.jpg"] = 1
+
+	server := httptest.NewServer(s3)
+	t.Cleanup(server.Close)
+
+	options = append([]Option{
+		WithEndpoint(strings.TrimPrefix(server.URL, "http://")),
+		WithAccesskeyId("ak"),
+		WithSecretaccessKey("sk"),
+	}, options...)
+	return New(options...), s3
+}
+
+func keysOf(objects []minio.ObjectInfo) []string {
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+func TestListObjectsCursor(t *testing.T) {
+	c, _ := newListComponent(t)
+	ctx := context.Background()
+
+	opts := ListOptions{Prefix: "img/", Limit: 4, Filter: ListFilter{Exts: []string{".JPG"}}}
+	var all []string
+	for pages := 0; ; pages++ {
+		page, err := c.ListObjects(ctx, "bucket", opts)
+		assert.Nil(t, err)
+		assert.Equal(t, -1, page.Total)
+		all = append(all, keysOf(page.Objects)...)
+		if len(page.NextCursor) == 0 {
+			assert.Equal(t, 2, pages)
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	assert.Len(t, all, 10)
+	assert.Equal(t, "img/00.jpg", all[0])
+	assert.Equal(t, "img/09.jpg", all[9])
+
+	// 正好满页时最后一页没有 cursor
+	page, err := c.ListObjects(ctx, "bucket", ListOptions{Prefix: "img/", Limit: 11})
+	assert.Nil(t, err)
+	assert.Len(t, page.Objects, 11)
+	assert.Empty(t, page.NextCursor)
+
+	// cursor 与过滤条件绑定
+	opts.Filter.Exts = nil
+	_, err = c.ListObjects(ctx, "bucket", opts)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = c.ListObjects(ctx, "bucket", ListOptions{Cursor: "!!"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestListFilter(t *testing.T) {
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	obj := minio.ObjectInfo{Key: "a/Cover-01.JPG", Size: 100, LastModified: modified}
+
+	assert.True(t, ListFilter{}.match(obj))
+	assert.True(t, ListFilter{Exts: []string{".png", ".jpg"}}.match(obj))
+	assert.False(t, ListFilter{Exts: []string{".png"}}.match(obj))
+	assert.True(t, ListFilter{Keyword: "cover"}.match(obj))
+	assert.False(t, ListFilter{Keyword: "a/"}.match(obj))
+	assert.True(t, ListFilter{MinSize: 100, MaxSize: 100}.match(obj))
+	assert.False(t, ListFilter{MaxSize: 99}.match(obj))
+	assert.True(t, ListFilter{ModifiedAfter: modified, ModifiedBefore: modified.Add(time.Second)}.match(obj))
+	assert.False(t, ListFilter{ModifiedBefore: modified}.match(obj))
+}
+
+func TestListObjectsPage(t *testing.T) {
+	c, s3 := newListComponent(t, WithListCache(mem.NewLRU(100, 0)))
+	ctx := context.Background()
+	opts := ListOptions{Prefix: "img/", Limit: 3, Filter: ListFilter{MinSize: 100}}
+
+	page, err := c.ListObjectsPage(ctx, "bucket", opts, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 9, page.Total)
+	assert.Equal(t, []string{"img/04.jpg", "img/05.jpg", "img/06.jpg"}, keysOf(page.Objects))
+	assert.Equal(t, int64(400), page.Objects[0].Size)
+	assert.True(t, page.Objects[0].LastModified.Equal(s3.modified))
+	lists := s3.lists.Load()
+
+	// 命中索引，不再列举
+	page, err = c.ListObjectsPage(ctx, "bucket", opts, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"img/07.jpg", "img/08.jpg", "img/09.jpg"}, keysOf(page.Objects))
+	assert.Empty(t, page.NextCursor)
+	assert.Equal(t, lists, s3.lists.Load())
+
+	page, err = c.ListObjectsPage(ctx, "bucket", opts, 4)
+	assert.Nil(t, err)
+	assert.Empty(t, page.Objects)
+	assert.Equal(t, 9, page.Total)
+
+	// NextCursor 可以接着用游标读取
+	page, _ = c.ListObjectsPage(ctx, "bucket", opts, 1)
+	opts.Cursor = page.NextCursor
+	next, err := c.ListObjects(ctx, "bucket", opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "img/04.jpg", next.Objects[0].Key)
+	opts.Cursor = ""
+
+	// 上传后索引失效
+	_, err = c.PutObject("bucket", "img/10.jpg", strings.NewReader(strings.Repeat("x", 200)), 200, minio.PutObjectOptions{})
+	assert.Nil(t, err)
+	page, err = c.ListObjectsPage(ctx, "bucket", opts, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, page.Total)
+	assert.Greater(t, s3.lists.Load(), lists)
+
+	// 其他前缀的索引不受影响
+	_, err = c.ListObjectsPage(ctx, "bucket", ListOptions{Prefix: "other/"}, 1)
+	assert.Nil(t, err)
+	lists = s3.lists.Load()
+	assert.Nil(t, c.DeleteObjectWithBucketAndKey("bucket", "img/10.jpg"))
+	page, _ = c.ListObjectsPage(ctx, "bucket", ListOptions{Prefix: "other/"}, 1)
+	assert.Equal(t, 1, page.Total)
+	assert.Equal(t, lists, s3.lists.Load())
+
+	// 其他 bucket 的修改不影响
+	assert.Nil(t, c.InvalidateListIndex("bucket2", "other/"))
+	c.ListObjectsPage(ctx, "bucket", ListOptions{Prefix: "other/"}, 1)
+	assert.Equal(t, lists, s3.lists.Load())
+}
+
+func TestListObjectsPageChunks(t *testing.T) {
+	cache := mem.NewLRU(100, 0)
+	c, s3 := newListComponent(t, WithListCache(cache))
+	s3.pageSize = 1000
+	for i := 0; i < indexChunkSize+500; i++ {
+		s3.objects["many/"+strconv.Itoa(100000+i)] = 1
+	}
+	ctx := context.Background()
+	opts := ListOptions{Prefix: "many/", Limit: 100}
+
+	// 跨块的一页
+	page, err := c.ListObjectsPage(ctx, "bucket", opts, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, indexChunkSize+500, page.Total)
+	assert.Len(t, page.Objects, 100)
+	assert.Equal(t, "many/100900", page.Objects[0].Key)
+	assert.Equal(t, "many/100999", page.Objects[99].Key)
+
+	page, err = c.ListObjectsPage(ctx, "bucket", opts, 11)
+	assert.Nil(t, err)
+	assert.Equal(t, "many/101000", page.Objects[0].Key)
+
+	// 块被淘汰时仍然返回正确结果
+	var index listIndex
+	assert.Nil(t, cache.Scan(listIndexBucket("bucket"), func(key string) error {
+		value, _ := cache.Get(key)
+		return json.Unmarshal([]byte(value), &index)
+	}))
+	assert.Nil(t, cache.Delete(c.chunkKey(index, 1)))
+	page, err = c.ListObjectsPage(ctx, "bucket", opts, 15)
+	assert.Nil(t, err)
+	assert.Equal(t, "many/101400", page.Objects[0].Key)
+}
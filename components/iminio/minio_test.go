@@ -49,7 +49,7 @@ func TestMinioUpload(t *testing.T) {
 	//accessKeyId = "admin"
 	//secretAccessKey = "lovetwins"
 
-	log.Println(iminio.SignUrlPublic("public/th.jpeg"))
+	log.Println(iminio.GetUrl(bucket, "th.jpeg"))
 
 	fileinput := "/Users/vanilla/Downloads/th.jpeg"
 	if info, err := iminio.FPutObject(
@@ -63,7 +63,7 @@ func TestMinioUpload(t *testing.T) {
 		log.Println(info)
 	}
 
-	log.Println(iminio.SignUrlPublic("public/th2.jpeg"))
+	log.Println(iminio.GetUrl(bucket, "th2.jpeg"))
 }
 
 //
@@ -0,0 +1,440 @@
+package iminio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/syntax/irate"
+	"github.com/cute-angelia/go-xutils/utils/generator/hash"
+	"github.com/minio/minio-go/v7"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	minPartSize              = 5 << 20 // S3 除最后一个分片外的最小长度
+	defaultPartSize          = 16 << 20
+	maxPartCount             = 10000
+	defaultUploadConcurrency = 4
+	defaultUploadInterval    = 500 * time.Millisecond
+)
+
+// UploadProgress 分片上传进度
+type UploadProgress struct {
+	Bucket    string
+	Object    string
+	UploadID  string
+	Done      int64
+	Total     int64
+	Speed     int64         // 字节/秒，平滑后的速度
+	Eta       time.Duration // 预计剩余时间，未知时为 -1
+	PartsDone int
+	Parts     int
+	Finished  bool  // 最后一次事件
+	Err       error // Finished 时上传失败的原因，状态已保存，可以再次调用续传
+}
+
+// UploadProgressFunc 进度回调，同一个上传的回调不会并发调用
+type UploadProgressFunc func(p UploadProgress)
+
+// MultipartOptions 分片上传参数，零值使用默认值
+type MultipartOptions struct {
+	PartSize         int64                  // 分片大小，默认 16MB，最小 5MB；分片数超过 10000 时自动加倍
+	Concurrency      int                    // 并发上传的分片数，默认 4
+	OnProgress       UploadProgressFunc     // 进度回调
+	ProgressInterval time.Duration          // 回调间隔，默认 500ms；分片完成时也会回调
+	PutOptions       minio.PutObjectOptions // ContentType、UserMetadata 等，创建上传时使用
+	Bandwidth        *irate.Bandwidth       // 单个上传限速，与全局、组件限速同时生效
+}
+
+// uploadState 保存在 UploadStateDir，重启后根据它续传
+type uploadState struct {
+	Bucket   string               `json:"bucket"`
+	Object   string               `json:"object"` // 经过 ReplaceMode 处理后的 key
+	File     string               `json:"file"`
+	Size     int64                `json:"size"`
+	ModTime  int64                `json:"mod_time"`
+	PartSize int64                `json:"part_size"`
+	UploadID string               `json:"upload_id"`
+	Parts    []minio.CompletePart `json:"parts"`
+	Created  time.Time            `json:"created"`
+}
+
+// FPutObjectMultipart 大文件分片并发上传，支持断点续传
+//   - 创建上传后在 UploadStateDir 保存 upload id，每完成一个分片保存一次
+//   - 同一 bucket / objectName / 文件再次调用时，跳过服务端确认过的分片；文件大小或修改时间变化时重新上传
+//   - 失败时保留状态，服务端的未完成上传由 AbortStaleUploads 清理
+func (e *Component) FPutObjectMultipart(ctx context.Context, bucket string, objectNameIn string, filePath string, opts MultipartOptions) (minio.UploadInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("获取文件信息失败: %w", err)
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	partSize := opts.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	partSize = max(partSize, minPartSize)
+	for (fileInfo.Size()+partSize-1)/partSize > maxPartCount {
+		partSize *= 2
+	}
+
+	stateFile := e.uploadStateFile(bucket, objectNameIn, absPath)
+	state, done, err := e.resumeUpload(ctx, stateFile, bucket, absPath, fileInfo, partSize)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	if state == nil {
+		objectName, ok := e.CheckMode(objectNameIn)
+		if !ok {
+			return minio.UploadInfo{}, fmt.Errorf("模式未设置 %s", objectNameIn)
+		}
+		objectName = strings.ReplaceAll(objectName, "//", "/")
+
+		uploadID, err := e.core().NewMultipartUpload(ctx, bucket, objectName, opts.PutOptions)
+		if err != nil {
+			return minio.UploadInfo{}, fmt.Errorf("创建分片上传失败: %w", err)
+		}
+		state = &uploadState{
+			Bucket:   bucket,
+			Object:   objectName,
+			File:     absPath,
+			Size:     fileInfo.Size(),
+			ModTime:  fileInfo.ModTime().UnixNano(),
+			PartSize: partSize,
+			UploadID: uploadID,
+			Created:  time.Now(),
+		}
+		if err := saveUploadState(stateFile, state); err != nil {
+			return minio.UploadInfo{}, err
+		}
+		done = map[int]bool{}
+	}
+
+	partCount := int(max((state.Size+partSize-1)/partSize, 1))
+	tracker := newUploadTracker(opts, state, partCount, done)
+
+	var mu sync.Mutex
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultUploadConcurrency
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for n := 1; n <= partCount; n++ {
+		if done[n] {
+			continue
+		}
+		g.Go(func() error {
+			offset := int64(n-1) * partSize
+			size := min(partSize, state.Size-offset)
+			section := io.NewSectionReader(file, offset, size)
+			r := &partReader{r: section, limit: e.limitReader(gctx, section, opts.Bandwidth), done: &tracker.parts[n-1]}
+
+			part, err := e.core().PutObjectPart(gctx, bucket, state.Object, state.UploadID, n, r, size, minio.PutObjectPartOptions{})
+			if err != nil {
+				return fmt.Errorf("上传分片 %d 失败: %w", n, err)
+			}
+			tracker.partDone()
+
+			mu.Lock()
+			defer mu.Unlock()
+			state.Parts = append(state.Parts, minio.CompletePart{PartNumber: n, ETag: part.ETag})
+			if err := saveUploadState(stateFile, state); err != nil {
+				log.Println(PackageName, "保存上传状态失败", stateFile, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		tracker.finish(err)
+		return minio.UploadInfo{}, err
+	}
+
+	sort.Slice(state.Parts, func(i, j int) bool { return state.Parts[i].PartNumber < state.Parts[j].PartNumber })
+	uploadInfo, err := e.core().CompleteMultipartUpload(ctx, bucket, state.Object, state.UploadID, state.Parts, opts.PutOptions)
+	if err != nil {
+		err = fmt.Errorf("合并分片失败: %w", err)
+		tracker.finish(err)
+		return minio.UploadInfo{}, err
+	}
+	os.Remove(stateFile)
+	e.invalidateList(bucket, state.Object)
+	tracker.finish(nil)
+
+	if e.config.Debug {
+		log.Printf("Successfully uploaded: %s/%s, Size: %d, Parts: %d\n", bucket, state.Object, state.Size, partCount)
+	}
+	uploadInfo.Size = state.Size
+	return uploadInfo, nil
+}
+
+// AbortStaleUploads 终止 prefix 下创建时间早于 olderThan 的未完成分片上传，释放服务端占用的空间
+// 同时删除本地对应的续传状态；返回终止的数量
+func (e *Component) AbortStaleUploads(ctx context.Context, bucket string, prefix string, olderThan time.Duration) (int, error) {
+	deadline := time.Now().Add(-olderThan)
+	aborted := map[string]bool{}
+
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		result, err := e.core().ListMultipartUploads(ctx, bucket, prefix, keyMarker, uploadIDMarker, "", 1000)
+		if err != nil {
+			return len(aborted), err
+		}
+		for _, upload := range result.Uploads {
+			if !upload.Initiated.Before(deadline) {
+				continue
+			}
+			if err := e.core().AbortMultipartUpload(ctx, bucket, upload.Key, upload.UploadID); err != nil && !isNoSuchUpload(err) {
+				return len(aborted), err
+			}
+			aborted[upload.UploadID] = true
+		}
+		if !result.IsTruncated {
+			break
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+
+	if len(aborted) > 0 {
+		files, _ := filepath.Glob(filepath.Join(e.config.UploadStateDir, "*.json"))
+		for _, stateFile := range files {
+			if state, err := loadUploadState(stateFile); err == nil && aborted[state.UploadID] {
+				os.Remove(stateFile)
+			}
+		}
+	}
+	return len(aborted), nil
+}
+
+func (e *Component) core() *minio.Core {
+	return &minio.Core{Client: e.Client}
+}
+
+func (e *Component) uploadStateFile(bucket, objectName, absPath string) string {
+	return filepath.Join(e.config.UploadStateDir, hash.NewEncodeMD5(bucket+"/"+objectName+"|"+absPath)+".json")
+}
+
+// resumeUpload 读取续传状态，返回已完成的分片；没有可用状态时返回 nil
+func (e *Component) resumeUpload(ctx context.Context, stateFile, bucket, absPath string, fileInfo os.FileInfo, partSize int64) (*uploadState, map[int]bool, error) {
+	state, err := loadUploadState(stateFile)
+	if err != nil {
+		return nil, nil, nil
+	}
+	if state.Bucket != bucket || state.File != absPath || state.Size != fileInfo.Size() ||
+		state.ModTime != fileInfo.ModTime().UnixNano() || state.PartSize != partSize {
+		// 文件变化，旧的上传不再需要
+		e.core().AbortMultipartUpload(ctx, state.Bucket, state.Object, state.UploadID)
+		os.Remove(stateFile)
+		return nil, nil, nil
+	}
+
+	// 以服务端为准，本地记录的分片需要服务端确认
+	uploaded := map[int]minio.ObjectPart{}
+	marker := 0
+	for {
+		result, err := e.core().ListObjectParts(ctx, bucket, state.Object, state.UploadID, marker, 1000)
+		if isNoSuchUpload(err) {
+			os.Remove(stateFile)
+			return nil, nil, nil
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("查询已上传分片失败: %w", err)
+		}
+		for _, part := range result.ObjectParts {
+			uploaded[part.PartNumber] = part
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+
+	done := map[int]bool{}
+	var parts []minio.CompletePart
+	for _, part := range state.Parts {
+		if remote, ok := uploaded[part.PartNumber]; ok && strings.Trim(remote.ETag, `"`) == strings.Trim(part.ETag, `"`) {
+			done[part.PartNumber] = true
+			parts = append(parts, part)
+		}
+	}
+	state.Parts = parts
+	return state, done, nil
+}
+
+func isNoSuchUpload(err error) bool {
+	return err != nil && minio.ToErrorResponse(err).Code == "NoSuchUpload"
+}
+
+func loadUploadState(stateFile string) (*uploadState, error) {
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		return nil, err
+	}
+	var state uploadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if len(state.UploadID) == 0 {
+		return nil, errors.New("empty upload id")
+	}
+	return &state, nil
+}
+
+// saveUploadState 先写临时文件再重命名，中途退出不会留下损坏的状态
+func saveUploadState(stateFile string, state *uploadState) error {
+	if err := os.MkdirAll(filepath.Dir(stateFile), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := stateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, stateFile)
+}
+
+// partReader 统计分片已读取的字节；minio 计算签名或重试时会 Seek 回开头，进度随之回退
+type partReader struct {
+	r     *io.SectionReader
+	limit io.Reader // 对 r 限速，不限速时就是 r；限速放在内部，保留 Seek，minio 重试时可以回到开头
+	done  *atomic.Int64
+}
+
+func (p *partReader) Read(b []byte) (int, error) {
+	n, err := p.limit.Read(b)
+	p.done.Add(int64(n))
+	return n, err
+}
+
+func (p *partReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := p.r.Seek(offset, whence)
+	if err == nil {
+		p.done.Store(pos)
+	}
+	return pos, err
+}
+
+// uploadTracker 统计一次分片上传的进度，没有回调时只计数
+type uploadTracker struct {
+	f        UploadProgressFunc
+	progress UploadProgress
+	parts    []atomic.Int64
+	doneN    atomic.Int32
+
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+
+	mu    sync.Mutex
+	speed *irate.Speed
+}
+
+func newUploadTracker(opts MultipartOptions, state *uploadState, partCount int, done map[int]bool) *uploadTracker {
+	t := &uploadTracker{
+		f: opts.OnProgress,
+		progress: UploadProgress{
+			Bucket:   state.Bucket,
+			Object:   state.Object,
+			UploadID: state.UploadID,
+			Total:    state.Size,
+			Parts:    partCount,
+		},
+		parts:   make([]atomic.Int64, partCount),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	var resumed int64
+	for n := range done {
+		size := min(state.PartSize, state.Size-int64(n-1)*state.PartSize)
+		t.parts[n-1].Store(size)
+		resumed += size
+		t.doneN.Add(1)
+	}
+	t.speed = irate.NewSpeed(resumed)
+	if t.f == nil {
+		close(t.stopped)
+		return t
+	}
+
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = defaultUploadInterval
+	}
+	go func() {
+		defer close(t.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				t.emit(false, nil)
+			}
+		}
+	}()
+	return t
+}
+
+// partDone 分片完成时立即通知一次
+func (t *uploadTracker) partDone() {
+	t.doneN.Add(1)
+	if t.f != nil {
+		t.emit(false, nil)
+	}
+}
+
+// finish 停止定时通知，发送最后一次事件
+func (t *uploadTracker) finish(err error) {
+	if t.f == nil {
+		return
+	}
+	t.once.Do(func() {
+		close(t.stop)
+		<-t.stopped
+		t.emit(true, err)
+	})
+}
+
+// emit 定时器和分片完成都会调用，加锁保证回调不并发
+func (t *uploadTracker) emit(finished bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.progress
+	for i := range t.parts {
+		p.Done += t.parts[i].Load()
+	}
+	p.PartsDone = int(t.doneN.Load())
+	p.Finished, p.Err = finished, err
+
+	p.Speed = t.speed.Update(p.Done)
+	p.Eta = t.speed.Eta(p.Total - p.Done)
+	if finished && err == nil {
+		p.Eta = 0
+	}
+	t.f(p)
+}
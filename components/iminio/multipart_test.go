@@ -0,0 +1,310 @@
+package iminio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUpload struct {
+	key       string
+	initiated time.Time
+	parts     map[int][]byte
+}
+
+// fakeMultipart 只实现分片上传相关接口
+type fakeMultipart struct {
+	mu       sync.Mutex
+	uploads  map[string]*fakeUpload
+	objects  map[string][]byte
+	puts     map[int]int // 每个分片号的上传次数
+	failPart int         // 该分片返回一次 500
+	nextID   int
+}
+
+type fakePart struct {
+	PartNumber   int
+	ETag         string
+	Size         int64
+	LastModified time.Time
+}
+
+type fakeMultipartUpload struct {
+	Key       string
+	UploadID  string `xml:"UploadId"`
+	Initiated time.Time
+}
+
+func partETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// readPayload 非 TLS 时 minio 使用 aws-chunked 流式签名：每块为 "长度;chunk-signature=...\r\n数据\r\n"
+func readPayload(r *http.Request) []byte {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		data, _ := io.ReadAll(r.Body)
+		return data
+	}
+	var data []byte
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return data
+		}
+		size, _ := strconv.ParseInt(strings.SplitN(strings.TrimSpace(line), ";", 2)[0], 16, 64)
+		if size == 0 {
+			return data
+		}
+		chunk := make([]byte, size+2)
+		io.ReadFull(br, chunk)
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func (s *fakeMultipart) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	upload := s.uploads[query.Get("uploadId")]
+	if query.Has("uploadId") && upload == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`<Error><Code>NoSuchUpload</Code><Message>no such upload</Message></Error>`))
+		return
+	}
+
+	switch {
+	case query.Has("location"):
+		w.Write([]byte(`<LocationConstraint>us-east-1</LocationConstraint>`))
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.nextID++
+		id := fmt.Sprintf("upload-%d", s.nextID)
+		s.uploads[id] = &fakeUpload{key: key, initiated: time.Now(), parts: map[int][]byte{}}
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, key, id)
+
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		data := readPayload(r)
+		s.puts[n]++
+		if n == s.failPart {
+			s.failPart = 0
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<Error><Code>InvalidPart</Code><Message>injected</Message></Error>`))
+			return
+		}
+		upload.parts[n] = data
+		w.Header().Set("ETag", partETag(data))
+
+	case r.Method == http.MethodGet && query.Has("uploadId"):
+		var result struct {
+			XMLName xml.Name   `xml:"ListPartsResult"`
+			Part    []fakePart `xml:"Part"`
+		}
+		for n, data := range upload.parts {
+			result.Part = append(result.Part, fakePart{PartNumber: n, ETag: partETag(data), Size: int64(len(data)), LastModified: time.Now()})
+		}
+		xml.NewEncoder(w).Encode(result)
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		var complete struct {
+			Part []struct {
+				PartNumber int
+				ETag       string
+			}
+		}
+		xml.NewDecoder(r.Body).Decode(&complete)
+		var buf bytes.Buffer
+		for i, part := range complete.Part {
+			data, ok := upload.parts[part.PartNumber]
+			if !ok || part.PartNumber != i+1 || partETag(data) != `"`+strings.Trim(part.ETag, `"`)+`"` {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`<Error><Code>InvalidPart</Code><Message>bad part</Message></Error>`))
+				return
+			}
+			buf.Write(data)
+		}
+		s.objects[key] = buf.Bytes()
+		delete(s.uploads, query.Get("uploadId"))
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><ETag>"done"</ETag></CompleteMultipartUploadResult>`, key)
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodGet && query.Has("uploads"):
+		var result struct {
+			XMLName xml.Name              `xml:"ListMultipartUploadsResult"`
+			Upload  []fakeMultipartUpload `xml:"Upload"`
+		}
+		for id, upload := range s.uploads {
+			result.Upload = append(result.Upload, fakeMultipartUpload{Key: upload.key, UploadID: id, Initiated: upload.initiated})
+		}
+		xml.NewEncoder(w).Encode(result)
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func newMultipartComponent(t *testing.T) (*Component, *fakeMultipart) {
+	s3 := &fakeMultipart{uploads: map[string]*fakeUpload{}, objects: map[string][]byte{}, puts: map[int]int{}}
+	server := httptest.NewServer(s3)
+	t.Cleanup(server.Close)
+
+	return New(
+		WithEndpoint(strings.TrimPrefix(server.URL, "http://")),
+		WithAccesskeyId("ak"),
+		WithSecretaccessKey("sk"),
+		WithUploadStateDir(t.TempDir()),
+	), s3
+}
+
+func writeTestFile(t *testing.T, size int) (string, []byte) {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	filename := filepath.Join(t.TempDir(), "big.bin")
+	assert.Nil(t, os.WriteFile(filename, data, 0644))
+	return filename, data
+}
+
+func TestFPutObjectMultipartResume(t *testing.T) {
+	c, s3 := newMultipartComponent(t)
+	filename, data := writeTestFile(t, minPartSize*3+100)
+
+	// 第一次第 3 个分片失败，其余分片已完成
+	s3.failPart = 3
+	var events []UploadProgress
+	_, err := c.FPutObjectMultipart(context.Background(), "bucket", "a/big.bin", filename, MultipartOptions{
+		PartSize:    minPartSize,
+		Concurrency: 1,
+		OnProgress:  func(p UploadProgress) { events = append(events, p) },
+	})
+	assert.NotNil(t, err)
+	last := events[len(events)-1]
+	assert.True(t, last.Finished)
+	assert.NotNil(t, last.Err)
+	assert.Equal(t, 4, last.Parts)
+	assert.Equal(t, 2, last.PartsDone)
+	assert.Len(t, s3.uploads, 1)
+
+	// 续传只上传剩下的分片
+	events = nil
+	info, err := c.FPutObjectMultipart(context.Background(), "bucket", "a/big.bin", filename, MultipartOptions{
+		PartSize:   minPartSize,
+		OnProgress: func(p UploadProgress) { events = append(events, p) },
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(data)), info.Size)
+	assert.True(t, bytes.Equal(data, s3.objects["a/big.bin"]))
+	assert.Equal(t, map[int]int{1: 1, 2: 1, 3: 2, 4: 1}, s3.puts)
+	assert.Empty(t, s3.uploads)
+
+	last = events[len(events)-1]
+	assert.True(t, last.Finished)
+	assert.Nil(t, last.Err)
+	assert.Equal(t, int64(len(data)), last.Done)
+	assert.Equal(t, 4, last.PartsDone)
+	assert.Equal(t, time.Duration(0), last.Eta)
+
+	// 完成后删除续传状态
+	files, _ := filepath.Glob(filepath.Join(c.config.UploadStateDir, "*.json"))
+	assert.Empty(t, files)
+}
+
+func TestFPutObjectMultipartRestartOnChange(t *testing.T) {
+	c, s3 := newMultipartComponent(t)
+	filename, _ := writeTestFile(t, minPartSize+10)
+
+	s3.failPart = 2
+	_, err := c.FPutObjectMultipart(context.Background(), "bucket", "b.bin", filename, MultipartOptions{Concurrency: 1, PartSize: minPartSize})
+	assert.NotNil(t, err)
+
+	// 文件变化后放弃旧的上传
+	data := bytes.Repeat([]byte("y"), minPartSize+20)
+	assert.Nil(t, os.WriteFile(filename, data, 0644))
+	_, err = c.FPutObjectMultipart(context.Background(), "bucket", "b.bin", filename, MultipartOptions{PartSize: minPartSize})
+	assert.Nil(t, err)
+	assert.True(t, bytes.Equal(data, s3.objects["b.bin"]))
+	assert.Empty(t, s3.uploads)
+
+	// 服务端的上传已被终止时重新上传
+	s3.failPart = 2
+	_, err = c.FPutObjectMultipart(context.Background(), "bucket", "c.bin", filename, MultipartOptions{Concurrency: 1, PartSize: minPartSize})
+	assert.NotNil(t, err)
+	s3.mu.Lock()
+	s3.uploads = map[string]*fakeUpload{}
+	s3.mu.Unlock()
+	_, err = c.FPutObjectMultipart(context.Background(), "bucket", "c.bin", filename, MultipartOptions{PartSize: minPartSize})
+	assert.Nil(t, err)
+	assert.True(t, bytes.Equal(data, s3.objects["c.bin"]))
+}
+
+func TestAbortStaleUploads(t *testing.T) {
+	c, s3 := newMultipartComponent(t)
+	filename, _ := writeTestFile(t, minPartSize+10)
+
+	s3.failPart = 2
+	_, err := c.FPutObjectMultipart(context.Background(), "bucket", "stale.bin", filename, MultipartOptions{Concurrency: 1, PartSize: minPartSize})
+	assert.NotNil(t, err)
+	s3.mu.Lock()
+	for _, upload := range s3.uploads {
+		upload.initiated = time.Now().Add(-2 * time.Hour)
+	}
+	s3.uploads["fresh"] = &fakeUpload{key: "fresh.bin", initiated: time.Now(), parts: map[int][]byte{}}
+	s3.mu.Unlock()
+
+	n, err := c.AbortStaleUploads(context.Background(), "bucket", "", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, s3.uploads, 1)
+	assert.NotNil(t, s3.uploads["fresh"])
+
+	files, _ := filepath.Glob(filepath.Join(c.config.UploadStateDir, "*.json"))
+	assert.Empty(t, files)
+}
+
+func TestPartReaderLimitedSeek(t *testing.T) {
+	c, _ := newMultipartComponent(t)
+	c.SetRateLimit(64 << 20)
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	// 限速时仍然可以 Seek 回开头重新读取，minio 重试分片时需要
+	var done atomic.Int64
+	section := io.NewSectionReader(bytes.NewReader(data), 100, 5000)
+	r := &partReader{r: section, limit: c.limitReader(context.Background(), section, nil), done: &done}
+	first, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5000), done.Load())
+
+	pos, err := r.Seek(0, io.SeekStart)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), pos)
+	assert.Equal(t, int64(0), done.Load())
+	second, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, data[100:5100], second)
+	assert.Equal(t, first, second)
+}
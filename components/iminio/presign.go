@@ -0,0 +1,230 @@
+package iminio
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/utils/generator/hash"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	defaultUploadExpiry = 15 * time.Minute
+	maxUploadExpiry     = 7 * 24 * time.Hour // S3 签名最长有效期
+	uploadCallbackGrace = time.Hour          // 上传可能在过期前一刻开始，回调允许晚于过期时间
+)
+
+var (
+	ErrInvalidUploadPolicy = errors.New("iminio: invalid upload policy")
+	ErrInvalidUploadToken  = errors.New("iminio: invalid upload token")
+	ErrUploadNotFound      = errors.New("iminio: uploaded object not found")
+	ErrUploadMismatch      = errors.New("iminio: uploaded object does not match policy")
+)
+
+// UploadPolicy 浏览器直传的限制条件
+type UploadPolicy struct {
+	Bucket      string        `json:"bucket"`
+	Key         string        `json:"key,omitempty"`          // 指定 key，PUT 必填
+	KeyPrefix   string        `json:"key_prefix,omitempty"`   // Key 为空时，POST 允许前端在该前缀下自定 key
+	ContentType string        `json:"content_type,omitempty"` // 以 / 结尾时按前缀匹配，如 "image/"
+	MinSize     int64         `json:"min_size,omitempty"`
+	MaxSize     int64         `json:"max_size,omitempty"` // 0 不限制
+	Expiry      time.Duration `json:"expiry,omitempty"`   // 默认 15 分钟，最长 7 天
+}
+
+// PresignedUpload 返回给前端的上传参数
+//   - POST：以 multipart/form-data 提交 FormData 中的字段，文件字段名为 file 且放在最后；
+//     按前缀限制时 FormData 中的 key / Content-Type 为前缀，由前端替换为实际值
+//   - PUT：请求体为文件内容，需要带上 Headers
+type PresignedUpload struct {
+	Method   string            `json:"method"`
+	Url      string            `json:"url"`
+	Key      string            `json:"key,omitempty"`
+	FormData map[string]string `json:"form_data,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Expires  time.Time         `json:"expires"`
+	Token    string            `json:"token"` // 上传完成回调时原样带回，用 ParseUploadToken 还原策略
+}
+
+type uploadToken struct {
+	Policy  UploadPolicy `json:"p"`
+	Expires int64        `json:"e"`
+}
+
+// PresignPost 生成浏览器表单直传的 POST 策略，大小、类型、key 由服务端校验
+func (e *Component) PresignPost(ctx context.Context, policy UploadPolicy) (PresignedUpload, error) {
+	if err := policy.validate(); err != nil {
+		return PresignedUpload{}, err
+	}
+	expires := time.Now().Add(policy.expiry()).UTC()
+
+	postPolicy := minio.NewPostPolicy()
+	errs := []error{postPolicy.SetBucket(policy.Bucket), postPolicy.SetExpires(expires)}
+	if len(policy.Key) > 0 {
+		errs = append(errs, postPolicy.SetKey(policy.Key))
+	} else {
+		errs = append(errs, postPolicy.SetKeyStartsWith(policy.KeyPrefix))
+	}
+	if strings.HasSuffix(policy.ContentType, "/") {
+		errs = append(errs, postPolicy.SetContentTypeStartsWith(policy.ContentType))
+	} else if len(policy.ContentType) > 0 {
+		errs = append(errs, postPolicy.SetContentType(policy.ContentType))
+	}
+	if policy.MaxSize > 0 {
+		errs = append(errs, postPolicy.SetContentLengthRange(policy.MinSize, policy.MaxSize))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return PresignedUpload{}, fmt.Errorf("%w: %v", ErrInvalidUploadPolicy, err)
+	}
+
+	u, formData, err := e.Client.PresignedPostPolicy(ctx, postPolicy)
+	if err != nil {
+		return PresignedUpload{}, err
+	}
+	return PresignedUpload{
+		Method:   http.MethodPost,
+		Url:      u.String(),
+		Key:      policy.Key,
+		FormData: formData,
+		Expires:  expires,
+		Token:    e.signUploadToken(policy, expires),
+	}, nil
+}
+
+// PresignPut 生成 PUT 直传链接
+//   - 只能指定 key，ContentType 为具体类型时签入请求头
+//   - 签名无法限制大小，大小与前缀类型只能在 VerifyUpload 时检查
+func (e *Component) PresignPut(ctx context.Context, policy UploadPolicy) (PresignedUpload, error) {
+	if err := policy.validate(); err != nil {
+		return PresignedUpload{}, err
+	}
+	if len(policy.Key) == 0 {
+		return PresignedUpload{}, fmt.Errorf("%w: PUT requires Key", ErrInvalidUploadPolicy)
+	}
+	expires := time.Now().Add(policy.expiry()).UTC()
+
+	headers := http.Header{}
+	if len(policy.ContentType) > 0 && !strings.HasSuffix(policy.ContentType, "/") {
+		headers.Set("Content-Type", policy.ContentType)
+	}
+	u, err := e.Client.PresignHeader(ctx, http.MethodPut, policy.Bucket, policy.Key, policy.expiry(), nil, headers)
+	if err != nil {
+		return PresignedUpload{}, err
+	}
+
+	upload := PresignedUpload{
+		Method:  http.MethodPut,
+		Url:     u.String(),
+		Key:     policy.Key,
+		Expires: expires,
+		Token:   e.signUploadToken(policy, expires),
+	}
+	if len(headers) > 0 {
+		upload.Headers = map[string]string{"Content-Type": headers.Get("Content-Type")}
+	}
+	return upload, nil
+}
+
+// ParseUploadToken 校验签名并还原策略，过期超过 1 小时的 token 视为无效
+func (e *Component) ParseUploadToken(token string) (UploadPolicy, error) {
+	payload, sign, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sign), []byte(hash.HMAC(hash.AlgoSha256, payload, e.config.SecretaccessKey))) {
+		return UploadPolicy{}, ErrInvalidUploadToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return UploadPolicy{}, ErrInvalidUploadToken
+	}
+	var t uploadToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return UploadPolicy{}, ErrInvalidUploadToken
+	}
+	if time.Now().After(time.Unix(t.Expires, 0).Add(uploadCallbackGrace)) {
+		return UploadPolicy{}, fmt.Errorf("%w: expired", ErrInvalidUploadToken)
+	}
+	return t.Policy, nil
+}
+
+// VerifyUpload 上传完成回调时检查对象确实存在且符合策略，key 为前端上报的 key
+//   - 符合时清除相关的列表索引
+//   - 不符合时返回 ErrUploadMismatch，对象不会被删除，需要时由调用方删除
+func (e *Component) VerifyUpload(ctx context.Context, policy UploadPolicy, key string) (minio.ObjectInfo, error) {
+	if !policy.matchKey(key) {
+		return minio.ObjectInfo{}, fmt.Errorf("%w: key %s", ErrUploadMismatch, key)
+	}
+	info, err := e.Client.StatObject(ctx, policy.Bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return minio.ObjectInfo{}, fmt.Errorf("%w: %s", ErrUploadNotFound, key)
+		}
+		return minio.ObjectInfo{}, err
+	}
+
+	switch {
+	case info.Size < policy.MinSize || (policy.MaxSize > 0 && info.Size > policy.MaxSize):
+		return info, fmt.Errorf("%w: size %d not in [%d, %d]", ErrUploadMismatch, info.Size, policy.MinSize, policy.MaxSize)
+	case !policy.matchContentType(info.ContentType):
+		return info, fmt.Errorf("%w: content type %s", ErrUploadMismatch, info.ContentType)
+	}
+	// 直传不经过组件，确认后再清除列表索引
+	e.invalidateList(policy.Bucket, key)
+	return info, nil
+}
+
+func (p UploadPolicy) validate() error {
+	switch {
+	case len(p.Bucket) == 0:
+		return fmt.Errorf("%w: empty Bucket", ErrInvalidUploadPolicy)
+	case len(p.Key) == 0 && len(p.KeyPrefix) == 0:
+		// 两者都为空时整个 bucket 可写
+		return fmt.Errorf("%w: Key or KeyPrefix required", ErrInvalidUploadPolicy)
+	case len(p.Key) > 0 && !strings.HasPrefix(p.Key, p.KeyPrefix):
+		return fmt.Errorf("%w: Key %s outside KeyPrefix %s", ErrInvalidUploadPolicy, p.Key, p.KeyPrefix)
+	case p.MinSize < 0 || (p.MaxSize > 0 && p.MaxSize < p.MinSize):
+		return fmt.Errorf("%w: size range [%d, %d]", ErrInvalidUploadPolicy, p.MinSize, p.MaxSize)
+	case p.Expiry > maxUploadExpiry:
+		return fmt.Errorf("%w: expiry %s exceeds 7 days", ErrInvalidUploadPolicy, p.Expiry)
+	}
+	return nil
+}
+
+func (p UploadPolicy) expiry() time.Duration {
+	if p.Expiry <= 0 {
+		return defaultUploadExpiry
+	}
+	return p.Expiry
+}
+
+func (p UploadPolicy) matchKey(key string) bool {
+	if len(p.Key) > 0 {
+		return key == p.Key
+	}
+	return strings.HasPrefix(key, p.KeyPrefix) && len(key) > len(p.KeyPrefix)
+}
+
+// matchContentType 忽略 charset 等参数
+func (p UploadPolicy) matchContentType(contentType string) bool {
+	if len(p.ContentType) == 0 {
+		return true
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if strings.HasSuffix(p.ContentType, "/") {
+		return strings.HasPrefix(mediaType, strings.ToLower(p.ContentType))
+	}
+	return mediaType == strings.ToLower(p.ContentType)
+}
+
+// signUploadToken base64url(策略).hmac-sha256，密钥为 SecretaccessKey
+func (e *Component) signUploadToken(policy UploadPolicy, expires time.Time) string {
+	data, _ := json.Marshal(uploadToken{Policy: policy, Expires: expires.Unix()})
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + hash.HMAC(hash.AlgoSha256, payload, e.config.SecretaccessKey)
+}
@@ -0,0 +1,107 @@
+package iminio
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresignPost(t *testing.T) {
+	c, _ := newSyncComponent(t)
+	policy := UploadPolicy{Bucket: "bucket", KeyPrefix: "avatar/42/", ContentType: "image/", MaxSize: 1 << 20}
+	upload, err := c.PresignPost(context.Background(), policy)
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPost, upload.Method)
+	assert.True(t, strings.HasSuffix(upload.Url, "/bucket/"))
+	assert.Equal(t, "avatar/42/", upload.FormData["key"])
+	assert.Equal(t, "image/", upload.FormData["Content-Type"])
+	assert.NotEmpty(t, upload.FormData["x-amz-signature"])
+	assert.WithinDuration(t, time.Now().Add(defaultUploadExpiry), upload.Expires, time.Minute)
+
+	doc, err := base64.StdEncoding.DecodeString(upload.FormData["policy"])
+	assert.Nil(t, err)
+	assert.Contains(t, string(doc), `["starts-with","$key","avatar/42/"]`)
+	assert.Contains(t, string(doc), `["starts-with","$Content-Type","image/"]`)
+	assert.Contains(t, string(doc), `["content-length-range", 0, 1048576]`)
+
+	parsed, err := c.ParseUploadToken(upload.Token)
+	assert.Nil(t, err)
+	assert.Equal(t, policy, parsed)
+
+	_, err = c.PresignPost(context.Background(), UploadPolicy{Bucket: "bucket"})
+	assert.ErrorIs(t, err, ErrInvalidUploadPolicy)
+	_, err = c.PresignPost(context.Background(), UploadPolicy{Bucket: "bucket", Key: "a.jpg", KeyPrefix: "avatar/"})
+	assert.ErrorIs(t, err, ErrInvalidUploadPolicy)
+}
+
+func TestPresignPut(t *testing.T) {
+	c, _ := newSyncComponent(t)
+	upload, err := c.PresignPut(context.Background(), UploadPolicy{Bucket: "bucket", Key: "doc/a.pdf", ContentType: "application/pdf", Expiry: time.Hour})
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPut, upload.Method)
+	assert.Equal(t, map[string]string{"Content-Type": "application/pdf"}, upload.Headers)
+
+	u, err := url.Parse(upload.Url)
+	assert.Nil(t, err)
+	assert.Equal(t, "/bucket/doc/a.pdf", u.Path)
+	assert.Equal(t, "3600", u.Query().Get("X-Amz-Expires"))
+	assert.Contains(t, u.Query().Get("X-Amz-SignedHeaders"), "content-type")
+
+	_, err = c.PresignPut(context.Background(), UploadPolicy{Bucket: "bucket", KeyPrefix: "doc/"})
+	assert.ErrorIs(t, err, ErrInvalidUploadPolicy)
+}
+
+func TestParseUploadToken(t *testing.T) {
+	c, _ := newSyncComponent(t)
+	policy := UploadPolicy{Bucket: "bucket", Key: "a.jpg"}
+
+	token := c.signUploadToken(policy, time.Now())
+	parsed, err := c.ParseUploadToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, policy, parsed)
+
+	// 篡改策略
+	other := c.signUploadToken(UploadPolicy{Bucket: "bucket", KeyPrefix: "/"}, time.Now())
+	_, sign, _ := strings.Cut(token, ".")
+	payload, _, _ := strings.Cut(other, ".")
+	_, err = c.ParseUploadToken(payload + "." + sign)
+	assert.ErrorIs(t, err, ErrInvalidUploadToken)
+
+	_, err = c.ParseUploadToken(c.signUploadToken(policy, time.Now().Add(-2*uploadCallbackGrace)))
+	assert.ErrorIs(t, err, ErrInvalidUploadToken)
+}
+
+func TestVerifyUpload(t *testing.T) {
+	c, s3 := newSyncComponent(t)
+	s3.put("avatar/42/a.png", make([]byte, 100), time.Now())
+	s3.objects["avatar/42/a.png"].contentType = "image/png"
+	s3.put("avatar/42/b.txt", make([]byte, 100), time.Now())
+	s3.objects["avatar/42/b.txt"].contentType = "text/plain; charset=utf-8"
+	s3.put("avatar/42/big.png", make([]byte, 2000), time.Now())
+	s3.objects["avatar/42/big.png"].contentType = "image/png"
+	s3.put("avatar/7/a.png", make([]byte, 100), time.Now())
+
+	policy := UploadPolicy{Bucket: "bucket", KeyPrefix: "avatar/42/", ContentType: "image/", MaxSize: 1000}
+	info, err := c.VerifyUpload(context.Background(), policy, "avatar/42/a.png")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), info.Size)
+
+	_, err = c.VerifyUpload(context.Background(), policy, "avatar/42/missing.png")
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+	_, err = c.VerifyUpload(context.Background(), policy, "avatar/7/a.png")
+	assert.ErrorIs(t, err, ErrUploadMismatch)
+	_, err = c.VerifyUpload(context.Background(), policy, "avatar/42/b.txt")
+	assert.ErrorIs(t, err, ErrUploadMismatch)
+	_, err = c.VerifyUpload(context.Background(), policy, "avatar/42/big.png")
+	assert.ErrorIs(t, err, ErrUploadMismatch)
+
+	policy = UploadPolicy{Bucket: "bucket", Key: "avatar/42/b.txt", ContentType: "text/plain"}
+	_, err = c.VerifyUpload(context.Background(), policy, "avatar/42/b.txt")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,342 @@
+package iminio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cute-angelia/go-xutils/components/ifileutil"
+	"github.com/cute-angelia/go-xutils/syntax/ifile"
+	"github.com/minio/minio-go/v7"
+	"golang.org/x/sync/errgroup"
+)
+
+const defaultSyncConcurrency = 4
+
+// SyncDirection 同步方向
+type SyncDirection int
+
+const (
+	SyncUpload   SyncDirection = iota // 本地 -> bucket
+	SyncDownload                      // bucket -> 本地
+)
+
+// SyncAction 同步动作
+type SyncAction string
+
+const (
+	SyncActionUpload   SyncAction = "upload"
+	SyncActionDownload SyncAction = "download"
+	SyncActionDelete   SyncAction = "delete" // 删除目标端多余的文件
+	SyncActionSkip     SyncAction = "skip"
+)
+
+// 同步原因
+const (
+	SyncReasonNew    = "new"    // 目标端不存在
+	SyncReasonSize   = "size"   // 大小不同
+	SyncReasonETag   = "etag"   // 大小相同，md5 与 ETag 不同
+	SyncReasonMtime  = "mtime"  // 分片上传的 ETag 不是 md5，源端更新
+	SyncReasonOrphan = "orphan" // 源端已不存在
+	SyncReasonSame   = "same"
+	SyncReasonUnsafe = "unsafe" // 对象 key 含有 .. 等，对应的本地路径超出本地目录，不处理
+)
+
+// ErrUnsafeSyncKey 对象 key 对应的本地路径超出 local.Dir()
+var ErrUnsafeSyncKey = errors.New("iminio: sync key escapes local dir")
+
+// SyncOptions 同步参数
+type SyncOptions struct {
+	Direction   SyncDirection
+	Delete      bool // 删除目标端多余的文件，只处理通过过滤的文件
+	DryRun      bool // 只比较，不修改任何文件
+	Concurrency int  // 并发数，默认 4
+}
+
+// SyncItem 单个文件的比较结果
+type SyncItem struct {
+	Action SyncAction
+	Reason string
+	Key    string // 对象 key
+	Path   string // 本地路径
+	Size   int64  // 源端大小，删除时为目标端大小
+	Err    error
+}
+
+// SyncReport 同步结果，Items 按 key 排序；DryRun 时数量为计划处理的数量
+type SyncReport struct {
+	Items      []SyncItem
+	Uploaded   int
+	Downloaded int
+	Deleted    int
+	Skipped    int
+	Failed     int
+	Bytes      int64 // 上传或下载的字节数
+}
+
+// String 差异报告，每行一个需要处理的文件：+ 新增，~ 修改，- 删除，! 失败
+func (r SyncReport) String() string {
+	var b strings.Builder
+	for _, item := range r.Items {
+		if item.Action == SyncActionSkip {
+			continue
+		}
+		sign := "~"
+		switch {
+		case item.Err != nil:
+			sign = "!"
+		case item.Action == SyncActionDelete:
+			sign = "-"
+		case item.Reason == SyncReasonNew:
+			sign = "+"
+		}
+		fmt.Fprintf(&b, "%s %-8s %s (%s, %d)", sign, item.Action, item.Key, item.Reason, item.Size)
+		if item.Err != nil {
+			fmt.Fprintf(&b, ": %v", item.Err)
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "上传 %d，下载 %d，删除 %d，跳过 %d，失败 %d，传输 %d 字节", r.Uploaded, r.Downloaded, r.Deleted, r.Skipped, r.Failed, r.Bytes)
+	return b.String()
+}
+
+type syncEntry struct {
+	rel    string
+	local  os.FileInfo
+	remote *minio.ObjectInfo
+}
+
+// SyncDir 同步本地目录 local.Dir() 与 bucket 下的 prefix
+//   - 本地文件与对象 key 去掉 prefix 后的相对路径都按 local 的文件夹 / 后缀过滤
+//   - 依次比较大小、ETag（单次上传时为 md5）、修改时间，只传输新增或变化的文件
+//   - 上传直接覆盖同名对象，不受 ReplaceMode 影响；下载后本地修改时间设为对象的 LastModified
+//   - 单个文件失败不影响其他文件，失败记录在对应的 SyncItem 中并汇总为返回的 error
+//   - 对应的本地路径超出 local.Dir() 的对象 key（如 prefix/../../etc/x）记为失败，返回 ErrUnsafeSyncKey
+func (e *Component) SyncDir(ctx context.Context, local *ifileutil.Component, bucket string, prefix string, opts SyncOptions) (SyncReport, error) {
+	prefix = strings.Trim(prefix, "/")
+	if len(prefix) > 0 {
+		prefix += "/"
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultSyncConcurrency
+	}
+
+	entries := map[string]*syncEntry{}
+	if _, err := os.Stat(local.Dir()); err == nil {
+		err := local.Walk(func(relPath string, info os.FileInfo) error {
+			entries[relPath] = &syncEntry{rel: relPath, local: info}
+			return nil
+		})
+		if err != nil {
+			return SyncReport{}, err
+		}
+	} else if !os.IsNotExist(err) || opts.Direction == SyncUpload {
+		return SyncReport{}, err
+	}
+
+	err := e.walkObjects(ctx, bucket, prefix, "", ListFilter{}, func(obj minio.ObjectInfo) bool {
+		relPath := strings.TrimPrefix(obj.Key, prefix)
+		if !local.Match(relPath) {
+			return true
+		}
+		if entry, ok := entries[relPath]; ok {
+			entry.remote = &obj
+		} else {
+			entries[relPath] = &syncEntry{rel: relPath, remote: &obj}
+		}
+		return true
+	})
+	if err != nil {
+		return SyncReport{}, err
+	}
+
+	items := make([]SyncItem, 0, len(entries))
+	plan := make([]*syncEntry, 0, len(entries))
+	for _, entry := range entries {
+		plan = append(plan, entry)
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].rel < plan[j].rel })
+	for _, entry := range plan {
+		item := SyncItem{
+			Key:  prefix + entry.rel,
+			Path: filepath.Join(local.Dir(), filepath.FromSlash(entry.rel)),
+		}
+		// rel 来自远端 key，不能写到本地目录之外
+		if !filepath.IsLocal(filepath.FromSlash(entry.rel)) || !withinDir(local.Dir(), item.Path) {
+			item.Action, item.Reason = SyncActionSkip, SyncReasonUnsafe
+			item.Err = fmt.Errorf("%w: %s", ErrUnsafeSyncKey, item.Key)
+		}
+		items = append(items, item)
+	}
+
+	var changed atomic.Bool
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, entry := range plan {
+		item := &items[i]
+		if item.Err != nil {
+			continue
+		}
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				item.Err = err
+				return nil
+			}
+			if item.Err = planSync(item, entry, opts); item.Err != nil || opts.DryRun || item.Action == SyncActionSkip {
+				return nil
+			}
+			item.Err = e.applySync(gctx, bucket, item, entry)
+			if item.Action == SyncActionUpload || (item.Action == SyncActionDelete && opts.Direction == SyncUpload) {
+				changed.Store(true)
+			}
+			return nil
+		})
+	}
+	g.Wait()
+	if changed.Load() {
+		e.invalidateList(bucket, prefix)
+	}
+
+	report := SyncReport{Items: items}
+	var errs []error
+	for _, item := range items {
+		switch {
+		case item.Err != nil:
+			report.Failed++
+			errs = append(errs, fmt.Errorf("%s: %w", item.Key, item.Err))
+		case item.Action == SyncActionSkip:
+			report.Skipped++
+		case item.Action == SyncActionUpload:
+			report.Uploaded++
+			report.Bytes += item.Size
+		case item.Action == SyncActionDownload:
+			report.Downloaded++
+			report.Bytes += item.Size
+		case item.Action == SyncActionDelete:
+			report.Deleted++
+		}
+	}
+	if len(errs) > 0 {
+		return report, fmt.Errorf("%s: %d 个文件同步失败: %w", PackageName, len(errs), errors.Join(errs...))
+	}
+	return report, nil
+}
+
+// withinDir filePath 在 dir 之内
+func withinDir(dir, filePath string) bool {
+	rel, err := filepath.Rel(dir, filePath)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// planSync 决定单个文件的动作，大小相同时可能需要读取本地文件计算 md5
+func planSync(item *SyncItem, entry *syncEntry, opts SyncOptions) error {
+	source, target := entry.local != nil, entry.remote != nil
+	action := SyncActionUpload
+	if opts.Direction == SyncDownload {
+		source, target = target, source
+		action = SyncActionDownload
+	}
+	item.Size = entry.size(opts.Direction)
+
+	switch {
+	case !source:
+		item.Action, item.Reason = SyncActionSkip, SyncReasonOrphan
+		if opts.Delete {
+			item.Action = SyncActionDelete
+		}
+		return nil
+	case !target:
+		item.Action, item.Reason = action, SyncReasonNew
+		return nil
+	}
+
+	reason, err := syncReason(item.Path, entry, opts.Direction)
+	if err != nil {
+		return err
+	}
+	item.Action, item.Reason = action, reason
+	if reason == SyncReasonSame {
+		item.Action = SyncActionSkip
+	}
+	return nil
+}
+
+// size 优先取 direction 源端的大小，源端不存在时取目标端
+func (entry *syncEntry) size(direction SyncDirection) int64 {
+	if entry.local != nil && (direction == SyncUpload || entry.remote == nil) {
+		return entry.local.Size()
+	}
+	return entry.remote.Size
+}
+
+func syncReason(filePath string, entry *syncEntry, direction SyncDirection) (string, error) {
+	if entry.local.Size() != entry.remote.Size {
+		return SyncReasonSize, nil
+	}
+
+	etag := strings.Trim(entry.remote.ETag, `"`)
+	if len(etag) == 32 && !strings.Contains(etag, "-") {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		sum, err := ifile.FileHashMd5(file)
+		if err != nil {
+			return "", err
+		}
+		if strings.EqualFold(sum, etag) {
+			return SyncReasonSame, nil
+		}
+		return SyncReasonETag, nil
+	}
+
+	// 分片上传的 ETag 为 "md5-分片数"，只能比较修改时间
+	localTime, remoteTime := entry.local.ModTime(), entry.remote.LastModified
+	if (direction == SyncUpload && localTime.After(remoteTime)) || (direction == SyncDownload && remoteTime.After(localTime)) {
+		return SyncReasonMtime, nil
+	}
+	return SyncReasonSame, nil
+}
+
+func (e *Component) applySync(ctx context.Context, bucket string, item *SyncItem, entry *syncEntry) error {
+	switch {
+	case item.Action == SyncActionUpload:
+		file, err := os.Open(item.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		contentType := mime.TypeByExtension(path.Ext(item.Key))
+		if len(contentType) == 0 {
+			contentType = "application/octet-stream"
+		}
+		_, err = e.Client.PutObject(ctx, bucket, item.Key, e.limitReader(ctx, file, nil), entry.local.Size(), minio.PutObjectOptions{ContentType: contentType})
+		return err
+
+	case item.Action == SyncActionDownload:
+		if err := os.MkdirAll(filepath.Dir(item.Path), 0755); err != nil {
+			return err
+		}
+		if err := e.Client.FGetObject(ctx, bucket, item.Key, item.Path, minio.GetObjectOptions{}); err != nil {
+			return err
+		}
+		lastModified := entry.remote.LastModified
+		return os.Chtimes(item.Path, lastModified, lastModified)
+
+	case item.Action == SyncActionDelete && entry.remote != nil:
+		return e.Client.RemoveObject(ctx, bucket, item.Key, minio.RemoveObjectOptions{})
+
+	case item.Action == SyncActionDelete:
+		return os.Remove(item.Path)
+	}
+	return nil
+}
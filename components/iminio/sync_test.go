@@ -0,0 +1,256 @@
+package iminio
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/ifileutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBucketObject struct {
+	data        []byte
+	etag        string
+	modified    time.Time
+	contentType string
+}
+
+// fakeBucket 保存对象内容，实现同步用到的列表 / 上传 / 下载 / 删除
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects map[string]*fakeBucketObject
+	puts    []string
+	deletes []string
+}
+
+func (s *fakeBucket) put(key string, data []byte, modified time.Time) {
+	s.objects[key] = &fakeBucketObject{data: data, etag: partETag(data), modified: modified}
+}
+
+func (s *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	obj := s.objects[key]
+	switch {
+	case query.Has("location"):
+		w.Write([]byte(`<LocationConstraint>us-east-1</LocationConstraint>`))
+
+	case r.Method == http.MethodPut:
+		s.put(key, readPayload(r), time.Now().UTC().Truncate(time.Second))
+		s.objects[key].contentType = r.Header.Get("Content-Type")
+		s.puts = append(s.puts, key)
+		w.Header().Set("ETag", s.objects[key].etag)
+
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		s.deletes = append(s.deletes, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	case query.Get("list-type") == "2":
+		result := fakeListResult{Name: "bucket", Prefix: query.Get("prefix"), MaxKeys: 1000}
+		var keys []string
+		for k := range s.objects {
+			if strings.HasPrefix(k, query.Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.Contents = append(result.Contents, fakeObject{
+				Key:          k,
+				LastModified: s.objects[k].modified.Format("2006-01-02T15:04:05.000Z"),
+				ETag:         s.objects[k].etag,
+				Size:         int64(len(s.objects[k].data)),
+			})
+		}
+		result.KeyCount = len(result.Contents)
+		w.Header().Set("Content-Type", "application/xml")
+		xml.NewEncoder(w).Encode(result)
+
+	case obj == nil:
+		w.WriteHeader(http.StatusNotFound)
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+		}
+
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("ETag", obj.etag)
+		w.Header().Set("Last-Modified", obj.modified.Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if len(obj.contentType) > 0 {
+			w.Header().Set("Content-Type", obj.contentType)
+		}
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func newSyncComponent(t *testing.T) (*Component, *fakeBucket) {
+	s3 := &fakeBucket{objects: map[string]*fakeBucketObject{}}
+	server := httptest.NewServer(s3)
+	t.Cleanup(server.Close)
+
+	return New(
+		WithEndpoint(strings.TrimPrefix(server.URL, "http://")),
+		WithAccesskeyId("ak"),
+		WithSecretaccessKey("sk"),
+	), s3
+}
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.Nil(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+}
+
+func reportOf(report SyncReport) map[string]string {
+	result := map[string]string{}
+	for _, item := range report.Items {
+		result[item.Key] = string(item.Action) + ":" + item.Reason
+	}
+	return result
+}
+
+func TestSyncDirUpload(t *testing.T) {
+	c, s3 := newSyncComponent(t)
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.txt":       "new content",
+		"same/b.txt":  "unchanged",
+		"big.bin":     "multipart",
+		"new.txt":     "hello",
+		"tmp/x.txt":   "excluded dir",
+		"debug.log":   "excluded ext",
+		"deep/c/d.md": "nested",
+	})
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s3.put("backup/a.txt", []byte("old content"), old)
+	s3.put("backup/same/b.txt", []byte("unchanged"), old)
+	s3.put("backup/big.bin", []byte("multipart"), old)
+	s3.objects["backup/big.bin"].etag = `"0123456789abcdef0123456789abcdef-2"`
+	s3.put("backup/orphan.txt", []byte("orphan"), old)
+	s3.put("backup/tmp/keep.txt", []byte("excluded remote"), old)
+	s3.put("other/a.txt", []byte("outside prefix"), old)
+
+	local := ifileutil.New(ifileutil.WithDir(dir), ifileutil.WithDirDeclude([]string{"tmp"}), ifileutil.WithExtDeclude([]string{".log"}))
+	opts := SyncOptions{Direction: SyncUpload, Delete: true, DryRun: true}
+	report, err := c.SyncDir(context.Background(), local, "bucket", "/backup/", opts)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"backup/a.txt":       "upload:etag",
+		"backup/big.bin":     "upload:mtime",
+		"backup/deep/c/d.md": "upload:new",
+		"backup/new.txt":     "upload:new",
+		"backup/orphan.txt":  "delete:orphan",
+		"backup/same/b.txt":  "skip:same",
+	}, reportOf(report))
+	assert.Equal(t, 4, report.Uploaded)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Contains(t, report.String(), "+ upload   backup/new.txt (new, 5)")
+	assert.Contains(t, report.String(), "- delete   backup/orphan.txt (orphan, 6)")
+	assert.Empty(t, s3.puts)
+	assert.Empty(t, s3.deletes)
+
+	opts.DryRun = false
+	report, err = c.SyncDir(context.Background(), local, "bucket", "backup", opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, report.Uploaded)
+	assert.Equal(t, int64(len("new content")+len("multipart")+len("nested")+len("hello")), report.Bytes)
+	assert.Equal(t, []string{"backup/orphan.txt"}, s3.deletes)
+	assert.Equal(t, "new content", string(s3.objects["backup/a.txt"].data))
+	assert.NotNil(t, s3.objects["backup/tmp/keep.txt"])
+	assert.NotNil(t, s3.objects["other/a.txt"])
+
+	// 再次同步没有变化
+	report, err = c.SyncDir(context.Background(), local, "bucket", "backup", opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, report.Skipped)
+	assert.Equal(t, 0, report.Uploaded+report.Deleted)
+}
+
+func TestSyncDirDownload(t *testing.T) {
+	c, s3 := newSyncComponent(t)
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s3.put("site/index.html", []byte("<html>"), modified)
+	s3.put("site/img/logo.png", []byte("png"), modified)
+	s3.put("site/img/raw.psd", []byte("excluded"), modified)
+
+	dir := filepath.Join(t.TempDir(), "site")
+	local := ifileutil.New(ifileutil.WithDir(dir), ifileutil.WithExtDeclude([]string{".psd"}))
+	opts := SyncOptions{Direction: SyncDownload, Delete: true}
+	report, err := c.SyncDir(context.Background(), local, "bucket", "site", opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, report.Downloaded)
+
+	data, err := os.ReadFile(filepath.Join(dir, "img", "logo.png"))
+	assert.Nil(t, err)
+	assert.Equal(t, "png", string(data))
+	info, err := os.Stat(filepath.Join(dir, "index.html"))
+	assert.Nil(t, err)
+	assert.True(t, info.ModTime().Equal(modified))
+	assert.NoFileExists(t, filepath.Join(dir, "img", "raw.psd"))
+
+	// 本地多余的文件被删除，远端修改的文件重新下载
+	writeTree(t, dir, map[string]string{"stale.txt": "stale"})
+	s3.put("site/index.html", []byte("<html>v2"), modified.Add(time.Hour))
+	report, err = c.SyncDir(context.Background(), local, "bucket", "site", opts)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"site/index.html":   "download:size",
+		"site/img/logo.png": "skip:same",
+		"site/stale.txt":    "delete:orphan",
+	}, reportOf(report))
+	assert.NoFileExists(t, filepath.Join(dir, "stale.txt"))
+	data, _ = os.ReadFile(filepath.Join(dir, "index.html"))
+	assert.Equal(t, "<html>v2", string(data))
+	assert.Empty(t, s3.puts)
+	assert.Empty(t, s3.deletes)
+}
+
+func TestSyncDirUnsafeKey(t *testing.T) {
+	c, s3 := newSyncComponent(t)
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s3.put("site/ok.txt", []byte("ok"), modified)
+	s3.put("site/../../escape.txt", []byte("escape"), modified)
+	s3.put("site/a/../../../escape2.txt", []byte("escape"), modified)
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "a", "site")
+	local := ifileutil.New(ifileutil.WithDir(dir))
+	report, err := c.SyncDir(context.Background(), local, "bucket", "site", SyncOptions{Direction: SyncDownload, Delete: true})
+	assert.ErrorIs(t, err, ErrUnsafeSyncKey)
+	assert.Equal(t, 1, report.Downloaded)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, map[string]string{
+		"site/ok.txt":                 "download:new",
+		"site/../../escape.txt":       "skip:unsafe",
+		"site/a/../../../escape2.txt": "skip:unsafe",
+	}, reportOf(report))
+
+	assert.FileExists(t, filepath.Join(dir, "ok.txt"))
+	assert.NoFileExists(t, filepath.Join(root, "escape.txt"))
+	assert.NoFileExists(t, filepath.Join(root, "a", "escape.txt"))
+	assert.NoFileExists(t, filepath.Join(root, "escape2.txt"))
+	assert.NoFileExists(t, filepath.Join(root, "a", "escape2.txt"))
+}
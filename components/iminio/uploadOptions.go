@@ -0,0 +1,25 @@
+package iminio
+
+import (
+	"github.com/cute-angelia/go-xutils/syntax/irate"
+)
+
+// UploadOptions 单个上传的参数，配合 PutObjectContext / FPutObjectContext / PutObjectFromUrl 使用
+type UploadOptions struct {
+	Bandwidth *irate.Bandwidth // 单个上传限速，与全局、组件限速同时生效
+}
+
+type UploadOption func(*UploadOptions)
+
+// WithBandwidth 单个上传限速，多个上传传入同一个 Bandwidth 时共享限速
+func WithBandwidth(b *irate.Bandwidth) UploadOption {
+	return func(o *UploadOptions) { o.Bandwidth = b }
+}
+
+func newUploadOptions(opts []UploadOption) *UploadOptions {
+	o := &UploadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -0,0 +1,92 @@
+package istore
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cute-angelia/go-xutils/syntax/ijson"
+	"github.com/spf13/viper"
+)
+
+// Config 后端配置，切换后端只需要修改配置；不同 Driver 使用不同的字段
+type Config struct {
+	Driver string // minio / oss / qiniu / github / local / mem，需要 import 对应的后端包
+
+	// minio / oss / qiniu
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	UseSSL    bool
+	Domain    string // qiniu 下载域名，如 https://cdn.example.com
+
+	// local
+	Dir     string // 根目录
+	BaseUrl string // 对外访问的地址，PresignGet 返回 BaseUrl/key，为空时不支持
+
+	// github
+	Owner  string
+	Repo   string
+	Branch string
+	Token  string
+}
+
+// Driver 根据配置创建后端，由各后端包在 init 中 Register
+type Driver func(config Config) (ObjectStore, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+// Register 注册后端，重复注册 panic
+func Register(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if _, ok := drivers[name]; ok {
+		panic(PackageName + ": Register called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// Drivers 已注册的后端
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open 按 config.Driver 创建后端
+func Open(config Config) (ObjectStore, error) {
+	driversMu.RLock()
+	driver, ok := drivers[config.Driver]
+	driversMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown driver %q (forgotten import?)", PackageName, config.Driver)
+	}
+	return driver(config)
+}
+
+// Load viper 加载配置，创建失败时 panic
+func Load(key string) ObjectStore {
+	var config Config
+	jsonstr, _ := ijson.Marshal(viper.GetStringMap(key))
+	if err := ijson.Unmarshal(jsonstr, &config); err != nil {
+		panic(fmt.Errorf("%s: load %s: %w", PackageName, key, err))
+	}
+
+	store, err := Open(config)
+	if err != nil {
+		panic(err)
+	}
+	return store
+}
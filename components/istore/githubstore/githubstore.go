@@ -0,0 +1,265 @@
+package githubstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/cute-angelia/go-xutils/utils/store"
+	"github.com/google/go-github/github"
+)
+
+func init() {
+	istore.Register("github", func(config istore.Config) (istore.ObjectStore, error) {
+		if len(config.Owner) == 0 || len(config.Repo) == 0 {
+			return nil, fmt.Errorf("%s: github driver requires Owner and Repo", istore.PackageName)
+		}
+		return New(store.NewGithub(store.GithubConfig{
+			Token:  config.Token,
+			Owner:  config.Owner,
+			Repo:   config.Repo,
+			Branch: config.Branch,
+		})), nil
+	})
+}
+
+// Store GitHub 仓库存储，每次 Put / Delete 是一次提交
+//   - 适合少量小文件（图床、配置），不适合频繁写入
+//   - ETag 为 blob SHA；PresignGet 返回 download_url，expiry 无效
+type Store struct {
+	g *store.GithubApi
+}
+
+var _ istore.ObjectStore = (*Store)(nil)
+
+// New 使用已有的 GithubApi，分支为 g.Branch
+func New(g *store.GithubApi) *Store {
+	return &Store{g: g}
+}
+
+func (s *Store) Put(ctx context.Context, key string, r io.Reader, size int64, opts istore.PutOptions) (istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return istore.ObjectInfo{}, fmt.Errorf("%s: read %d bytes, size %d", istore.PackageName, len(data), size)
+	}
+
+	fileOpts := s.fileOptions()
+	fileOpts.Content = data
+
+	// 已存在时需要带上 SHA 更新
+	var resp *github.RepositoryContentResponse
+	if file, _, err := s.getContents(ctx, key); err == nil {
+		fileOpts.SHA = file.SHA
+		resp, _, err = s.g.Client.Repositories.UpdateFile(ctx, s.g.Owner, s.g.Repo, key, fileOpts)
+		if err != nil {
+			return istore.ObjectInfo{}, err
+		}
+	} else if errors.Is(err, istore.ErrNotFound) {
+		resp, _, err = s.g.Client.Repositories.CreateFile(ctx, s.g.Owner, s.g.Repo, key, fileOpts)
+		if err != nil {
+			return istore.ObjectInfo{}, err
+		}
+	} else {
+		return istore.ObjectInfo{}, err
+	}
+
+	lastModified := time.Now()
+	if date := resp.Commit.GetCommitter().GetDate(); !date.IsZero() {
+		lastModified = date
+	}
+	return istore.ObjectInfo{
+		Key:          key,
+		Size:         int64(len(data)),
+		ETag:         resp.GetContent().GetSHA(),
+		ContentType:  istore.ContentType(key, opts),
+		LastModified: lastModified,
+	}, nil
+}
+
+// Get 小于 1MB 的文件内容随元数据返回，更大的文件再从 download_url 下载
+func (s *Store) Get(ctx context.Context, key string) (io.ReadCloser, istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	file, info, err := s.getContents(ctx, key)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	if int64(len(content)) == info.Size {
+		return io.NopCloser(strings.NewReader(content)), info, nil
+	}
+
+	req, err := s.g.Client.NewRequest(http.MethodGet, file.GetDownloadURL(), nil)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, info.Size))
+	if _, err := s.g.Client.Do(ctx, req, buf); err != nil {
+		return nil, istore.ObjectInfo{}, convertError(key, err)
+	}
+	return io.NopCloser(buf), info, nil
+}
+
+func (s *Store) Stat(ctx context.Context, key string) (istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	_, info, err := s.getContents(ctx, key)
+	return info, err
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return err
+	}
+	file, _, err := s.getContents(ctx, key)
+	if errors.Is(err, istore.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	fileOpts := s.fileOptions()
+	fileOpts.SHA = file.SHA
+	_, _, err = s.g.Client.Repositories.DeleteFile(ctx, s.g.Owner, s.g.Repo, key, fileOpts)
+	return err
+}
+
+// List 从 prefix 所在的目录开始逐层请求，按 key 排序后回调；目录较多时请求数也较多
+func (s *Store) List(ctx context.Context, prefix string, f func(info istore.ObjectInfo) error) error {
+	start := ""
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		start = strings.TrimPrefix(path.Clean("/"+prefix[:i]), "/")
+	}
+
+	var infos []istore.ObjectInfo
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		_, entries, _, err := s.g.Client.Repositories.GetContents(ctx, s.g.Owner, s.g.Repo, dir, s.getOptions())
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			entryPath := entry.GetPath()
+			switch entry.GetType() {
+			case "dir":
+				// 只进入可能包含 prefix 的目录
+				if strings.HasPrefix(entryPath+"/", prefix) || strings.HasPrefix(prefix, entryPath+"/") {
+					if err := walk(entryPath); err != nil {
+						return err
+					}
+				}
+			case "file":
+				if strings.HasPrefix(entryPath, prefix) {
+					infos = append(infos, contentInfo(entry, time.Time{}))
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(start); err != nil && !errors.Is(convertError(start, err), istore.ErrNotFound) {
+		return err
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	for _, info := range infos {
+		if err := f(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PresignGet 返回 download_url，私有仓库的链接带临时 token
+func (s *Store) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	file, _, err := s.getContents(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return file.GetDownloadURL(), nil
+}
+
+// Copy GitHub 没有复制接口，下载后重新上传
+func (s *Store) Copy(ctx context.Context, src, dst string) (istore.ObjectInfo, error) {
+	r, info, err := s.Get(ctx, src)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	defer r.Close()
+	return s.Put(ctx, dst, r, info.Size, istore.PutOptions{})
+}
+
+// getContents key 为目录时也返回 ErrNotFound
+func (s *Store) getContents(ctx context.Context, key string) (*github.RepositoryContent, istore.ObjectInfo, error) {
+	file, _, resp, err := s.g.Client.Repositories.GetContents(ctx, s.g.Owner, s.g.Repo, key, s.getOptions())
+	if err != nil {
+		return nil, istore.ObjectInfo{}, convertError(key, err)
+	}
+	if file == nil {
+		return nil, istore.ObjectInfo{}, fmt.Errorf("%w: %s", istore.ErrNotFound, key)
+	}
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return file, contentInfo(file, lastModified), nil
+}
+
+func (s *Store) getOptions() *github.RepositoryContentGetOptions {
+	return &github.RepositoryContentGetOptions{Ref: s.g.Branch}
+}
+
+// fileOptions 提交信息与 store.GithubApi 保持一致
+func (s *Store) fileOptions() *github.RepositoryContentFileOptions {
+	opts := &github.RepositoryContentFileOptions{
+		Message:   github.String(time.Now().Format("2006-01-02 15:04:05")),
+		Committer: &github.CommitAuthor{Name: github.String("a ghost"), Email: github.String("ghost@ghost.com")},
+	}
+	if len(s.g.Branch) > 0 {
+		opts.Branch = github.String(s.g.Branch)
+	}
+	return opts
+}
+
+func contentInfo(content *github.RepositoryContent, lastModified time.Time) istore.ObjectInfo {
+	return istore.ObjectInfo{
+		Key:          content.GetPath(),
+		Size:         int64(content.GetSize()),
+		ETag:         content.GetSHA(),
+		ContentType:  istore.ContentType(content.GetPath(), istore.PutOptions{}),
+		LastModified: lastModified,
+	}
+}
+
+// convertError 404 转为 istore.ErrNotFound
+func convertError(key string, err error) error {
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", istore.ErrNotFound, key)
+	}
+	return err
+}
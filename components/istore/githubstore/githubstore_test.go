@@ -0,0 +1,178 @@
+package githubstore
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/cute-angelia/go-xutils/components/istore/storetest"
+	"github.com/cute-angelia/go-xutils/utils/store"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// inlineLimit 超过该大小的文件不随元数据返回内容，走 download_url
+const inlineLimit = 1 << 10
+
+// fakeRepo 模拟 contents API 的一个仓库
+type fakeRepo struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	url   string
+}
+
+func (f *fakeRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/raw/") {
+		data, ok := f.files[strings.TrimPrefix(r.URL.Path, "/raw/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+		return
+	}
+
+	p := strings.Trim(strings.TrimPrefix(r.URL.Path, "/repos/o/r/contents"), "/")
+	switch r.Method {
+	case http.MethodGet:
+		if data, ok := f.files[p]; ok {
+			content := f.content(p, data)
+			if len(data) <= inlineLimit {
+				content["encoding"] = "base64"
+				content["content"] = base64.StdEncoding.EncodeToString(data)
+			}
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+			json.NewEncoder(w).Encode(content)
+			return
+		}
+		entries := f.list(p)
+		if len(entries) == 0 {
+			f.notFound(w)
+			return
+		}
+		json.NewEncoder(w).Encode(entries)
+
+	case http.MethodPut:
+		var opts github.RepositoryContentFileOptions
+		json.NewDecoder(r.Body).Decode(&opts)
+		if old, ok := f.files[p]; ok != (opts.SHA != nil) || ok && sha(old) != opts.GetSHA() {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"sha mismatch"}`))
+			return
+		}
+		f.files[p] = opts.Content
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": f.content(p, opts.Content),
+			"commit":  map[string]interface{}{"committer": map[string]interface{}{"date": time.Now()}},
+		})
+
+	case http.MethodDelete:
+		var opts github.RepositoryContentFileOptions
+		json.NewDecoder(r.Body).Decode(&opts)
+		old, ok := f.files[p]
+		if !ok {
+			f.notFound(w)
+			return
+		}
+		if sha(old) != opts.GetSHA() {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		delete(f.files, p)
+		w.Write([]byte(`{}`))
+	}
+}
+
+func (f *fakeRepo) content(p string, data []byte) map[string]interface{} {
+	return map[string]interface{}{
+		"type":         "file",
+		"path":         p,
+		"name":         p[strings.LastIndex(p, "/")+1:],
+		"size":         len(data),
+		"sha":          sha(data),
+		"download_url": f.url + "/raw/" + p,
+	}
+}
+
+// list dir 下一层的文件和目录
+func (f *fakeRepo) list(dir string) []map[string]interface{} {
+	prefix := ""
+	if len(dir) > 0 {
+		prefix = dir + "/"
+	}
+	seen := map[string]bool{}
+	var entries []map[string]interface{}
+	for p, data := range f.files {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(p, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			if sub := prefix + rest[:i]; !seen[sub] {
+				seen[sub] = true
+				entries = append(entries, map[string]interface{}{"type": "dir", "path": sub, "name": rest[:i]})
+			}
+			continue
+		}
+		entries = append(entries, f.content(p, data))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i]["path"].(string) < entries[j]["path"].(string) })
+	return entries
+}
+
+func (f *fakeRepo) notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"message":"Not Found"}`))
+}
+
+func sha(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func newTestStore(t *testing.T) *Store {
+	repo := &fakeRepo{files: map[string][]byte{}}
+	server := httptest.NewServer(repo)
+	t.Cleanup(server.Close)
+	repo.url = server.URL
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return New(&store.GithubApi{Owner: "o", Repo: "r", Client: client})
+}
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) istore.ObjectStore {
+		return newTestStore(t)
+	})
+}
+
+func TestGetLargeFile(t *testing.T) {
+	s := newTestStore(t)
+	content := strings.Repeat("x", inlineLimit*2)
+	_, err := s.Put(context.Background(), "big/file.bin", strings.NewReader(content), -1, istore.PutOptions{})
+	assert.Nil(t, err)
+
+	r, info, err := s.Get(context.Background(), "big/file.bin")
+	if assert.Nil(t, err) {
+		data, _ := io.ReadAll(r)
+		r.Close()
+		assert.Equal(t, content, string(data))
+		assert.Equal(t, int64(len(content)), info.Size)
+	}
+}
@@ -0,0 +1,232 @@
+package localstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/cute-angelia/go-xutils/components/iupload"
+)
+
+func init() {
+	istore.Register("local", func(config istore.Config) (istore.ObjectStore, error) {
+		if len(config.Dir) == 0 {
+			return nil, fmt.Errorf("%s: local driver requires Dir", istore.PackageName)
+		}
+		return New(config.Dir, config.BaseUrl), nil
+	})
+}
+
+// tempPrefix 上传中的临时文件，List 时跳过
+const tempPrefix = ".istore-"
+
+// Store 本地磁盘存储，key 对应 dir 下的相对路径
+//   - 先写临时文件再重命名，读取不会看到写了一半的文件
+//   - 没有元数据，ContentType 按扩展名推断，ETag 由修改时间和大小生成
+type Store struct {
+	dir     string
+	baseUrl string
+}
+
+var _ istore.ObjectStore = (*Store)(nil)
+
+// New dir 为根目录；baseUrl 为对外访问的地址（如静态文件服务），PresignGet 返回 baseUrl/key，为空时不支持
+func New(dir string, baseUrl string) *Store {
+	return &Store{dir: dir, baseUrl: strings.TrimRight(baseUrl, "/")}
+}
+
+// NewFromIupload 使用 iupload 的上传目录，iupload 上传的文件可以通过 key（UploadFile.Uri）读取
+func NewFromIupload(c *iupload.Component, baseUrl string) *Store {
+	return New(c.UploadDirectory(), baseUrl)
+}
+
+func (s *Store) Put(ctx context.Context, key string, r io.Reader, size int64, opts istore.PutOptions) (istore.ObjectInfo, error) {
+	key, filename, err := s.filename(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+
+	tmp, err := os.CreateTemp(dir, tempPrefix+"*")
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if size >= 0 && n != size {
+		return istore.ObjectInfo{}, fmt.Errorf("%s: read %d bytes, size %d", istore.PackageName, n, size)
+	}
+	// CreateTemp 创建的文件是 0600，其他用户不可读
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	return s.Stat(ctx, key)
+}
+
+func (s *Store) Get(ctx context.Context, key string) (io.ReadCloser, istore.ObjectInfo, error) {
+	key, filename, err := s.filename(key)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, notFound(key, err)
+	}
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		f.Close()
+		return nil, istore.ObjectInfo{}, notFound(key, err)
+	}
+	return f, objectInfo(key, stat), nil
+}
+
+func (s *Store) Stat(ctx context.Context, key string) (istore.ObjectInfo, error) {
+	key, filename, err := s.filename(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	stat, err := os.Stat(filename)
+	if err != nil || stat.IsDir() {
+		return istore.ObjectInfo{}, notFound(key, err)
+	}
+	return objectInfo(key, stat), nil
+}
+
+// Delete 删除文件后清理空目录
+func (s *Store) Delete(ctx context.Context, key string) error {
+	_, filename, err := s.filename(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	root := filepath.Clean(s.dir)
+	for dir := filepath.Dir(filename); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// List 从 prefix 所在的目录开始遍历，按 key 排序后回调
+func (s *Store) List(ctx context.Context, prefix string, f func(info istore.ObjectInfo) error) error {
+	start := s.dir
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		start = filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+prefix[:i])))
+	}
+
+	var infos []istore.ObjectInfo
+	err := filepath.WalkDir(start, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, filename)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		stat, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		infos = append(infos, objectInfo(key, stat))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	for _, info := range infos {
+		if err := f(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PresignGet 返回 baseUrl/key，不签名，expiry 无效
+func (s *Store) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if len(s.baseUrl) == 0 {
+		return "", istore.ErrNotSupported
+	}
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return s.baseUrl + "/" + (&url.URL{Path: info.Key}).EscapedPath(), nil
+}
+
+func (s *Store) Copy(ctx context.Context, src, dst string) (istore.ObjectInfo, error) {
+	r, info, err := s.Get(ctx, src)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	defer r.Close()
+	return s.Put(ctx, dst, r, info.Size, istore.PutOptions{})
+}
+
+// filename key 对应的文件
+func (s *Store) filename(key string) (string, string, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return key, filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+func objectInfo(key string, stat fs.FileInfo) istore.ObjectInfo {
+	return istore.ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		ETag:         fmt.Sprintf("%x-%x", stat.ModTime().UnixNano(), stat.Size()),
+		ContentType:  istore.ContentType(key, istore.PutOptions{}),
+		LastModified: stat.ModTime(),
+	}
+}
+
+func notFound(key string, err error) error {
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", istore.ErrNotFound, key)
+	}
+	return err
+}
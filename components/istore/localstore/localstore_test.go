@@ -0,0 +1,57 @@
+package localstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/cute-angelia/go-xutils/components/istore/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) istore.ObjectStore {
+		return New(t.TempDir(), "http://static.example.com/")
+	})
+}
+
+func TestKeyStaysInDir(t *testing.T) {
+	root := t.TempDir()
+	s := New(filepath.Join(root, "data"), "")
+
+	info, err := s.Put(context.Background(), "../../escape.txt", strings.NewReader("x"), 1, istore.PutOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "escape.txt", info.Key)
+	assert.FileExists(t, filepath.Join(root, "data", "escape.txt"))
+
+	_, err = s.PresignGet(context.Background(), "escape.txt", time.Minute)
+	assert.ErrorIs(t, err, istore.ErrNotSupported)
+}
+
+func TestDeleteRemovesEmptyDirs(t *testing.T) {
+	root := t.TempDir()
+	s := New(root, "")
+
+	_, err := s.Put(context.Background(), "a/b/c.txt", strings.NewReader("x"), 1, istore.PutOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, s.Delete(context.Background(), "a/b/c.txt"))
+
+	_, err = os.Stat(filepath.Join(root, "a"))
+	assert.True(t, os.IsNotExist(err))
+	assert.DirExists(t, root)
+}
+
+func TestPutFileMode(t *testing.T) {
+	root := t.TempDir()
+	s := New(root, "")
+
+	_, err := s.Put(context.Background(), "a.txt", strings.NewReader("x"), 1, istore.PutOptions{})
+	assert.Nil(t, err)
+	stat, err := os.Stat(filepath.Join(root, "a.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0644), stat.Mode().Perm())
+}
@@ -0,0 +1,145 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+)
+
+func init() {
+	istore.Register("mem", func(config istore.Config) (istore.ObjectStore, error) {
+		return New(), nil
+	})
+}
+
+type object struct {
+	data []byte
+	info istore.ObjectInfo
+}
+
+// Store 内存存储，用于测试或开发环境
+type Store struct {
+	mu      sync.RWMutex
+	objects map[string]object
+}
+
+var _ istore.ObjectStore = (*Store)(nil)
+
+// New 创建空的内存存储
+func New() *Store {
+	return &Store{objects: map[string]object{}}
+}
+
+func (s *Store) Put(ctx context.Context, key string, r io.Reader, size int64, opts istore.PutOptions) (istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return istore.ObjectInfo{}, fmt.Errorf("%s: read %d bytes, size %d", istore.PackageName, len(data), size)
+	}
+
+	sum := md5.Sum(data)
+	info := istore.ObjectInfo{
+		Key:          key,
+		Size:         int64(len(data)),
+		ETag:         hex.EncodeToString(sum[:]),
+		ContentType:  istore.ContentType(key, opts),
+		LastModified: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.objects[key] = object{data: data, info: info}
+	s.mu.Unlock()
+	return info, nil
+}
+
+func (s *Store) Get(ctx context.Context, key string) (io.ReadCloser, istore.ObjectInfo, error) {
+	obj, err := s.get(key)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), obj.info, nil
+}
+
+func (s *Store) Stat(ctx context.Context, key string) (istore.ObjectInfo, error) {
+	obj, err := s.get(key)
+	return obj.info, err
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Store) List(ctx context.Context, prefix string, f func(info istore.ObjectInfo) error) error {
+	s.mu.RLock()
+	var infos []istore.ObjectInfo
+	for key, obj := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, obj.info)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := f(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PresignGet 返回 mem://key，只用于测试
+func (s *Store) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	obj, err := s.get(key)
+	if err != nil {
+		return "", err
+	}
+	return "mem://" + obj.info.Key, nil
+}
+
+func (s *Store) Copy(ctx context.Context, src, dst string) (istore.ObjectInfo, error) {
+	obj, err := s.get(src)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	return s.Put(ctx, dst, bytes.NewReader(obj.data), int64(len(obj.data)), istore.PutOptions{ContentType: obj.info.ContentType})
+}
+
+func (s *Store) get(key string) (object, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return object{}, err
+	}
+	s.mu.RLock()
+	obj, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return object{}, fmt.Errorf("%w: %s", istore.ErrNotFound, key)
+	}
+	return obj, nil
+}
@@ -0,0 +1,14 @@
+package memstore
+
+import (
+	"testing"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/cute-angelia/go-xutils/components/istore/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) istore.ObjectStore {
+		return New()
+	})
+}
@@ -0,0 +1,173 @@
+package miniostore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/iminio"
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/minio/minio-go/v7"
+)
+
+func init() {
+	istore.Register("minio", func(config istore.Config) (istore.ObjectStore, error) {
+		if len(config.Endpoint) == 0 || len(config.Bucket) == 0 {
+			return nil, fmt.Errorf("%s: minio driver requires Endpoint and Bucket", istore.PackageName)
+		}
+		c := iminio.New(
+			iminio.WithEndpoint(config.Endpoint),
+			iminio.WithAccesskeyId(config.AccessKey),
+			iminio.WithSecretaccessKey(config.SecretKey),
+			iminio.WithUseSSL(config.UseSSL),
+		)
+		return New(c, config.Bucket), nil
+	})
+}
+
+// Store minio / S3 存储
+//   - 上传走 iminio.PutObjectContext，沿用组件的限速、分片和 ReplaceMode 配置
+//   - ReplaceMode 为 ReplaceModeTwo 时实际 key 会变化，以返回的 ObjectInfo.Key 为准
+type Store struct {
+	c      *iminio.Component
+	bucket string
+}
+
+var _ istore.ObjectStore = (*Store)(nil)
+
+// New 使用已有的 iminio 组件，所有操作限定在 bucket 内
+func New(c *iminio.Component, bucket string) *Store {
+	return &Store{c: c, bucket: bucket}
+}
+
+func (s *Store) Put(ctx context.Context, key string, r io.Reader, size int64, opts istore.PutOptions) (istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	uploadInfo, err := s.c.PutObjectContext(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: istore.ContentType(key, opts),
+	})
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+
+	lastModified := uploadInfo.LastModified
+	if lastModified.IsZero() {
+		lastModified = time.Now()
+	}
+	return istore.ObjectInfo{
+		Key:          uploadInfo.Key,
+		Size:         uploadInfo.Size,
+		ETag:         uploadInfo.ETag,
+		ContentType:  istore.ContentType(key, opts),
+		LastModified: lastModified,
+	}, nil
+}
+
+func (s *Store) Get(ctx context.Context, key string) (io.ReadCloser, istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	obj, err := s.c.Client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, istore.ObjectInfo{}, convertError(key, err)
+	}
+	// GetObject 不发请求，Stat 时才知道是否存在
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, istore.ObjectInfo{}, convertError(key, err)
+	}
+	return obj, objectInfo(stat), nil
+}
+
+func (s *Store) Stat(ctx context.Context, key string) (istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	stat, err := s.c.Client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return istore.ObjectInfo{}, convertError(key, err)
+	}
+	return objectInfo(stat), nil
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return err
+	}
+	return s.c.Client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// List S3 按 key 字典序返回，提前结束时取消 ctx 让 minio 的 goroutine 退出
+func (s *Store) List(ctx context.Context, prefix string, f func(info istore.ObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for obj := range s.c.Client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if err := f(objectInfo(obj)); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *Store) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	u, err := s.c.Client.PresignedGetObject(ctx, s.bucket, key, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// Copy 服务端复制，不经过本地
+func (s *Store) Copy(ctx context.Context, src, dst string) (istore.ObjectInfo, error) {
+	src, err := istore.CleanKey(src)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	dst, err = istore.CleanKey(dst)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	_, err = s.c.Client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucket, Object: dst},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: src},
+	)
+	if err != nil {
+		return istore.ObjectInfo{}, convertError(src, err)
+	}
+	return s.Stat(ctx, dst)
+}
+
+func objectInfo(obj minio.ObjectInfo) istore.ObjectInfo {
+	return istore.ObjectInfo{
+		Key:          obj.Key,
+		Size:         obj.Size,
+		ETag:         obj.ETag,
+		ContentType:  obj.ContentType,
+		LastModified: obj.LastModified,
+	}
+}
+
+// convertError NoSuchKey 转为 istore.ErrNotFound
+func convertError(key string, err error) error {
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", istore.ErrNotFound, key)
+	}
+	return err
+}
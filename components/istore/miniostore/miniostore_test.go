@@ -0,0 +1,59 @@
+package miniostore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cute-angelia/go-xutils/components/iminio"
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/stretchr/testify/assert"
+)
+
+// 只模拟 bucket location 和对象不存在，验证错误转换
+func newNotFoundServer(t *testing.T) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["location"]; ok {
+			w.Write([]byte(`<LocationConstraint>us-east-1</LocationConstraint>`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		if r.Method != http.MethodHead {
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestNotFound(t *testing.T) {
+	server := newNotFoundServer(t)
+	c := iminio.New(
+		iminio.WithEndpoint(strings.TrimPrefix(server.URL, "http://")),
+		iminio.WithAccesskeyId("ak"),
+		iminio.WithSecretaccessKey("sk"),
+	)
+	s := New(c, "bucket")
+
+	_, err := s.Stat(context.Background(), "missing")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+
+	_, _, err = s.Get(context.Background(), "missing")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+
+	_, err = s.Copy(context.Background(), "missing", "dst")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+}
+
+func TestOpen(t *testing.T) {
+	_, err := istore.Open(istore.Config{Driver: "minio"})
+	assert.NotNil(t, err)
+
+	s, err := istore.Open(istore.Config{Driver: "minio", Endpoint: "127.0.0.1:9000", Bucket: "b"})
+	assert.Nil(t, err)
+	assert.IsType(t, &Store{}, s)
+}
@@ -0,0 +1,186 @@
+package ossstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/cute-angelia/go-xutils/components/istore"
+	ioss "github.com/cute-angelia/go-xutils/third_party/oss"
+)
+
+func init() {
+	istore.Register("oss", func(config istore.Config) (istore.ObjectStore, error) {
+		if len(config.Endpoint) == 0 || len(config.Bucket) == 0 {
+			return nil, fmt.Errorf("%s: oss driver requires Endpoint and Bucket", istore.PackageName)
+		}
+		c := ioss.New(
+			ioss.WithEndpoint(config.Endpoint),
+			ioss.WithAccessKeyId(config.AccessKey),
+			ioss.WithAccessKeySecret(config.SecretKey),
+			ioss.WithBucketName(config.Bucket),
+			ioss.WithBucketHost(config.Domain),
+		)
+		if c.Client == nil {
+			return nil, fmt.Errorf("%s: oss driver: invalid endpoint %q", istore.PackageName, config.Endpoint)
+		}
+		return New(c), nil
+	})
+}
+
+// Store 阿里云 OSS 存储
+//   - SDK 不支持 context，ctx 只在发起请求前检查
+//   - ETag 去掉了 OSS 返回的引号
+type Store struct {
+	bucket *oss.Bucket
+}
+
+var _ istore.ObjectStore = (*Store)(nil)
+
+// New 使用已有的 oss 组件，bucket 由组件配置决定
+func New(c *ioss.Component) *Store {
+	return &Store{bucket: c.Client}
+}
+
+func (s *Store) Put(ctx context.Context, key string, r io.Reader, size int64, opts istore.PutOptions) (istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+
+	options := []oss.Option{oss.ContentType(istore.ContentType(key, opts))}
+	if size >= 0 {
+		options = append(options, oss.ContentLength(size))
+	}
+	if err := s.bucket.PutObject(key, r, options...); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	return s.Stat(ctx, key)
+}
+
+func (s *Store) Get(ctx context.Context, key string) (io.ReadCloser, istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	result, err := s.bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: key}, nil)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, convertError(key, err)
+	}
+	return result.Response.Body, headerInfo(key, result.Response.Headers), nil
+}
+
+func (s *Store) Stat(ctx context.Context, key string) (istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	header, err := s.bucket.GetObjectDetailedMeta(key)
+	if err != nil {
+		return istore.ObjectInfo{}, convertError(key, err)
+	}
+	return headerInfo(key, header), nil
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.bucket.DeleteObject(key)
+}
+
+// List 按 marker 分页，OSS 按 key 字典序返回
+func (s *Store) List(ctx context.Context, prefix string, f func(info istore.ObjectInfo) error) error {
+	marker := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := s.bucket.ListObjects(oss.Prefix(prefix), oss.Marker(marker), oss.MaxKeys(1000))
+		if err != nil {
+			return err
+		}
+		for _, obj := range result.Objects {
+			info := istore.ObjectInfo{
+				Key:          obj.Key,
+				Size:         obj.Size,
+				ETag:         strings.Trim(obj.ETag, `"`),
+				LastModified: obj.LastModified,
+			}
+			if err := f(info); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+func (s *Store) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return s.bucket.SignURL(key, oss.HTTPGet, int64(expiry/time.Second))
+}
+
+// Copy 服务端复制，不经过本地
+func (s *Store) Copy(ctx context.Context, src, dst string) (istore.ObjectInfo, error) {
+	src, err := istore.CleanKey(src)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	dst, err = istore.CleanKey(dst)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if _, err := s.bucket.CopyObject(src, dst); err != nil {
+		return istore.ObjectInfo{}, convertError(src, err)
+	}
+	return s.Stat(ctx, dst)
+}
+
+func headerInfo(key string, header http.Header) istore.ObjectInfo {
+	size, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	lastModified, _ := http.ParseTime(header.Get("Last-Modified"))
+	return istore.ObjectInfo{
+		Key:          key,
+		Size:         size,
+		ETag:         strings.Trim(header.Get("ETag"), `"`),
+		ContentType:  header.Get("Content-Type"),
+		LastModified: lastModified,
+	}
+}
+
+// convertError 404 转为 istore.ErrNotFound
+func convertError(key string, err error) error {
+	var serviceErr oss.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", istore.ErrNotFound, key)
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package ossstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+	ioss "github.com/cute-angelia/go-xutils/third_party/oss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		if r.Method != http.MethodHead {
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+		}
+	}))
+	defer server.Close()
+
+	s := New(ioss.New(
+		ioss.WithEndpoint(server.URL),
+		ioss.WithAccessKeyId("ak"),
+		ioss.WithAccessKeySecret("sk"),
+		ioss.WithBucketName("bucket"),
+	))
+
+	_, err := s.Stat(context.Background(), "missing")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+
+	_, _, err = s.Get(context.Background(), "missing")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+
+	_, err = s.Copy(context.Background(), "missing", "dst")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+}
+
+func TestHeaderInfo(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Length", "12")
+	header.Set("ETag", `"abc"`)
+	header.Set("Content-Type", "text/plain")
+	header.Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+
+	info := headerInfo("k", header)
+	assert.Equal(t, int64(12), info.Size)
+	assert.Equal(t, "abc", info.ETag)
+	assert.Equal(t, "text/plain", info.ContentType)
+	assert.Equal(t, 2006, info.LastModified.Year())
+}
@@ -0,0 +1,217 @@
+package qiniustore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/cute-angelia/go-xutils/utils/store"
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func init() {
+	istore.Register("qiniu", func(config istore.Config) (istore.ObjectStore, error) {
+		if len(config.Bucket) == 0 || len(config.Domain) == 0 {
+			return nil, fmt.Errorf("%s: qiniu driver requires Bucket and Domain", istore.PackageName)
+		}
+		q := store.NewQiNiu(config.AccessKey, config.SecretKey, config.Bucket, "")
+		q.Domain = config.Domain
+		return New(q), nil
+	})
+}
+
+// codeNotFound 七牛资源不存在的错误码
+const codeNotFound = 612
+
+// Store 七牛存储
+//   - 读取走下载域名 q.Domain（带 scheme），按私有空间签名，公开空间同样可用
+//   - 表单上传需要知道长度，size 为 -1 时先读入内存
+//   - 管理接口不支持 context，ctx 只在发起请求前检查
+type Store struct {
+	q      *store.Qiniu
+	mac    *auth.Credentials
+	client *http.Client
+}
+
+var _ istore.ObjectStore = (*Store)(nil)
+
+// New 使用已有的七牛配置
+func New(q *store.Qiniu) *Store {
+	return &Store{
+		q:      q,
+		mac:    auth.New(q.Ak, q.Sk),
+		client: &http.Client{},
+	}
+}
+
+func (s *Store) Put(ctx context.Context, key string, r io.Reader, size int64, opts istore.PutOptions) (istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if size < 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return istore.ObjectInfo{}, err
+		}
+		r, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	// scope 带 key 时允许覆盖
+	putPolicy := storage.PutPolicy{Scope: s.q.Bucket + ":" + key}
+	uploader := storage.NewFormUploaderEx(&s.q.QiniuConfig, &storage.Client{Client: s.client})
+	ret := storage.PutRet{}
+	extra := &storage.PutExtra{MimeType: istore.ContentType(key, opts)}
+	if err := uploader.Put(ctx, &ret, putPolicy.UploadToken(s.mac), key, r, size, extra); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	return s.Stat(ctx, key)
+}
+
+// Get 通过下载域名读取，CDN 缓存可能导致覆盖后短时间内读到旧内容
+func (s *Store) Get(ctx context.Context, key string) (io.ReadCloser, istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.privateUrl(key, time.Hour), nil)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, istore.ObjectInfo{}, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, istore.ObjectInfo{}, fmt.Errorf("%w: %s", istore.ErrNotFound, key)
+	case resp.StatusCode != http.StatusOK:
+		resp.Body.Close()
+		return nil, istore.ObjectInfo{}, fmt.Errorf("%s: get %s: %s", istore.PackageName, key, resp.Status)
+	}
+
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return resp.Body, istore.ObjectInfo{
+		Key:          key,
+		Size:         size,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		ContentType:  resp.Header.Get("Content-Type"),
+		LastModified: lastModified,
+	}, nil
+}
+
+func (s *Store) Stat(ctx context.Context, key string) (istore.ObjectInfo, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	info, err := s.q.BucketManager.Stat(s.q.Bucket, key)
+	if err != nil {
+		return istore.ObjectInfo{}, convertError(key, err)
+	}
+	return objectInfo(key, info.Hash, info.Fsize, info.PutTime, info.MimeType), nil
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := convertError(key, s.q.BucketManager.Delete(s.q.Bucket, key)); err != nil && !errors.Is(err, istore.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// List 按 marker 分页，七牛按 key 字典序返回
+func (s *Store) List(ctx context.Context, prefix string, f func(info istore.ObjectInfo) error) error {
+	marker := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entries, _, nextMarker, hasNext, err := s.q.BucketManager.ListFiles(s.q.Bucket, prefix, "", marker, 1000)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsEmpty() {
+				continue
+			}
+			if err := f(objectInfo(entry.Key, entry.Hash, entry.Fsize, entry.PutTime, entry.MimeType)); err != nil {
+				return err
+			}
+		}
+		if !hasNext {
+			return nil
+		}
+		marker = nextMarker
+	}
+}
+
+func (s *Store) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := istore.CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return s.privateUrl(key, expiry), nil
+}
+
+// Copy 服务端复制，dst 已存在时覆盖
+func (s *Store) Copy(ctx context.Context, src, dst string) (istore.ObjectInfo, error) {
+	src, err := istore.CleanKey(src)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	dst, err = istore.CleanKey(dst)
+	if err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return istore.ObjectInfo{}, err
+	}
+	if err := s.q.BucketManager.Copy(s.q.Bucket, src, s.q.Bucket, dst, true); err != nil {
+		return istore.ObjectInfo{}, convertError(src, err)
+	}
+	return s.Stat(ctx, dst)
+}
+
+func (s *Store) privateUrl(key string, expiry time.Duration) string {
+	return storage.MakePrivateURLv2(s.mac, s.q.Domain, key, time.Now().Add(expiry).Unix())
+}
+
+// objectInfo putTime 单位为 100 纳秒
+func objectInfo(key, hash string, size, putTime int64, mimeType string) istore.ObjectInfo {
+	return istore.ObjectInfo{
+		Key:          key,
+		Size:         size,
+		ETag:         hash,
+		ContentType:  mimeType,
+		LastModified: time.Unix(0, putTime*100),
+	}
+}
+
+// convertError 612 转为 istore.ErrNotFound
+func convertError(key string, err error) error {
+	var errInfo *storage.ErrorInfo
+	if errors.As(err, &errInfo) && errInfo.Code == codeNotFound {
+		return fmt.Errorf("%w: %s", istore.ErrNotFound, key)
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package qiniustore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/cute-angelia/go-xutils/utils/store"
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 私有链接需要带签名
+		if len(r.URL.Query().Get("token")) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/a/b.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"hash"`)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	q := store.NewQiNiu("ak", "sk", "bucket", "")
+	q.Domain = server.URL
+	s := New(q)
+
+	r, info, err := s.Get(context.Background(), "/a/b.txt")
+	if assert.Nil(t, err) {
+		data, _ := io.ReadAll(r)
+		r.Close()
+		assert.Equal(t, "hello", string(data))
+		assert.Equal(t, "a/b.txt", info.Key)
+		assert.Equal(t, int64(5), info.Size)
+		assert.Equal(t, "hash", info.ETag)
+	}
+
+	_, _, err = s.Get(context.Background(), "missing")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+}
+
+func TestConvertError(t *testing.T) {
+	err := convertError("k", &storage.ErrorInfo{Code: codeNotFound})
+	assert.True(t, errors.Is(err, istore.ErrNotFound))
+
+	other := &storage.ErrorInfo{Code: 631}
+	assert.Equal(t, error(other), convertError("k", other))
+	assert.Nil(t, convertError("k", nil))
+}
+
+func TestObjectInfo(t *testing.T) {
+	now := time.Now().Truncate(time.Microsecond)
+	info := objectInfo("k", "hash", 3, now.UnixNano()/100, "text/plain")
+	assert.True(t, now.Equal(info.LastModified))
+}
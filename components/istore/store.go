@@ -0,0 +1,84 @@
+package istore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+)
+
+const PackageName = "component.istore"
+
+var (
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("istore: object not found")
+	// ErrNotSupported 后端不支持该操作，如本地存储没有配置 BaseUrl 时的 PresignGet
+	ErrNotSupported = errors.New("istore: operation not supported")
+	// ErrInvalidKey key 为空
+	ErrInvalidKey = errors.New("istore: invalid key")
+)
+
+type (
+	// ObjectStore 对象存储，key 为 / 分隔的相对路径，bucket 在创建后端时指定
+	// 所有实现需通过 storetest.Run 的一致性测试
+	ObjectStore interface {
+		// Put 上传，size 为 -1 时长度未知；key 已存在时覆盖
+		Put(ctx context.Context, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error)
+
+		// Get 读取，调用方负责 Close；不存在返回 ErrNotFound
+		Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+
+		// Stat 对象信息，不存在返回 ErrNotFound
+		Stat(ctx context.Context, key string) (ObjectInfo, error)
+
+		// Delete 删除，key 不存在不报错
+		Delete(ctx context.Context, key string) error
+
+		// List 按 key 顺序遍历 prefix 下的所有对象（包括子目录）
+		// f 返回错误时停止遍历并返回该错误
+		List(ctx context.Context, prefix string, f func(info ObjectInfo) error) error
+
+		// PresignGet 有效期为 expiry 的下载链接，不支持签名的后端返回公开链接或 ErrNotSupported
+		PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+
+		// Copy 复制到 dst，dst 已存在时覆盖；src 不存在返回 ErrNotFound
+		Copy(ctx context.Context, src, dst string) (ObjectInfo, error)
+	}
+
+	// ObjectInfo 对象信息，后端不提供的字段为空
+	ObjectInfo struct {
+		Key          string
+		Size         int64
+		ETag         string // 各后端算法不同，只用于判断是否变化
+		ContentType  string
+		LastModified time.Time
+	}
+
+	// PutOptions 上传选项
+	PutOptions struct {
+		ContentType string // 为空时按扩展名推断
+	}
+)
+
+// ContentType opts.ContentType 为空时按扩展名推断，都没有时为 application/octet-stream
+func ContentType(key string, opts PutOptions) string {
+	if len(opts.ContentType) > 0 {
+		return opts.ContentType
+	}
+	if contentType := mime.TypeByExtension(path.Ext(key)); len(contentType) > 0 {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// CleanKey 规范化 key：去掉开头的 /，合并重复的 /，.. 不会超出根目录；为空时返回 ErrInvalidKey
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if len(key) == 0 {
+		return "", ErrInvalidKey
+	}
+	return key, nil
+}
@@ -0,0 +1,245 @@
+// Package storetest 是 istore.ObjectStore 的行为一致性测试，每个后端都应通过
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) istore.ObjectStore {
+//			return memstore.New()
+//		})
+//	}
+package storetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/istore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory 每个用例调用一次，返回一个空的存储
+type Factory func(t *testing.T) istore.ObjectStore
+
+type Option func(o *options)
+
+type options struct {
+	skip map[string]bool
+}
+
+// WithSkip 跳过指定用例
+func WithSkip(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.skip[name] = true
+		}
+	}
+}
+
+type testCase struct {
+	name string
+	run  func(t *testing.T, s istore.ObjectStore)
+}
+
+var cases = []testCase{
+	{"PutGet", testPutGet},
+	{"PutUnknownSize", testPutUnknownSize},
+	{"Overwrite", testOverwrite},
+	{"NotFound", testNotFound},
+	{"Delete", testDelete},
+	{"List", testList},
+	{"ListStopsOnError", testListStopsOnError},
+	{"Copy", testCopy},
+	{"PresignGet", testPresignGet},
+	{"Concurrency", testConcurrency},
+}
+
+// Run 执行所有用例
+func Run(t *testing.T, factory Factory, opts ...Option) {
+	o := &options{skip: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if o.skip[tc.name] {
+				t.Skip("skipped by option")
+			}
+			tc.run(t, factory(t))
+		})
+	}
+}
+
+func put(t *testing.T, s istore.ObjectStore, key, content string) istore.ObjectInfo {
+	info, err := s.Put(context.Background(), key, strings.NewReader(content), int64(len(content)), istore.PutOptions{})
+	require.Nil(t, err)
+	return info
+}
+
+func read(t *testing.T, s istore.ObjectStore, key string) (string, istore.ObjectInfo) {
+	r, info, err := s.Get(context.Background(), key)
+	require.Nil(t, err)
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.Nil(t, err)
+	return string(data), info
+}
+
+func testPutGet(t *testing.T, s istore.ObjectStore) {
+	info := put(t, s, "a/b.txt", "hello")
+	assert.Equal(t, "a/b.txt", info.Key)
+	assert.Equal(t, int64(5), info.Size)
+
+	data, info := read(t, s, "a/b.txt")
+	assert.Equal(t, "hello", data)
+	assert.Equal(t, "a/b.txt", info.Key)
+	assert.Equal(t, int64(5), info.Size)
+
+	stat, err := s.Stat(context.Background(), "a/b.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), stat.Size)
+	assert.NotEmpty(t, stat.ETag)
+	assert.True(t, strings.HasPrefix(stat.ContentType, "text/plain"), stat.ContentType)
+	assert.False(t, stat.LastModified.IsZero())
+}
+
+func testPutUnknownSize(t *testing.T, s istore.ObjectStore) {
+	content := bytes.Repeat([]byte("x"), 100<<10)
+	_, err := s.Put(context.Background(), "unknown.bin", bytes.NewReader(content), -1, istore.PutOptions{})
+	assert.Nil(t, err)
+
+	stat, err := s.Stat(context.Background(), "unknown.bin")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), stat.Size)
+}
+
+func testOverwrite(t *testing.T, s istore.ObjectStore) {
+	before := put(t, s, "k.txt", "v1")
+	after := put(t, s, "k.txt", "version2")
+
+	data, info := read(t, s, "k.txt")
+	assert.Equal(t, "version2", data)
+	assert.Equal(t, int64(8), info.Size)
+	if len(before.ETag) > 0 {
+		assert.NotEqual(t, before.ETag, after.ETag)
+	}
+}
+
+func testNotFound(t *testing.T, s istore.ObjectStore) {
+	_, _, err := s.Get(context.Background(), "missing")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+
+	_, err = s.Stat(context.Background(), "missing")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+}
+
+func testDelete(t *testing.T, s istore.ObjectStore) {
+	put(t, s, "d.txt", "x")
+	assert.Nil(t, s.Delete(context.Background(), "d.txt"))
+
+	_, err := s.Stat(context.Background(), "d.txt")
+	assert.True(t, errors.Is(err, istore.ErrNotFound))
+
+	// 不存在不报错
+	assert.Nil(t, s.Delete(context.Background(), "d.txt"))
+}
+
+func testList(t *testing.T, s istore.ObjectStore) {
+	for _, key := range []string{"p/b.txt", "p/a.txt", "p/sub/c.txt", "q/d.txt", "pp.txt"} {
+		put(t, s, key, key)
+	}
+
+	var keys []string
+	err := s.List(context.Background(), "p/", func(info istore.ObjectInfo) error {
+		keys = append(keys, info.Key)
+		assert.Equal(t, int64(len(info.Key)), info.Size)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"p/a.txt", "p/b.txt", "p/sub/c.txt"}, keys)
+
+	// prefix 不必是目录
+	keys = nil
+	s.List(context.Background(), "p", func(info istore.ObjectInfo) error {
+		keys = append(keys, info.Key)
+		return nil
+	})
+	assert.Equal(t, []string{"p/a.txt", "p/b.txt", "p/sub/c.txt", "pp.txt"}, keys)
+}
+
+func testListStopsOnError(t *testing.T, s istore.ObjectStore) {
+	for _, key := range []string{"l/1", "l/2", "l/3"} {
+		put(t, s, key, key)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err := s.List(context.Background(), "l/", func(info istore.ObjectInfo) error {
+		count++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+}
+
+func testCopy(t *testing.T, s istore.ObjectStore) {
+	put(t, s, "src.txt", "copy me")
+
+	info, err := s.Copy(context.Background(), "src.txt", "dst/copy.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, "dst/copy.txt", info.Key)
+
+	data, _ := read(t, s, "dst/copy.txt")
+	assert.Equal(t, "copy me", data)
+	data, _ = read(t, s, "src.txt")
+	assert.Equal(t, "copy me", data)
+
+	_, err = s.Copy(context.Background(), "missing", "dst/missing")
+	assert.True(t, errors.Is(err, istore.ErrNotFound), err)
+}
+
+func testPresignGet(t *testing.T, s istore.ObjectStore) {
+	put(t, s, "presign.txt", "x")
+
+	url, err := s.PresignGet(context.Background(), "presign.txt", time.Minute)
+	if errors.Is(err, istore.ErrNotSupported) {
+		return
+	}
+	assert.Nil(t, err)
+	assert.Contains(t, url, "presign.txt")
+}
+
+func testConcurrency(t *testing.T, s istore.ObjectStore) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				key := "c/" + string(rune('a'+i))
+				content := strings.Repeat(key, j+1)
+				_, err := s.Put(context.Background(), key, strings.NewReader(content), int64(len(content)), istore.PutOptions{})
+				assert.Nil(t, err)
+				r, _, err := s.Get(context.Background(), key)
+				if assert.Nil(t, err) {
+					io.Copy(io.Discard, r)
+					r.Close()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	count := 0
+	s.List(context.Background(), "c/", func(info istore.ObjectInfo) error {
+		count++
+		return nil
+	})
+	assert.Equal(t, 8, count)
+}
@@ -44,6 +44,11 @@ func newComponent(config *config) *Component {
 	}
 }
 
+// UploadDirectory 上传文件保存的根目录
+func (that *Component) UploadDirectory() string {
+	return that.config.UploadDirectory
+}
+
 func (that *Component) Upload(file *multipart.FileHeader, folder string, fileName string, fileType FileType) (uf *UploadFile, e error) {
 	if e = that.checkFile(file, fileType); e != nil {
 		return
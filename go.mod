@@ -5,6 +5,7 @@ go 1.26.0
 require (
 	git.mills.io/prologic/bitcask v1.0.2
 	github.com/ajg/form v1.5.1
+	github.com/alicebob/miniredis/v2 v2.39.0
 	github.com/aliyun/aliyun-oss-go-sdk v2.1.10+incompatible
 	github.com/anthonynsimon/bild v0.13.0
 	github.com/astaxie/beego v1.12.3
@@ -45,6 +46,7 @@ require (
 	github.com/spf13/viper v1.11.0
 	github.com/stretchr/testify v1.11.1
 	github.com/tidwall/buntdb v1.3.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	github.com/yitter/idgenerator-go v1.3.3
 	golang.org/x/crypto v0.45.0
 	golang.org/x/image v0.18.0
@@ -131,6 +133,8 @@ require (
 	github.com/tidwall/rtred v0.1.2 // indirect
 	github.com/tidwall/tinyqueue v0.1.1 // indirect
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 	golang.org/x/arch v0.24.0 // indirect
 	golang.org/x/exp v0.0.0-20260218203240-3dfff04db8fa // indirect
 	golang.org/x/sys v0.41.0 // indirect
@@ -0,0 +1,136 @@
+package irate
+
+import (
+	"context"
+	"io"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// chunkSize 每次读写的最大字节数，限速更平滑
+const chunkSize = 32 * 1024
+
+// GlobalBandwidth 全局限速，所有使用 NewReader / NewWriter 的传输共享，默认不限速
+var GlobalBandwidth = NewBandwidth(0)
+
+// Bandwidth 令牌桶限速，单位 字节/秒，0 表示不限速
+// 多个传输共享同一个 Bandwidth 时共享带宽，运行中可以 SetLimit 修改
+type Bandwidth struct {
+	limiter *rate.Limiter
+}
+
+// NewBandwidth bytesPerSecond 为 0 不限速
+func NewBandwidth(bytesPerSecond int64) *Bandwidth {
+	b := &Bandwidth{limiter: rate.NewLimiter(rate.Inf, 0)}
+	b.SetLimit(bytesPerSecond)
+	return b
+}
+
+// SetLimit 修改限速，正在进行的传输从下一次读写开始生效
+func (b *Bandwidth) SetLimit(bytesPerSecond int64) {
+	if bytesPerSecond <= 0 {
+		b.limiter.SetLimit(rate.Inf)
+		return
+	}
+	// 桶容量为 1 秒的流量，空闲后最多突发 1 秒
+	b.limiter.SetBurst(int(min(bytesPerSecond, math.MaxInt32)))
+	b.limiter.SetLimit(rate.Limit(bytesPerSecond))
+}
+
+// Limit 当前限速，0 表示不限速
+func (b *Bandwidth) Limit() int64 {
+	limit := b.limiter.Limit()
+	if limit == rate.Inf {
+		return 0
+	}
+	return int64(limit)
+}
+
+// WaitN 等待 n 个字节的令牌
+func (b *Bandwidth) WaitN(ctx context.Context, n int) error {
+	for n > 0 {
+		if b.limiter.Limit() == rate.Inf {
+			return nil
+		}
+		take := min(n, b.limiter.Burst())
+		if err := b.limiter.WaitN(ctx, take); err != nil {
+			return err
+		}
+		n -= take
+	}
+	return nil
+}
+
+// Limited 是否有任一限速生效，都不限速时调用方可以不包装，保留 io.ReaderAt 等能力
+func Limited(bandwidths ...*Bandwidth) bool {
+	for _, b := range bandwidths {
+		if b != nil && b.Limit() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type reader struct {
+	ctx        context.Context
+	r          io.Reader
+	bandwidths []*Bandwidth
+}
+
+// NewReader 读取时依次等待 bandwidths 的令牌，nil 忽略；一般传入 GlobalBandwidth、组件和单个传输的限速
+func NewReader(ctx context.Context, r io.Reader, bandwidths ...*Bandwidth) io.Reader {
+	return &reader{ctx: ctx, r: r, bandwidths: bandwidths}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > chunkSize {
+		p = p[:chunkSize]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := wait(r.ctx, n, r.bandwidths); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+type writer struct {
+	ctx        context.Context
+	w          io.Writer
+	bandwidths []*Bandwidth
+}
+
+// NewWriter 写入前依次等待 bandwidths 的令牌，nil 忽略
+func NewWriter(ctx context.Context, w io.Writer, bandwidths ...*Bandwidth) io.Writer {
+	return &writer{ctx: ctx, w: w, bandwidths: bandwidths}
+}
+
+func (w *writer) Write(p []byte) (written int, err error) {
+	for len(p) > 0 {
+		chunk := p[:min(len(p), chunkSize)]
+		if err := wait(w.ctx, len(chunk), w.bandwidths); err != nil {
+			return written, err
+		}
+		n, err := w.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+func wait(ctx context.Context, n int, bandwidths []*Bandwidth) error {
+	for _, b := range bandwidths {
+		if b == nil {
+			continue
+		}
+		if err := b.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package irate
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBandwidthReader(t *testing.T) {
+	b := NewBandwidth(100 * 1024)
+	data := make([]byte, 200*1024)
+
+	// 第一秒的突发不限速，剩余 100KB 需要约 1 秒
+	start := time.Now()
+	n, err := io.Copy(io.Discard, NewReader(context.Background(), bytes.NewReader(data), GlobalBandwidth, b, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.InDelta(t, time.Second, time.Since(start), float64(300*time.Millisecond))
+}
+
+func TestBandwidthWriter(t *testing.T) {
+	b := NewBandwidth(50 * 1024)
+	var buf bytes.Buffer
+
+	start := time.Now()
+	n, err := NewWriter(context.Background(), &buf, b).Write(make([]byte, 100*1024))
+	assert.Nil(t, err)
+	assert.Equal(t, 100*1024, n)
+	assert.Equal(t, 100*1024, buf.Len())
+	assert.InDelta(t, time.Second, time.Since(start), float64(300*time.Millisecond))
+}
+
+func TestBandwidthSetLimit(t *testing.T) {
+	b := NewBandwidth(0)
+	assert.False(t, Limited(b, nil))
+
+	b.SetLimit(10 * 1024)
+	assert.True(t, Limited(b))
+	assert.Equal(t, int64(10*1024), b.Limit())
+
+	// 运行中取消限速，等待中的读取继续
+	r := NewReader(context.Background(), bytes.NewReader(make([]byte, 1<<20)), b)
+	time.AfterFunc(200*time.Millisecond, func() { b.SetLimit(0) })
+
+	start := time.Now()
+	_, err := io.Copy(io.Discard, r)
+	assert.Nil(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, int64(0), b.Limit())
+}
+
+// TestReaderContext ctx 结束时等待中的读取返回错误
+func TestReaderContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := io.Copy(io.Discard, NewReader(ctx, bytes.NewReader(make([]byte, 10*1024)), NewBandwidth(1024)))
+	assert.NotNil(t, err)
+}
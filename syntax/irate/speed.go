@@ -0,0 +1,38 @@
+package irate
+
+import "time"
+
+// Speed 统计传输速度，指数平滑避免速度跳动；不是并发安全的，由调用方加锁
+type Speed struct {
+	last   int64
+	lastAt time.Time
+	speed  float64
+}
+
+// NewSpeed done 为开始统计时已完成的字节数，续传时不计入速度
+func NewSpeed(done int64) *Speed {
+	return &Speed{last: done, lastAt: time.Now()}
+}
+
+// Update 根据累计完成的字节数更新速度，返回平滑后的 字节/秒
+func (s *Speed) Update(done int64) int64 {
+	now := time.Now()
+	if elapsed := now.Sub(s.lastAt).Seconds(); elapsed > 0 {
+		current := float64(done-s.last) / elapsed
+		if s.speed == 0 {
+			s.speed = current
+		} else {
+			s.speed = 0.3*current + 0.7*s.speed
+		}
+		s.last, s.lastAt = done, now
+	}
+	return int64(s.speed)
+}
+
+// Eta 按当前速度传输 remaining 字节的预计时间，速度未知时为 -1
+func (s *Speed) Eta(remaining int64) time.Duration {
+	if int64(s.speed) <= 0 {
+		return -1
+	}
+	return time.Duration(float64(max(remaining, 0)) / s.speed * float64(time.Second))
+}
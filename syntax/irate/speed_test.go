@@ -0,0 +1,22 @@
+package irate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpeed(t *testing.T) {
+	s := NewSpeed(100)
+	assert.Equal(t, time.Duration(-1), s.Eta(1000))
+
+	// 续传前已完成的字节不计入速度
+	s.lastAt = time.Now().Add(-time.Second)
+	assert.InDelta(t, 1000, s.Update(1100), 10)
+	assert.InDelta(t, float64(2*time.Second), float64(s.Eta(2000)), float64(50*time.Millisecond))
+
+	// 停顿后速度逐渐下降
+	s.lastAt = time.Now().Add(-time.Second)
+	assert.InDelta(t, 700, s.Update(1100), 10)
+}
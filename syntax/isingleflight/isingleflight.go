@@ -27,3 +27,24 @@ func GenericWrapper[T any](key string, fn func() (T, error)) (T, error) {
 	}
 	return zero, nil
 }
+
+// Group 泛型 singleflight，每个 Group 的 key 互相独立
+type Group[T any] struct {
+	g singleflight.Group
+}
+
+// Do 相同 key 并发调用时只执行一次 fn，shared 表示结果是否被多个调用共享
+func (g *Group[T]) Do(key string, fn func() (T, error)) (v T, err error, shared bool) {
+	val, err, shared := g.g.Do(key, func() (interface{}, error) {
+		return fn()
+	})
+	if typedVal, ok := val.(T); ok {
+		v = typedVal
+	}
+	return v, err, shared
+}
+
+// Forget 丢弃 key 正在进行中的调用，下一次 Do 会重新执行
+func (g *Group[T]) Forget(key string) {
+	g.g.Forget(key)
+}
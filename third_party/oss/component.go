@@ -2,6 +2,7 @@ package oss
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -107,11 +108,42 @@ func (e Component) PutObjectWithSrc(uri string, objectName string) (string, stri
 			return "", "", errors.New("获取图片失败：❌：" + uri)
 		} else {
 			log.Printf(PackageName+"上传成功：✅ %s => %s", uri, objectName)
-			return p, ifile.FileHashSHA1(bytes.NewReader(filebyte)), nil
+			hash, err := ifile.FileHashSHA1(bytes.NewReader(filebyte))
+			return p, hash, err
 		}
 	}
 }
 
+// PutObjectFromUrl 提供链接，响应体直接流式上传，不整体读入内存；读取中断时用 Range 继续
+// ctx 可以取消下载，opts 为下载参数，如 idownload.WithChecksum 设置期望的校验值
+func (e Component) PutObjectFromUrl(ctx context.Context, uri string, objectName string, opts ...idownload.DownloadOption) (string, error) {
+	idown := idownload.New(
+		idownload.WithProxySocks5(e.config.ProxySocks5),
+		idownload.WithDebug(e.config.Debug),
+		idownload.WithTimeout(time.Second*20),
+	)
+	stream, err := idown.OpenStream(ctx, uri, opts...)
+	if err != nil {
+		log.Println(PackageName, "获取文件失败：❌", err)
+		return "", fmt.Errorf("获取文件失败：❌：%s %w", uri, err)
+	}
+	defer stream.Close()
+
+	var options []oss.Option
+	if stream.Size >= 0 {
+		options = append(options, oss.ContentLength(stream.Size))
+	}
+	if contentType := stream.Header.Get("Content-Type"); len(contentType) > 0 {
+		options = append(options, oss.ContentType(contentType))
+	}
+	if err := e.Client.PutObject(objectName, stream, options...); err != nil {
+		log.Println(PackageName, "上传失败：❌", err, uri)
+		return "", fmt.Errorf("上传失败：❌：%s %w", uri, err)
+	}
+	log.Printf(PackageName+"上传成功：✅ %s => %s", uri, objectName)
+	return e.CleanObjKey(objectName), nil
+}
+
 // PutObjectWithBase64 上传 - base64
 func (e Component) PutObjectWithBase64(objectNameIn string, base64File string) (string, error) {
 	b64data := base64File[strings.IndexByte(base64File, ',')+1:]
@@ -13,7 +13,7 @@ func TestPutFile(t *testing.T) {
 	img2 := "https://images.pexels.com/photos/2583852/pexels-photo-2583852.jpeg?auto=compress&cs=tinysrgb&dpr=2&h=750&w=1260"
 
 	up, _ := url.Parse(img2)
-	log.Println(ijson.Pretty(up))
+	ijson.LogPretty(up)
 	log.Println(path.IsAbs(up.Path))
 
 	if path.IsAbs(up.Path) {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/cute-angelia/go-xutils/components/caches/tagged"
 	"net/http"
 	"sync"
 	"time"
@@ -12,6 +13,7 @@ import (
 type Component struct {
 	name   string
 	config *config
+	store  *tagged.Component
 	locker sync.Mutex
 }
 
@@ -20,6 +22,7 @@ func newComponent(compName string, config *config) *Component {
 	return &Component{
 		name:   compName,
 		config: config,
+		store:  tagged.New(config.Cache),
 	}
 }
 
@@ -55,7 +58,7 @@ func (e *Component) resp(w http.ResponseWriter, code int, msg string, cacheData
 
 // Deprecated: GetCache 获取缓存
 func (e *Component) GetCache() string {
-	data, _ := e.config.Cache.Get(e.getSelfCacheKey())
+	data, _ := e.store.Get(e.getSelfCacheKey())
 	if len(data) > 6 {
 		return data
 	} else {
@@ -65,7 +68,7 @@ func (e *Component) GetCache() string {
 
 // Deprecated: GetCacheAndWriter get cache and write
 func (e *Component) GetCacheAndWriter(w http.ResponseWriter, msg string) (string, error) {
-	data, _ := e.config.Cache.Get(e.getSelfCacheKey())
+	data, _ := e.store.Get(e.getSelfCacheKey())
 	if len(data) > 6 {
 		e.resp(w, 0, msg, data)
 		return data, nil
@@ -76,7 +79,7 @@ func (e *Component) GetCacheAndWriter(w http.ResponseWriter, msg string) (string
 // Deprecated: SetCache
 func (e *Component) SetCache(data interface{}) error {
 	ds, _ := json.Marshal(data)
-	return e.config.Cache.Set(e.getSelfCacheKey(), string(ds), e.config.Timeout)
+	return e.store.SetWithTags(e.getSelfCacheKey(), string(ds), e.config.Timeout, e.config.Tags...)
 }
 
 // Deprecated: DeleteCache
@@ -88,3 +91,8 @@ func (e *Component) DeleteCache() error {
 func (e *Component) DeleteCacheAll() error {
 	return e.config.Cache.Flush()
 }
+
+// InvalidateTags 清理带有这些 tag 的缓存，见 WithTags
+func (e *Component) InvalidateTags(tags ...string) error {
+	return e.store.InvalidateTags(tags...)
+}
@@ -19,6 +19,8 @@ type config struct {
 
 	CacheKey string // 保存的 key
 
+	Tags []string // 缓存依赖的数据，InvalidateTags 任意一个后失效
+
 	OnlyToday bool // 是否凌晨刷新
 	Debug     bool
 
@@ -75,6 +75,13 @@ func WithPrefix(prefix string) Option {
 	}
 }
 
+// WithTags 给缓存打 tag，如 "article:7"，编辑文章后 InvalidateTags("article:7")
+func WithTags(tags ...string) Option {
+	return func(c *Container) {
+		c.config.Tags = tags
+	}
+}
+
 func WithPrefixMaxNum(prefixMaxNum int) Option {
 	return func(c *Container) {
 		c.config.PrefixMaxNum = prefixMaxNum
@@ -5,6 +5,7 @@ import (
 	"encoding/gob"
 	"encoding/hex"
 	"encoding/json"
+	"github.com/cute-angelia/go-xutils/components/caches/tagged"
 	"github.com/schollz/progressbar/v3"
 	"hash/fnv"
 	"io/ioutil"
@@ -24,6 +25,7 @@ const (
 
 type Component struct {
 	config *config
+	store  *tagged.Component // 响应缓存，支持 tag 失效
 	bar    *progressbar.ProgressBar
 }
 
@@ -34,6 +36,7 @@ const routeCachePrefix = "routeCP_"
 func newComponent(config *config) *Component {
 	comp := &Component{}
 	comp.config = config
+	comp.store = tagged.New(config.Store)
 	return comp
 }
 
@@ -71,13 +74,13 @@ func (c *Component) NewMiddleware(next http.Handler) http.Handler {
 				r.URL.RawQuery = params.Encode()
 				c.config.Store.Delete(key)
 			} else {
-				b, err := c.config.Store.Get(key)
+				b, err := c.store.Get(key)
 				response := c.bytesToResponse([]byte(b))
 				if err == nil {
 					if response.Expiration.After(time.Now()) {
 						response.LastAccess = time.Now()
 						response.Frequency++
-						c.config.Store.Set(key, response.String(), response.Expiration.Sub(time.Now()))
+						c.store.SetWithTags(key, response.String(), response.Expiration.Sub(time.Now()), response.Tags...)
 
 						for k, v := range response.Header {
 							w.Header().Set(k, strings.Join(v, ","))
@@ -102,6 +105,7 @@ func (c *Component) NewMiddleware(next http.Handler) http.Handler {
 				}
 			}
 
+			r, tc := withTagCollector(r)
 			rec := httptest.NewRecorder()
 			next.ServeHTTP(rec, r)
 			result := rec.Result()
@@ -123,8 +127,12 @@ func (c *Component) NewMiddleware(next http.Handler) http.Handler {
 					Expiration: expires,
 					LastAccess: now,
 					Frequency:  1,
+					Tags:       tc.list(),
 				}
-				c.config.Store.Set(key, response.String(), response.Expiration.Sub(time.Now()))
+				if c.config.TagFunc != nil {
+					response.Tags = append(response.Tags, c.config.TagFunc(r)...)
+				}
+				c.store.SetWithTags(key, response.String(), response.Expiration.Sub(time.Now()), response.Tags...)
 			}
 			for k, v := range result.Header {
 				w.Header().Set(k, strings.Join(v, ","))
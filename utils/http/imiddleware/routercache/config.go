@@ -21,6 +21,8 @@ type config struct {
 
 	CustomKey string // 自定义key
 
+	TagFunc func(r *http.Request) []string // 按请求生成 tag，handler 中也可以用 AddTags
+
 	PrintLog bool // 打印日志
 }
 
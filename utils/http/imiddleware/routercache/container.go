@@ -2,6 +2,7 @@ package routercache
 
 import (
 	"github.com/cute-angelia/go-xutils/components/caches"
+	"net/http"
 	"time"
 )
 
@@ -85,3 +86,10 @@ func WithPrintLog(printLog bool) Option {
 		c.config.PrintLog = printLog
 	}
 }
+
+// WithTagFunc 按请求给缓存的响应打 tag，如 "user:" + uid
+func WithTagFunc(f func(r *http.Request) []string) Option {
+	return func(c *Container) {
+		c.config.TagFunc = f
+	}
+}
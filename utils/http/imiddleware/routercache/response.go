@@ -28,6 +28,9 @@ type Response struct {
 	// Frequency is the count of times a cached response is accessed.
 	// Used for LFU and MFU algorithms.
 	Frequency int
+
+	// Tags 响应依赖的数据，见 AddTags
+	Tags []string
 }
 
 // Bytes converts Response data structure into bytes array.
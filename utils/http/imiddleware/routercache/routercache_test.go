@@ -0,0 +1,35 @@
+package routercache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidateTags(t *testing.T) {
+	rc := New(WithStore(mem.NewLRU(100, 0)), WithTtl(time.Minute), WithPrintLog(false))
+
+	renders := 0
+	handler := rc.NewMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders++
+		AddTags(r, "article:7")
+		w.Write([]byte("page"))
+	}))
+	get := func() string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/articles?id=7", nil))
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "page", get())
+	assert.Equal(t, "page", get())
+	assert.Equal(t, 1, renders)
+
+	assert.Nil(t, rc.InvalidateTags("article:7"))
+	assert.Equal(t, "page", get())
+	assert.Equal(t, 2, renders)
+}
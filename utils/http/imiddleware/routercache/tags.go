@@ -0,0 +1,41 @@
+package routercache
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type tagsCtxKey struct{}
+
+// tagCollector 中间件放在请求 context 中，handler 渲染时记录用到的数据
+type tagCollector struct {
+	mu   sync.Mutex
+	tags []string
+}
+
+// AddTags 在 handler 中给当前响应打 tag，如渲染了文章 7 就调用 AddTags(r, "article:7")
+// 之后 InvalidateTags("article:7") 会清理所有渲染过该文章的页面
+func AddTags(r *http.Request, tags ...string) {
+	if tc, ok := r.Context().Value(tagsCtxKey{}).(*tagCollector); ok {
+		tc.mu.Lock()
+		tc.tags = append(tc.tags, tags...)
+		tc.mu.Unlock()
+	}
+}
+
+func withTagCollector(r *http.Request) (*http.Request, *tagCollector) {
+	tc := &tagCollector{}
+	return r.WithContext(context.WithValue(r.Context(), tagsCtxKey{}, tc)), tc
+}
+
+func (tc *tagCollector) list() []string {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return append([]string(nil), tc.tags...)
+}
+
+// InvalidateTags 清理带有这些 tag 的缓存页面，多个实例共享 Store 时同样生效
+func (c *Component) InvalidateTags(tags ...string) error {
+	return c.store.InvalidateTags(tags...)
+}
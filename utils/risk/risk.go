@@ -1,10 +1,15 @@
 package risk
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
 	"time"
+
+	"github.com/cute-angelia/go-xutils/components/caches"
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
 	"github.com/patrickmn/go-cache"
-	"sync"
-	"fmt"
 )
 
 type riskRule struct {
@@ -13,71 +18,79 @@ type riskRule struct {
 	MaxCount int
 }
 
-type riskCache struct {
-	Count int
+// Store 计数器存储，需支持原子操作，多实例共享同一个 Store（如 iredis）时计数一致
+type Store interface {
+	caches.Cache
+	caches.Atomic
 }
 
 // 风控
+// 每次 Increase 都重新设置 TTL，TTL 内没有 Increase 时清零
 type Risk struct {
 	Rules map[string]riskRule
+	Store Store
+
+	// Deprecated: 计数保存在 Store 中，不再使用
 	Cache *cache.Cache
-	Lock  sync.Mutex
+	// Deprecated: Store 的操作是原子的，不再需要加锁
+	Lock sync.Mutex
 }
 
 func (self *Risk) getCacheKey(key string) string {
 	return "risk_" + key
 }
 
-func (self *Risk) getRule(key string) riskRule {
-	if v, ok := self.Rules[key]; ok {
-		return v
-	} else {
-		return riskRule{}
-	}
+func (self *Risk) getRule(key string) (riskRule, bool) {
+	v, ok := self.Rules[key]
+	return v, ok
 }
 
 func (self *Risk) Increase(key string) error {
-	self.Lock.Lock()
-	defer self.Lock.Unlock()
-
-	cacheKey := self.getCacheKey(key)
-	rule := self.getRule(key)
-
-	if &rule != nil {
-		if c, found := self.Cache.Get(cacheKey); found {
-			d := c.(riskCache)
-			d.Count += 1
-			self.Cache.Set(self.getCacheKey(key), d, rule.TTL)
-		} else {
-			c := riskCache{
-				Count: 1,
+	rule, ok := self.getRule(key)
+	if !ok {
+		return fmt.Errorf("未发现规则: %s", key)
+	}
+	return self.incr(self.getCacheKey(key), rule.TTL)
+}
+
+// incr 计数加 1 并把过期时间重新设为 ttl，写入冲突时重试
+func (self *Risk) incr(cacheKey string, ttl time.Duration) error {
+	for {
+		v, err := self.Store.Get(cacheKey)
+		if errors.Is(err, caches.ErrNotFound) {
+			if ok, err := self.Store.SetNX(cacheKey, "1", ttl); ok || err != nil {
+				return err
 			}
-			self.Cache.Set(self.getCacheKey(key), c, rule.TTL)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		count, _ := strconv.Atoi(v)
+		if ok, err := self.Store.CompareAndSwap(cacheKey, v, strconv.Itoa(count+1), ttl); ok || err != nil {
+			return err
 		}
-		return nil
-	} else {
-		return fmt.Errorf("未发现规则: %s" , key)
 	}
 }
 
 func (self *Risk) Check(key string) error {
-	self.Lock.Lock()
-	defer self.Lock.Unlock()
-
-	cacheKey := self.getCacheKey(key)
-	rule := self.getRule(key)
+	rule, ok := self.getRule(key)
+	if !ok || rule.MaxCount <= 0 {
+		return fmt.Errorf("未发现规则: %s", key)
+	}
 
-	if rule.MaxCount > 0 {
-		if c, found := self.Cache.Get(cacheKey); found {
-			d := c.(riskCache)
-			if d.Count > rule.MaxCount {
-				return fmt.Errorf("数量超过限制 now:%d => max:%d", d.Count, rule.MaxCount)
-			}
-		}
+	v, err := self.Store.Get(self.getCacheKey(key))
+	if errors.Is(err, caches.ErrNotFound) {
 		return nil
-	} else {
-		return fmt.Errorf("未发现规则: %s" , key)
 	}
+	if err != nil {
+		return err
+	}
+	count, _ := strconv.Atoi(v)
+	if count > rule.MaxCount {
+		return fmt.Errorf("数量超过限制 now:%d => max:%d", count, rule.MaxCount)
+	}
+	return nil
 }
 
 type RiskOption func(*Risk)
@@ -92,6 +105,11 @@ func NewRisk(opts ...RiskOption) *Risk {
 		opt(&sopt)
 	}
 
+	// 默认进程内存储，不限条目数，避免计数被淘汰；key 数量不超过规则数
+	if sopt.Store == nil {
+		sopt.Store = mem.NewLRU(0, 0).Atomic()
+	}
+
 	return &sopt
 }
 
@@ -104,3 +122,10 @@ func Rules(key string, ttl time.Duration, maxCount int) RiskOption {
 		}
 	}
 }
+
+// WithStore 计数器存储，如 iredis.New() 可以多实例共享
+func WithStore(store Store) RiskOption {
+	return func(options *Risk) {
+		options.Store = store
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"testing"
 	"time"
 	"log"
+	"strconv"
+
+	"github.com/cute-angelia/go-xutils/components/caches/mem"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRisk(t *testing.T) {
@@ -40,4 +44,49 @@ func TestRisk(t *testing.T) {
 
 //2019/08/07 12:59:01 check 数量超过限制 now:20 => max:10
 //2019/08/07 12:59:01 check <nil>
-//2019/08/07 12:59:01 check 未发现规则: other
\ No newline at end of file
+//2019/08/07 12:59:01 check 未发现规则: other
+func TestRiskStore(t *testing.T) {
+	risk := NewRisk(
+		Rules("reg", time.Minute, 2),
+		WithStore(mem.NewLRU(100, 0).Atomic()),
+	)
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, risk.Increase("reg"))
+		assert.Nil(t, risk.Check("reg"))
+	}
+	risk.Increase("reg")
+	assert.NotNil(t, risk.Check("reg"))
+
+	assert.NotNil(t, risk.Increase("other"))
+	assert.NotNil(t, risk.Check("other"))
+}
+
+// TestRiskSlidingTTL 每次 Increase 都重新设置过期时间
+func TestRiskSlidingTTL(t *testing.T) {
+	risk := NewRisk(Rules("reg", 150*time.Millisecond, 1))
+
+	assert.Nil(t, risk.Increase("reg"))
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, risk.Increase("reg"))
+	time.Sleep(100 * time.Millisecond)
+	assert.NotNil(t, risk.Check("reg"))
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Nil(t, risk.Check("reg"))
+}
+
+// TestRiskDefaultStore 默认存储不淘汰计数
+func TestRiskDefaultStore(t *testing.T) {
+	var opts []RiskOption
+	for i := 0; i <= 10000; i++ {
+		opts = append(opts, Rules(strconv.Itoa(i), time.Minute, 1))
+	}
+	risk := NewRisk(opts...)
+
+	for i := 0; i <= 10000; i++ {
+		assert.Nil(t, risk.Increase(strconv.Itoa(i)))
+	}
+	assert.Nil(t, risk.Increase("0"))
+	assert.NotNil(t, risk.Check("0"))
+}